@@ -0,0 +1,281 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var log = ctrl.Log.WithName("compliance-api-reporter")
+
+// ComplianceEvent is the request body of the compliance history API on the hub.
+type ComplianceEvent struct {
+	Cluster      Cluster       `json:"cluster"`
+	ParentPolicy *ParentPolicy `json:"parent_policy,omitempty"`
+	Policy       Policy        `json:"policy"`
+	Event        Event         `json:"event"`
+}
+
+type Cluster struct {
+	Name      string `json:"name"`
+	ClusterID string `json:"cluster_id,omitempty"`
+}
+
+type ParentPolicy struct {
+	Name       string   `json:"name"`
+	Namespace  string   `json:"namespace"`
+	Categories []string `json:"categories,omitempty"`
+	Controls   []string `json:"controls,omitempty"`
+	Standards  []string `json:"standards,omitempty"`
+}
+
+// Policy is the policy template that the compliance event is about.
+type Policy struct {
+	APIGroup  string                 `json:"apiGroup"`
+	Kind      string                 `json:"kind"`
+	Name      string                 `json:"name"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Spec      map[string]interface{} `json:"spec,omitempty"`
+	SpecHash  string                 `json:"specHash,omitempty"`
+	Severity  string                 `json:"severity,omitempty"`
+}
+
+type Event struct {
+	Compliance string                 `json:"compliance"`
+	Message    string                 `json:"message"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	ReportedBy string                 `json:"reported_by,omitempty"`
+}
+
+// errPermanent wraps errors from requests that will never succeed, so they aren't retried.
+var errPermanent = errors.New("the compliance event was rejected")
+
+// errUnauthorized wraps authentication and authorization failures, which are only retried up to MaxAuthRetries times.
+var errUnauthorized = errors.New("the compliance event was not authorized")
+
+// Reporter POSTs compliance events to the compliance history API on the hub. Events are buffered in memory and sent
+// in order by a single worker, which retries failures with an exponential backoff for as long as it takes, except for
+// authentication and authorization failures. When the buffer is full, new events are dropped. It implements
+// manager.Runnable.
+type Reporter struct {
+	// URL is the compliance events endpoint, such as https://<host>/api/v1/compliance-events. It's changed with SetHub
+	// once the Reporter is started.
+	URL string
+	// Client authenticates with the credentials of the hub kubeconfig. It's changed with SetHub once the Reporter is
+	// started.
+	Client *http.Client
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+	// MaxAuthRetries is the number of times an event is retried after an authentication or authorization failure,
+	// which can be resolved by a credential rotation or an RBAC change, before it's dropped.
+	MaxAuthRetries int
+	queue          chan ComplianceEvent
+	lock           sync.RWMutex
+}
+
+// NewReporter returns a Reporter for the endpoint that buffers up to bufferSize events. It authenticates like a client
+// of the hub API server with the hub config, such as with a client certificate, a token file that is read again when
+// it's rotated, or an exec plugin. The hub CA is trusted in addition to the system CAs since the API is commonly served
+// with the same CA as the hub API server.
+func NewReporter(url string, hubConfig *rest.Config, bufferSize int) (*Reporter, error) {
+	client, err := newHubClient(hubConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reporter{
+		URL:            url,
+		Client:         client,
+		MaxBackoff:     5 * time.Minute,
+		MaxAuthRetries: 5,
+		queue:          make(chan ComplianceEvent, bufferSize),
+	}, nil
+}
+
+// SetHub points the Reporter to the endpoint with the credentials of the hub config, such as after the hub changed.
+// The buffered compliance events and the one being retried are sent to the new endpoint.
+func (r *Reporter) SetHub(url string, hubConfig *rest.Config) error {
+	client, err := newHubClient(hubConfig)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	previous := r.Client
+	r.URL = url
+	r.Client = client
+	r.lock.Unlock()
+
+	if previous != nil {
+		previous.CloseIdleConnections()
+	}
+
+	return nil
+}
+
+// newHubClient returns an HTTP client that authenticates with the credentials of the hub config and trusts the hub CA
+// in addition to the system CAs.
+func newHubClient(hubConfig *rest.Config) (*http.Client, error) {
+	transportCfg, err := hubConfig.TransportConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hub transport config: %w", err)
+	}
+
+	tlsConfig, err := transport.TLSConfigFor(transportCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hub TLS config: %w", err)
+	}
+
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	caData := hubConfig.CAData
+	if len(caData) == 0 && hubConfig.CAFile != "" {
+		caData, err = os.ReadFile(hubConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the hub CA file: %w", err)
+		}
+	}
+
+	if len(caData) != 0 {
+		rootCAs.AppendCertsFromPEM(caData)
+	}
+
+	tlsConfig.RootCAs = rootCAs
+
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	httpTransport.TLSClientConfig = tlsConfig
+
+	roundTripper, err := transport.HTTPWrappersForConfig(transportCfg, httpTransport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hub authentication: %w", err)
+	}
+
+	return &http.Client{Transport: roundTripper, Timeout: 30 * time.Second}, nil
+}
+
+// Report buffers the compliance event to be sent. It never blocks. A nil Reporter is a no-op.
+func (r *Reporter) Report(event ComplianceEvent) {
+	if r == nil {
+		return
+	}
+
+	select {
+	case r.queue <- event:
+	default:
+		log.Info(
+			"The compliance event buffer is full. Dropping the compliance event.",
+			"policy", event.Policy.Name,
+			"kind", event.Policy.Kind,
+			"timestamp", event.Event.Timestamp,
+		)
+	}
+}
+
+// Start sends the buffered compliance events until the context is canceled.
+func (r *Reporter) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-r.queue:
+			r.sendWithRetries(ctx, event)
+		}
+	}
+}
+
+func (r *Reporter) sendWithRetries(ctx context.Context, event ComplianceEvent) {
+	backoff := min(time.Second, r.MaxBackoff)
+	authFailures := 0
+
+	for {
+		err := r.send(ctx, event)
+		if err == nil {
+			return
+		}
+
+		if errors.Is(err, errUnauthorized) {
+			authFailures++
+		}
+
+		if errors.Is(err, errPermanent) || authFailures > r.MaxAuthRetries {
+			log.Error(err, "Dropping the compliance event", "policy", event.Policy.Name, "kind", event.Policy.Kind)
+
+			return
+		}
+
+		log.Info("Failed to send the compliance event, will retry", "error", err.Error(), "retryAfter", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, r.MaxBackoff)
+	}
+}
+
+func (r *Reporter) send(ctx context.Context, event ComplianceEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+
+	r.lock.RLock()
+	url, client := r.URL, r.Client
+	r.lock.RUnlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	// The compliance event was already recorded, such as when a previous request timed out after it was processed.
+	case resp.StatusCode == http.StatusConflict:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w with the status code %d: %s", errUnauthorized, resp.StatusCode, respBody)
+	// Throttling and server errors are transient.
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return fmt.Errorf("the compliance API returned the status code %d: %s", resp.StatusCode, respBody)
+	default:
+		return fmt.Errorf("%w with the status code %d: %s", errPermanent, resp.StatusCode, respBody)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestReporterRetriesWithToken(t *testing.T) {
+	t.Parallel()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("hub-token\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write the token file: %v", err)
+	}
+
+	var attempts atomic.Int32
+
+	received := make(chan ComplianceEvent, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer hub-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		// Fail the first attempt to verify the retry.
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		event := ComplianceEvent{}
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		received <- event
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	reporter, err := NewReporter(server.URL, &rest.Config{BearerTokenFile: tokenFile}, 10)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	reporter.MaxBackoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = reporter.Start(ctx)
+	}()
+
+	for _, name := range []string{"config-a", "config-b"} {
+		reporter.Report(ComplianceEvent{
+			Cluster: Cluster{Name: "cluster1"},
+			Policy:  Policy{APIGroup: "policy.open-cluster-management.io", Kind: "ConfigurationPolicy", Name: name},
+			Event:   Event{Compliance: "NonCompliant", Message: "violation", Timestamp: time.Now()},
+		})
+	}
+
+	// The events are sent in order, and the failed event isn't skipped.
+	for _, name := range []string{"config-a", "config-b"} {
+		select {
+		case event := <-received:
+			if event.Policy.Name != name || event.Cluster.Name != "cluster1" {
+				t.Fatalf("Expected the event for %s but got: %v", name, event)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for the event for %s", name)
+		}
+	}
+}
+
+func TestReporterSendStatusCodes(t *testing.T) {
+	t.Parallel()
+
+	tests := map[int]struct {
+		expectErr          bool
+		expectPermanent    bool
+		expectUnauthorized bool
+	}{
+		http.StatusCreated:             {},
+		http.StatusConflict:            {},
+		http.StatusUnauthorized:        {expectErr: true, expectUnauthorized: true},
+		http.StatusForbidden:           {expectErr: true, expectUnauthorized: true},
+		http.StatusServiceUnavailable:  {expectErr: true},
+		http.StatusBadRequest:          {expectErr: true, expectPermanent: true},
+		http.StatusUnprocessableEntity: {expectErr: true, expectPermanent: true},
+	}
+
+	for statusCode, test := range tests {
+		t.Run(http.StatusText(statusCode), func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(statusCode)
+			}))
+			defer server.Close()
+
+			reporter, err := NewReporter(server.URL, &rest.Config{BearerToken: "hub-token"}, 1)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			err = reporter.send(context.TODO(), ComplianceEvent{})
+			if (err != nil) != test.expectErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if isPermanent := err != nil && errors.Is(err, errPermanent); isPermanent != test.expectPermanent {
+				t.Fatalf("Expected the error to be permanent: %t, but got: %v", test.expectPermanent, err)
+			}
+
+			if isUnauthorized := errors.Is(err, errUnauthorized); isUnauthorized != test.expectUnauthorized {
+				t.Fatalf("Expected the error to be unauthorized: %t, but got: %v", test.expectUnauthorized, err)
+			}
+		})
+	}
+}
+
+func TestReporterCapsAuthRetries(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	reporter, err := NewReporter(server.URL, &rest.Config{BearerToken: "hub-token"}, 1)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	reporter.MaxBackoff = time.Millisecond
+	reporter.MaxAuthRetries = 2
+
+	done := make(chan struct{})
+
+	go func() {
+		reporter.sendWithRetries(context.TODO(), ComplianceEvent{})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the event to be dropped")
+	}
+
+	if attempts.Load() != 3 {
+		t.Fatalf("Expected the event to be sent 3 times but got %d", attempts.Load())
+	}
+}
+
+func TestReporterClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "system:open-cluster-management:cluster1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create the certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal the key: %v", err)
+	}
+
+	clientCert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatalf("Failed to parse the certificate: %v", err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	reporter, err := NewReporter(server.URL, &rest.Config{TLSClientConfig: rest.TLSClientConfig{
+		CAData:   serverCA,
+		CertData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		KeyData:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}}, 1)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if err := reporter.send(context.TODO(), ComplianceEvent{}); err != nil {
+		t.Fatalf("Expected the event to be sent with the client certificate but got: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package compliancemetrics
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const ControllerName = "policy-compliance-metrics"
+
+// SetupWithManager sets up the controller with the Manager. All Policy events are mapped to a single request, so bursts
+// of compliance updates are coalesced by the work queue.
+func (r *ComplianceMetricsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		WithLogConstructor(func(req *reconcile.Request) logr.Logger {
+			return utils.LogConstructor(ControllerName, "Policy", req)
+		}).
+		Watches(
+			&policiesv1.Policy{},
+			handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: ControllerName}}}
+			}),
+		).
+		Complete(r)
+}
+
+// blank assignment to verify that ComplianceMetricsReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ComplianceMetricsReconciler{}
+
+// ComplianceMetricsReconciler computes the compliance metrics of the policies in the cluster namespaces and exposes
+// them through the Collector.
+type ComplianceMetricsReconciler struct {
+	client.Client
+	// ClusterNamespaces are the namespaces of the replicated policies of the hub and of the hub sources.
+	ClusterNamespaces []string
+	Collector         *Collector
+}
+
+// Reconcile lists the policies in the cluster namespaces and replaces the series of the Collector.
+func (r *ComplianceMetricsReconciler) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	policies := []policiesv1.Policy{}
+
+	for _, namespace := range r.ClusterNamespaces {
+		namespacePolicies := &policiesv1.PolicyList{}
+
+		if err := r.List(ctx, namespacePolicies, client.InNamespace(namespace)); err != nil {
+			log.Error(err, "Failed to list the policies", "namespace", namespace)
+
+			return reconcile.Result{}, err
+		}
+
+		policies = append(policies, namespacePolicies.Items...)
+	}
+
+	dropped := r.Collector.update(policies)
+	if dropped > 0 {
+		log.Info(
+			"The compliance metrics exceed the series limit, so some per-policy and per-template series were dropped",
+			"limit", r.Collector.maxSeries, "dropped", dropped,
+		)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// update computes the series of the input policies and replaces the series of the collector. The per-policy series are
+// kept before the per-template series when the series limit is reached, and the number of dropped series is returned.
+func (c *Collector) update(policies []policiesv1.Policy) int {
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Namespace != policies[j].Namespace {
+			return policies[i].Namespace < policies[j].Namespace
+		}
+
+		return policies[i].Name < policies[j].Name
+	})
+
+	policySamples := []sample{}
+	templateSamples := []sample{}
+	rollups := map[*prometheus.Desc]map[string]map[string]int{
+		c.standardDesc: {},
+		c.categoryDesc: {},
+		c.controlDesc:  {},
+	}
+
+	for i := range policies {
+		policy := &policies[i]
+
+		if policy.DeletionTimestamp != nil {
+			continue
+		}
+
+		labelValues := make([]string, 0, len(c.labelKeys))
+		for _, key := range c.labelKeys {
+			labelValues = append(labelValues, policy.Labels[key])
+		}
+
+		policySamples = append(policySamples, sample{
+			desc:        c.policyDesc,
+			value:       complianceValue(policy.Status.ComplianceState),
+			labelValues: append([]string{policy.Namespace, policy.Name}, labelValues...),
+		})
+
+		for _, policyT := range policy.Spec.PolicyTemplates {
+			tmpl := utils.TemplateObject(policyT)
+			if tmpl == nil {
+				continue
+			}
+
+			var state policiesv1.ComplianceState
+
+			for _, dpt := range policy.Status.Details {
+				if dpt != nil && dpt.TemplateMeta.Name == tmpl.GetName() {
+					state = dpt.ComplianceState
+
+					break
+				}
+			}
+
+			templateSamples = append(templateSamples, sample{
+				desc:  c.templateDesc,
+				value: complianceValue(state),
+				labelValues: append(
+					[]string{
+						policy.Namespace, policy.Name, tmpl.GetName(), tmpl.GroupVersionKind().GroupKind().String(),
+					},
+					labelValues...,
+				),
+			})
+		}
+
+		annotations := policy.GetAnnotations()
+		state := stateLabel(policy.Status.ComplianceState)
+
+		for desc, annotation := range map[*prometheus.Desc]string{
+			c.standardDesc: annotations[common.APIGroup+"/standards"],
+			c.categoryDesc: annotations[common.APIGroup+"/categories"],
+			c.controlDesc:  annotations[common.APIGroup+"/controls"],
+		} {
+			for _, value := range utils.SplitAnnotation(annotation) {
+				if rollups[desc][value] == nil {
+					rollups[desc][value] = map[string]int{}
+				}
+
+				rollups[desc][value][state]++
+			}
+		}
+	}
+
+	samples := []sample{}
+
+	for _, desc := range []*prometheus.Desc{c.standardDesc, c.categoryDesc, c.controlDesc} {
+		for value, counts := range rollups[desc] {
+			for _, state := range complianceStates {
+				samples = append(samples, sample{
+					desc: desc, value: float64(counts[state]), labelValues: []string{value, state},
+				})
+			}
+		}
+	}
+
+	limited := append(policySamples, templateSamples...)
+	dropped := 0
+
+	if c.maxSeries > 0 && len(limited) > c.maxSeries {
+		dropped = len(limited) - c.maxSeries
+		limited = limited[:c.maxSeries]
+	}
+
+	c.set(append(samples, limited...), dropped)
+
+	return dropped
+}
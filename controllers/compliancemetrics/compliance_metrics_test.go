@@ -0,0 +1,124 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package compliancemetrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	testutils "open-cluster-management.io/governance-policy-framework-addon/test/utils"
+)
+
+// reportedPolicy returns a policy of the team with a ConfigurationPolicy template of the same compliance state.
+func reportedPolicy(name string, team string, state policiesv1.ComplianceState) policiesv1.Policy {
+	meta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "managed",
+		Labels:    map[string]string{"example.com/team": team},
+		Annotations: map[string]string{
+			"policy.open-cluster-management.io/standards": "NIST SP 800-53",
+			"policy.open-cluster-management.io/controls":  "CM-2 Baseline Configuration",
+		},
+	}
+
+	return testutils.NewPolicy(meta, state, testutils.PolicyTemplate{Name: name + "-config", ComplianceState: state})
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector([]string{"example.com/team"}, 0)
+	// A policy of a hub source can have the name of a policy of the hub since it's in another namespace.
+	sourcePolicy := reportedPolicy("b", "apps", policiesv1.Compliant)
+	sourcePolicy.Namespace = "managed-hub2"
+
+	dropped := collector.update([]policiesv1.Policy{
+		sourcePolicy,
+		reportedPolicy("b", "apps", policiesv1.NonCompliant),
+		reportedPolicy("a", "platform", policiesv1.Compliant),
+	})
+
+	if dropped != 0 {
+		t.Fatalf("expected no dropped series, got %d", dropped)
+	}
+
+	expected := `
+# HELP policy_compliance The compliance of a policy: 0 when compliant, 1 when noncompliant and -1 when pending ` +
+		`or unknown
+# TYPE policy_compliance gauge
+policy_compliance{label_example_com_team="platform",namespace="managed",policy="a"} 0
+policy_compliance{label_example_com_team="apps",namespace="managed",policy="b"} 1
+policy_compliance{label_example_com_team="apps",namespace="managed-hub2",policy="b"} 0
+# HELP policy_compliance_by_control The number of policies in each compliance state per value of the controls ` +
+		`annotation
+# TYPE policy_compliance_by_control gauge
+policy_compliance_by_control{control="CM-2 Baseline Configuration",state="compliant"} 2
+policy_compliance_by_control{control="CM-2 Baseline Configuration",state="noncompliant"} 1
+policy_compliance_by_control{control="CM-2 Baseline Configuration",state="pending"} 0
+policy_compliance_by_control{control="CM-2 Baseline Configuration",state="unknown"} 0
+`
+
+	err := testutil.CollectAndCompare(
+		collector, strings.NewReader(expected), "policy_compliance", "policy_compliance_by_control",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := testutil.CollectAndCount(collector, "policy_template_compliance"); count != 3 {
+		t.Fatalf("expected 3 policy template series, got %d", count)
+	}
+
+	// Deleted policies no longer have series.
+	collector.update([]policiesv1.Policy{reportedPolicy("a", "platform", policiesv1.Compliant)})
+
+	if count := testutil.CollectAndCount(collector, "policy_compliance"); count != 1 {
+		t.Fatalf("expected 1 policy series, got %d", count)
+	}
+}
+
+func TestCollectorMaxSeries(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector(nil, 3)
+	dropped := collector.update([]policiesv1.Policy{
+		reportedPolicy("a", "", policiesv1.Compliant),
+		reportedPolicy("b", "", policiesv1.Pending),
+	})
+
+	if dropped != 1 {
+		t.Fatalf("expected 1 dropped series, got %d", dropped)
+	}
+
+	// The per-policy series are kept before the per-template series.
+	if count := testutil.CollectAndCount(collector, "policy_compliance"); count != 2 {
+		t.Fatalf("expected 2 policy series, got %d", count)
+	}
+
+	if count := testutil.CollectAndCount(collector, "policy_template_compliance"); count != 1 {
+		t.Fatalf("expected 1 policy template series, got %d", count)
+	}
+
+	// The rollup series aren't limited.
+	if count := testutil.CollectAndCount(collector, "policy_compliance_by_standard"); count != 4 {
+		t.Fatalf("expected 4 standard series, got %d", count)
+	}
+
+	expected := `
+# HELP policy_compliance_metrics_dropped_series The number of per-policy and per-template compliance series that ` +
+		`were dropped due to the series limit
+# TYPE policy_compliance_metrics_dropped_series gauge
+policy_compliance_metrics_dropped_series 1
+`
+
+	err := testutil.CollectAndCompare(
+		collector, strings.NewReader(expected), "policy_compliance_metrics_dropped_series",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package compliancemetrics
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// The compliance states in the state label of the rollup metrics.
+var complianceStates = []string{"compliant", "noncompliant", "pending", "unknown"}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// sample is a single series of a metric.
+type sample struct {
+	desc        *prometheus.Desc
+	value       float64
+	labelValues []string
+}
+
+// Collector exposes the compliance metrics computed by the ComplianceMetricsReconciler. The series are replaced as a
+// whole on each reconcile, so series of deleted policies disappear without being tracked, and a scrape never sees a
+// partial update.
+type Collector struct {
+	maxSeries    int
+	labelKeys    []string
+	policyDesc   *prometheus.Desc
+	templateDesc *prometheus.Desc
+	standardDesc *prometheus.Desc
+	categoryDesc *prometheus.Desc
+	controlDesc  *prometheus.Desc
+	droppedDesc  *prometheus.Desc
+
+	lock          sync.RWMutex
+	samples       []sample
+	droppedSeries int
+}
+
+// NewCollector returns a Collector that adds the Policy labels in labelAllowlist as metric labels, prefixed with
+// "label_", to the per-policy and per-template metrics. Those metrics are limited to maxSeries series, where 0 means no
+// limit. The rollup metrics are bounded by the annotation values and are always exposed.
+func NewCollector(labelAllowlist []string, maxSeries int) *Collector {
+	c := &Collector{maxSeries: maxSeries}
+
+	labelNames := []string{}
+	seen := map[string]bool{}
+
+	for _, key := range labelAllowlist {
+		name := "label_" + invalidLabelChars.ReplaceAllString(key, "_")
+		if !seen[name] {
+			seen[name] = true
+			labelNames = append(labelNames, name)
+			c.labelKeys = append(c.labelKeys, key)
+		}
+	}
+
+	c.policyDesc = prometheus.NewDesc(
+		"policy_compliance",
+		"The compliance of a policy: 0 when compliant, 1 when noncompliant and -1 when pending or unknown",
+		append([]string{"namespace", "policy"}, labelNames...), nil,
+	)
+	c.templateDesc = prometheus.NewDesc(
+		"policy_template_compliance",
+		"The compliance of a policy template: 0 when compliant, 1 when noncompliant and -1 when pending or unknown",
+		append([]string{"namespace", "policy", "template", "kind"}, labelNames...), nil,
+	)
+	c.standardDesc = prometheus.NewDesc(
+		"policy_compliance_by_standard",
+		"The number of policies in each compliance state per value of the standards annotation",
+		[]string{"standard", "state"}, nil,
+	)
+	c.categoryDesc = prometheus.NewDesc(
+		"policy_compliance_by_category",
+		"The number of policies in each compliance state per value of the categories annotation",
+		[]string{"category", "state"}, nil,
+	)
+	c.controlDesc = prometheus.NewDesc(
+		"policy_compliance_by_control",
+		"The number of policies in each compliance state per value of the controls annotation",
+		[]string{"control", "state"}, nil,
+	)
+	c.droppedDesc = prometheus.NewDesc(
+		"policy_compliance_metrics_dropped_series",
+		"The number of per-policy and per-template compliance series that were dropped due to the series limit",
+		nil, nil,
+	)
+
+	return c
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.policyDesc, c.templateDesc, c.standardDesc, c.categoryDesc, c.controlDesc, c.droppedDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for _, s := range c.samples {
+		ch <- prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, s.value, s.labelValues...)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.droppedDesc, prometheus.GaugeValue, float64(c.droppedSeries))
+}
+
+// set replaces the series exposed by the collector.
+func (c *Collector) set(samples []sample, droppedSeries int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.samples = samples
+	c.droppedSeries = droppedSeries
+}
+
+// complianceValue returns the metric value of a compliance state.
+func complianceValue(state policiesv1.ComplianceState) float64 {
+	switch state {
+	case policiesv1.Compliant:
+		return 0
+	case policiesv1.NonCompliant:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// stateLabel returns the state label value of a compliance state in the rollup metrics.
+func stateLabel(state policiesv1.ComplianceState) string {
+	switch state {
+	case policiesv1.Compliant:
+		return "compliant"
+	case policiesv1.NonCompliant:
+		return "noncompliant"
+	case policiesv1.Pending:
+		return "pending"
+	default:
+		return "unknown"
+	}
+}
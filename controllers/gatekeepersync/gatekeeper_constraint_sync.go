@@ -377,7 +377,9 @@ func (r *GatekeeperConstraintReconciler) sendComplianceEvent(
 
 	if len(refreshedPolicy.Status.Details) < templateIndex+1 ||
 		len(refreshedPolicy.Status.Details[templateIndex].History) == 0 ||
-		refreshedPolicy.Status.Details[templateIndex].History[0].Message != fmt.Sprintf("%s; %s", compliance, msg) {
+		refreshedPolicy.Status.Details[templateIndex].History[0].Message != utils.TruncateMessage(
+			fmt.Sprintf("%s; %s", compliance, msg), r.MaxMessageBytes,
+		) {
 		//#nosec G401
 		msgSHA1 := sha1.Sum([]byte(msg))
 		if existingMsgSHA1, ok := r.lastSentMessages.Load(kn); ok && existingMsgSHA1.([20]byte) == msgSHA1 {
@@ -0,0 +1,223 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package hubfailover
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// DefaultInterval is the default interval at which the hubs are probed.
+	DefaultInterval = 10 * time.Second
+	probeTimeout    = 10 * time.Second
+)
+
+var log = ctrl.Log.WithName("hub-failover")
+
+// Hub is one of the kubeconfigs of the same logical hub.
+type Hub struct {
+	// Name is the path of the kubeconfig of the hub, which also identifies it in the logs.
+	Name   string
+	Config *rest.Config
+	// ComplianceAPIURL is the compliance events endpoint of the compliance history API on the hub. It's optional.
+	ComplianceAPIURL string
+}
+
+// ProbeFunc returns an error when the hub is unreachable.
+type ProbeFunc func(ctx context.Context, cfg *rest.Config) error
+
+// Probe is the default ProbeFunc, which gets the Kubernetes version of the hub.
+func Probe(ctx context.Context, cfg *rest.Config) error {
+	cfg = rest.CopyConfig(cfg)
+	cfg.Timeout = probeTimeout
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	return clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
+
+// SelectHub returns the index of the first reachable hub. When no hub is reachable, the first hub is returned so that
+// the addon waits for the preferred hub.
+func SelectHub(ctx context.Context, hubs []Hub, probe ProbeFunc) int {
+	for i, hub := range hubs {
+		err := probe(ctx, hub.Config)
+		if err == nil {
+			return i
+		}
+
+		log.Info("The hub is unreachable", "hub", hub.Name, "error", err.Error())
+	}
+
+	return 0
+}
+
+// Monitor probes the hubs and determines when to fail over from the active hub, when it's unreachable for Period,
+// or when to fail back to a preferred hub, when it's reachable for Period. The hub to change to is returned by Pending,
+// and the hub manager is rebuilt in place for it, after which it's marked as the active hub with Activate.
+type Monitor struct {
+	// Hubs are the kubeconfigs of the same logical hub, in the order of preference.
+	Hubs     []Hub
+	Period   time.Duration
+	Interval time.Duration
+	Probe    ProbeFunc
+
+	lock sync.RWMutex
+	// active is the index of the hub that the addon is connected to.
+	active           int
+	unreachableSince time.Time
+	reachableSince   map[int]time.Time
+	target           int
+}
+
+// NewMonitor returns a Monitor of the hubs that is connected to the active hub, which probes the hubs at the
+// DefaultInterval with Probe.
+func NewMonitor(hubs []Hub, active int, period time.Duration) *Monitor {
+	return &Monitor{
+		Hubs:           hubs,
+		active:         active,
+		Period:         period,
+		Interval:       DefaultInterval,
+		Probe:          Probe,
+		reachableSince: map[int]time.Time{},
+		target:         active,
+	}
+}
+
+// Start probes the hubs at every Interval until the context is canceled.
+func (m *Monitor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			reachable, active := m.probeHubs(ctx)
+
+			m.evaluate(time.Now(), active, reachable)
+		}
+	}
+}
+
+// NeedLeaderElection is false so that every replica changes hubs.
+func (m *Monitor) NeedLeaderElection() bool {
+	return false
+}
+
+// Pending returns the index of the hub to change to, and whether it's not the active hub.
+func (m *Monitor) Pending() (int, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.target, m.target != m.active
+}
+
+// Activate marks the hub at the index as the hub that the addon is connected to, and starts recording the reachability
+// of the hubs relative to it.
+func (m *Monitor) Activate(index int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.active = index
+	m.target = index
+	m.unreachableSince = time.Time{}
+	m.reachableSince = map[int]time.Time{}
+}
+
+// probeHubs returns which hubs are reachable and the index of the active hub that they were probed for. The hubs after
+// the active hub are only probed when the active hub is unreachable, and are otherwise reported as unreachable.
+func (m *Monitor) probeHubs(ctx context.Context) ([]bool, int) {
+	m.lock.RLock()
+	active := m.active
+	m.lock.RUnlock()
+
+	reachable := make([]bool, len(m.Hubs))
+
+	for i := 0; i <= active; i++ {
+		reachable[i] = m.probe(ctx, i)
+	}
+
+	if !reachable[active] {
+		for i := active + 1; i < len(m.Hubs); i++ {
+			reachable[i] = m.probe(ctx, i)
+		}
+	}
+
+	return reachable, active
+}
+
+func (m *Monitor) probe(ctx context.Context, index int) bool {
+	err := m.Probe(ctx, m.Hubs[index].Config)
+	if err != nil {
+		log.V(1).Info("The hub is unreachable", "hub", m.Hubs[index].Name, "error", err.Error())
+	}
+
+	return err == nil
+}
+
+// evaluate records the reachability of the hubs at the given time and sets the hub to change to. The addon fails back
+// to the first preferred hub that has been reachable for Period. Otherwise, when the active hub has been unreachable
+// for Period, it fails over to the first reachable hub. The reachability is discarded when the hubs were probed for
+// another active hub, since the hub was changed while probing.
+func (m *Monitor) evaluate(now time.Time, active int, reachable []bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if active != m.active {
+		return
+	}
+
+	if reachable[m.active] {
+		m.unreachableSince = time.Time{}
+	} else if m.unreachableSince.IsZero() {
+		m.unreachableSince = now
+	}
+
+	for i := 0; i < m.active; i++ {
+		if !reachable[i] {
+			delete(m.reachableSince, i)
+		} else if _, ok := m.reachableSince[i]; !ok {
+			m.reachableSince[i] = now
+		}
+	}
+
+	target := m.active
+
+	for i := 0; i < m.active; i++ {
+		if since, ok := m.reachableSince[i]; ok && now.Sub(since) >= m.Period {
+			target = i
+
+			break
+		}
+	}
+
+	if target == m.active && !m.unreachableSince.IsZero() && now.Sub(m.unreachableSince) >= m.Period {
+		for i := range m.Hubs {
+			if i != m.active && reachable[i] {
+				target = i
+
+				break
+			}
+		}
+	}
+
+	if target != m.target {
+		if target == m.active {
+			log.Info("The hub change is no longer needed", "hub", m.Hubs[m.active].Name)
+		} else {
+			log.Info("Changing hubs", "from", m.Hubs[m.active].Name, "to", m.Hubs[target].Name)
+		}
+	}
+
+	m.target = target
+}
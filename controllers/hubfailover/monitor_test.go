@@ -0,0 +1,116 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package hubfailover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func testHubs() []Hub {
+	return []Hub{
+		{Name: "primary", Config: &rest.Config{Host: "https://primary"}},
+		{Name: "standby", Config: &rest.Config{Host: "https://standby"}},
+		{Name: "standby2", Config: &rest.Config{Host: "https://standby2"}},
+	}
+}
+
+func TestSelectHub(t *testing.T) {
+	t.Parallel()
+
+	probe := func(reachable ...string) ProbeFunc {
+		return func(_ context.Context, cfg *rest.Config) error {
+			for _, host := range reachable {
+				if cfg.Host == "https://"+host {
+					return nil
+				}
+			}
+
+			return errors.New("connection refused")
+		}
+	}
+
+	if active := SelectHub(context.TODO(), testHubs(), probe("primary", "standby")); active != 0 {
+		t.Errorf("Expected the primary hub but got %d", active)
+	}
+
+	if active := SelectHub(context.TODO(), testHubs(), probe("standby2")); active != 2 {
+		t.Errorf("Expected the second standby hub but got %d", active)
+	}
+
+	if active := SelectHub(context.TODO(), testHubs(), probe()); active != 0 {
+		t.Errorf("Expected the primary hub when none are reachable but got %d", active)
+	}
+}
+
+func TestEvaluateFailover(t *testing.T) {
+	t.Parallel()
+
+	monitor := NewMonitor(testHubs(), 0, time.Minute)
+	start := time.Now()
+
+	monitor.evaluate(start, 0, []bool{false, false, false})
+	monitor.evaluate(start.Add(time.Minute), 0, []bool{false, false, false})
+
+	if _, pending := monitor.Pending(); pending {
+		t.Fatal("Expected no failover when no hub is reachable")
+	}
+
+	monitor.evaluate(start.Add(2*time.Minute), 0, []bool{false, false, true})
+
+	if target, pending := monitor.Pending(); !pending || target != 2 {
+		t.Fatalf("Expected a failover to the second standby hub but got %d", target)
+	}
+
+	monitor.evaluate(start.Add(3*time.Minute), 0, []bool{true, false, true})
+
+	if _, pending := monitor.Pending(); pending {
+		t.Fatal("Expected no failover once the primary hub is reachable")
+	}
+
+	monitor.evaluate(start.Add(4*time.Minute), 0, []bool{false, false, true})
+	monitor.Activate(2)
+
+	if _, pending := monitor.Pending(); pending {
+		t.Fatal("Expected no hub change once the second standby hub is active")
+	}
+
+	// The reachability of the hubs probed for the previous active hub is discarded.
+	monitor.evaluate(start.Add(5*time.Minute), 0, []bool{true, true, true})
+	monitor.evaluate(start.Add(10*time.Minute), 0, []bool{true, true, true})
+
+	if _, pending := monitor.Pending(); pending {
+		t.Fatal("Expected no failback from the reachability probed for the previous active hub")
+	}
+}
+
+func TestEvaluateFailback(t *testing.T) {
+	t.Parallel()
+
+	monitor := NewMonitor(testHubs(), 2, time.Minute)
+	start := time.Now()
+
+	monitor.evaluate(start, 2, []bool{false, true, true})
+	monitor.evaluate(start.Add(30*time.Second), 2, []bool{true, true, true})
+	monitor.evaluate(start.Add(time.Minute), 2, []bool{true, true, true})
+
+	if target, _ := monitor.Pending(); target != 1 {
+		t.Fatalf("Expected a failback to the first standby hub but got %d", target)
+	}
+
+	monitor.evaluate(start.Add(90*time.Second), 2, []bool{true, true, true})
+
+	if target, _ := monitor.Pending(); target != 0 {
+		t.Fatalf("Expected a failback to the primary hub but got %d", target)
+	}
+
+	monitor.evaluate(start.Add(2*time.Minute), 2, []bool{false, false, true})
+
+	if _, pending := monitor.Pending(); pending {
+		t.Fatal("Expected no failback once the preferred hubs are unreachable")
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package hubsnapshot
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/secretsync"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// OfflineClient is a read-only client to the hub that serves the policies and the policy-encryption-key Secret from a
+// snapshot while the hub is unreachable. Other objects are not found, and writes fail with utils.ErrHubOffline.
+type OfflineClient struct {
+	snapshot       *Snapshot
+	namespaceOnHub string
+	scheme         *runtime.Scheme
+}
+
+// blank assignment to verify that OfflineClient implements client.Client
+var _ client.Client = &OfflineClient{}
+
+// NewOfflineClient returns an OfflineClient for the snapshot of the cluster namespace on the hub. A nil snapshot is
+// served as an empty hub.
+func NewOfflineClient(snapshot *Snapshot, namespaceOnHub string, scheme *runtime.Scheme) *OfflineClient {
+	if snapshot == nil {
+		snapshot = &Snapshot{}
+	}
+
+	return &OfflineClient{snapshot: snapshot, namespaceOnHub: namespaceOnHub, scheme: scheme}
+}
+
+func (c *OfflineClient) Get(
+	_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption,
+) error {
+	if key.Namespace == c.namespaceOnHub {
+		switch obj := obj.(type) {
+		case *policiesv1.Policy:
+			for i := range c.snapshot.Policies {
+				if c.snapshot.Policies[i].Name == key.Name {
+					c.snapshot.Policies[i].DeepCopyInto(obj)
+
+					return nil
+				}
+			}
+		case *corev1.Secret:
+			if key.Name == secretsync.SecretName && c.snapshot.EncryptionKey != nil {
+				*obj = corev1.Secret{}
+				obj.Name = secretsync.SecretName
+				obj.Namespace = c.namespaceOnHub
+				obj.Data = make(map[string][]byte, len(c.snapshot.EncryptionKey))
+
+				for k, v := range c.snapshot.EncryptionKey {
+					obj.Data[k] = append([]byte(nil), v...)
+				}
+
+				return nil
+			}
+		}
+	}
+
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
+		return err
+	}
+
+	return errors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.Name)
+}
+
+func (c *OfflineClient) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	policies, ok := list.(*policiesv1.PolicyList)
+	if !ok {
+		return nil
+	}
+
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	policies.Items = nil
+
+	if listOpts.Namespace != "" && listOpts.Namespace != c.namespaceOnHub {
+		return nil
+	}
+
+	for i := range c.snapshot.Policies {
+		plc := &c.snapshot.Policies[i]
+
+		if listOpts.LabelSelector != nil && !listOpts.LabelSelector.Matches(labels.Set(plc.Labels)) {
+			continue
+		}
+
+		policies.Items = append(policies.Items, *plc.DeepCopy())
+	}
+
+	return nil
+}
+
+func (c *OfflineClient) Apply(context.Context, runtime.ApplyConfiguration, ...client.ApplyOption) error {
+	return utils.ErrHubOffline
+}
+
+func (c *OfflineClient) Create(context.Context, client.Object, ...client.CreateOption) error {
+	return utils.ErrHubOffline
+}
+
+func (c *OfflineClient) Delete(context.Context, client.Object, ...client.DeleteOption) error {
+	return utils.ErrHubOffline
+}
+
+func (c *OfflineClient) Update(context.Context, client.Object, ...client.UpdateOption) error {
+	return utils.ErrHubOffline
+}
+
+func (c *OfflineClient) Patch(context.Context, client.Object, client.Patch, ...client.PatchOption) error {
+	return utils.ErrHubOffline
+}
+
+func (c *OfflineClient) DeleteAllOf(context.Context, client.Object, ...client.DeleteAllOfOption) error {
+	return utils.ErrHubOffline
+}
+
+func (c *OfflineClient) Status() client.SubResourceWriter {
+	return offlineSubResourceClient{}
+}
+
+func (c *OfflineClient) SubResource(string) client.SubResourceClient {
+	return offlineSubResourceClient{}
+}
+
+func (c *OfflineClient) Scheme() *runtime.Scheme {
+	return c.scheme
+}
+
+func (c *OfflineClient) RESTMapper() meta.RESTMapper {
+	return nil
+}
+
+func (c *OfflineClient) GroupVersionKindFor(obj runtime.Object) (schema.GroupVersionKind, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("failed to get the GroupVersionKind of the object: %w", err)
+	}
+
+	return gvk, nil
+}
+
+func (c *OfflineClient) IsObjectNamespaced(runtime.Object) (bool, error) {
+	return true, nil
+}
+
+// offlineSubResourceClient fails every subresource request with utils.ErrHubOffline.
+type offlineSubResourceClient struct{}
+
+func (offlineSubResourceClient) Get(
+	context.Context, client.Object, client.Object, ...client.SubResourceGetOption,
+) error {
+	return utils.ErrHubOffline
+}
+
+func (offlineSubResourceClient) Create(
+	context.Context, client.Object, client.Object, ...client.SubResourceCreateOption,
+) error {
+	return utils.ErrHubOffline
+}
+
+func (offlineSubResourceClient) Update(context.Context, client.Object, ...client.SubResourceUpdateOption) error {
+	return utils.ErrHubOffline
+}
+
+func (offlineSubResourceClient) Patch(
+	context.Context, client.Object, client.Patch, ...client.SubResourcePatchOption,
+) error {
+	return utils.ErrHubOffline
+}
+
+func (offlineSubResourceClient) Apply(
+	context.Context, runtime.ApplyConfiguration, ...client.SubResourceApplyOption,
+) error {
+	return utils.ErrHubOffline
+}
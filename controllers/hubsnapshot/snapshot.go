@@ -0,0 +1,339 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package hubsnapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/secretsync"
+)
+
+const (
+	// SecretName is the name of the Secret in the cluster namespace on the managed cluster that holds the snapshots of
+	// the hub.
+	SecretName = "governance-policy-hub-snapshot"
+	// maxSnapshotBytes keeps the Secret under the 1 MiB limit of Kubernetes objects.
+	maxSnapshotBytes = 1000 * 1024
+)
+
+var log = ctrl.Log.WithName("hub-snapshot")
+
+// SnapshotKey returns the key of the gzipped JSON snapshot in the Secret data for the policy selector of the addon
+// instance. The hub cache of an addon instance with a policy selector only has the policies that it selects, so each
+// policy selector has its own snapshot.
+func SnapshotKey(selector labels.Selector) string {
+	if selector == nil {
+		return "snapshot.json.gz"
+	}
+
+	sum := sha256.Sum256([]byte(selector.String()))
+
+	return "snapshot-" + hex.EncodeToString(sum[:5]) + ".json.gz"
+}
+
+// selectorString returns the policy selector recorded in a snapshot, which is empty when all policies are synced.
+func selectorString(selector labels.Selector) string {
+	if selector == nil {
+		return ""
+	}
+
+	return selector.String()
+}
+
+// Snapshot is the last-known state of the hub, which the addon starts from when the hub is unreachable.
+type Snapshot struct {
+	// PolicySelector is the policy selector of the addon instance that wrote the snapshot, which the policies are
+	// limited to.
+	PolicySelector string `json:"policySelector,omitempty"`
+	// Policies are the replicated policies in the cluster namespace on the hub, sorted by name.
+	Policies []policiesv1.Policy `json:"policies,omitempty"`
+	// EncryptionKey is the data of the policy-encryption-key Secret in the cluster namespace on the hub.
+	EncryptionKey map[string][]byte `json:"encryptionKey,omitempty"`
+}
+
+// encode returns the gzipped JSON of the snapshot.
+func (s *Snapshot) encode() ([]byte, error) {
+	snapshotJSON, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	gzipWriter := gzip.NewWriter(&buf)
+
+	if _, err := gzipWriter.Write(snapshotJSON); err != nil {
+		return nil, err
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decode parses the gzipped JSON of a snapshot.
+func decode(data []byte) (*Snapshot, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotJSON, err := io.ReadAll(gzipReader)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{}
+
+	if err := json.Unmarshal(snapshotJSON, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// Load returns the snapshot of the hub for the policy selector in the namespace on the managed cluster, or nil if there
+// is none. A snapshot that was written for another policy selector is refused.
+func Load(
+	ctx context.Context, reader client.Reader, namespace string, selector labels.Selector,
+) (*Snapshot, error) {
+	secret := &corev1.Secret{}
+
+	err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: SecretName}, secret)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	data, ok := secret.Data[SnapshotKey(selector)]
+	if !ok {
+		return nil, nil
+	}
+
+	snapshot, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("the hub snapshot is invalid: %w", err)
+	}
+
+	if snapshot.PolicySelector != selectorString(selector) {
+		return nil, fmt.Errorf(
+			"the hub snapshot is of the %q policy selector instead of %q",
+			snapshot.PolicySelector, selectorString(selector),
+		)
+	}
+
+	return snapshot, nil
+}
+
+// RestoreEncryptionKey creates the replicated policy-encryption-key Secret in the namespace on the managed cluster from
+// the snapshot if it's missing, since the secret sync can't replicate it while the hub is unreachable.
+func RestoreEncryptionKey(
+	ctx context.Context, managedClient client.Client, namespace string, snapshot *Snapshot,
+) error {
+	if snapshot == nil || snapshot.EncryptionKey == nil {
+		return nil
+	}
+
+	err := managedClient.Get(
+		ctx, types.NamespacedName{Namespace: namespace, Name: secretsync.SecretName}, &corev1.Secret{},
+	)
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	log.Info("Restoring the replicated policy encryption key from the hub snapshot", "namespace", namespace)
+
+	return managedClient.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretsync.SecretName, Namespace: namespace},
+		Data:       snapshot.EncryptionKey,
+	})
+}
+
+// Writer writes a snapshot of the replicated policies and the policy-encryption-key Secret in the cluster namespace
+// on the hub to the SecretName Secret in the cluster namespace on the managed cluster, when it starts and then at
+// every Interval. The snapshot is under the SnapshotKey of the PolicySelector, so that the snapshots of the other
+// addon instances in the Secret are kept. It only runs on the leader.
+type Writer struct {
+	// HubClient reads from the cache of the hub manager, which is limited to the synced policies.
+	HubClient client.Client
+	// PolicySelector is the policy selector that the hub cache is limited to, or nil when all policies are synced.
+	PolicySelector labels.Selector
+	// ManagedClient writes the snapshot Secret, which isn't in the cache of the managed manager, so ManagedReader is
+	// an uncached reader.
+	ManagedClient         client.Client
+	ManagedReader         client.Reader
+	ClusterNamespaceOnHub string
+	// The namespace on the managed cluster that the snapshot is written to.
+	TargetNamespace string
+	Interval        time.Duration
+}
+
+// The snapshot Secret is created when it doesn't exist, and a create request can't be limited to a resource name.
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=create
+//+kubebuilder:rbac:groups=core,resources=secrets,resourceNames=governance-policy-hub-snapshot,verbs=create;get;update
+
+// Start writes the snapshot at every Interval until the context is canceled.
+func (w *Writer) Start(ctx context.Context) error {
+	var lastSum [sha256.Size]byte
+
+	for {
+		sum, err := w.write(ctx, lastSum)
+		if err != nil {
+			log.Error(err, "Failed to write the hub snapshot", "namespace", w.TargetNamespace)
+		} else {
+			lastSum = sum
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.Interval):
+		}
+	}
+}
+
+// snapshot returns the snapshot of the hub.
+func (w *Writer) snapshot(ctx context.Context) (*Snapshot, error) {
+	policies := &policiesv1.PolicyList{}
+
+	if err := w.HubClient.List(ctx, policies, client.InNamespace(w.ClusterNamespaceOnHub)); err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		PolicySelector: selectorString(w.PolicySelector),
+		Policies:       make([]policiesv1.Policy, 0, len(policies.Items)),
+	}
+
+	for _, plc := range policies.Items {
+		// The managed fields are only relevant on the hub and make up a large part of the object.
+		plc.ManagedFields = nil
+
+		snapshot.Policies = append(snapshot.Policies, plc)
+	}
+
+	slices.SortFunc(snapshot.Policies, func(a, b policiesv1.Policy) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	encryptionSecret := &corev1.Secret{}
+
+	err := w.HubClient.Get(
+		ctx, types.NamespacedName{Namespace: w.ClusterNamespaceOnHub, Name: secretsync.SecretName}, encryptionSecret,
+	)
+	if err == nil {
+		snapshot.EncryptionKey = encryptionSecret.Data
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// write writes the snapshot unless its checksum is lastSum, and returns the checksum of the snapshot.
+func (w *Writer) write(ctx context.Context, lastSum [sha256.Size]byte) ([sha256.Size]byte, error) {
+	snapshot, err := w.snapshot(ctx)
+	if err != nil {
+		return lastSum, err
+	}
+
+	data, err := snapshot.encode()
+	if err != nil {
+		return lastSum, err
+	}
+
+	sum := sha256.Sum256(data)
+	if sum == lastSum {
+		return sum, nil
+	}
+
+	key := SnapshotKey(w.PolicySelector)
+	existing := &corev1.Secret{}
+
+	err = w.ManagedReader.Get(ctx, types.NamespacedName{Namespace: w.TargetNamespace, Name: SecretName}, existing)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return lastSum, err
+		}
+
+		if err := checkSize(nil, key, data); err != nil {
+			return lastSum, err
+		}
+
+		log.Info("Creating the hub snapshot", "namespace", w.TargetNamespace)
+
+		err := w.ManagedClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: w.TargetNamespace},
+			Data:       map[string][]byte{key: data},
+		})
+		if err != nil {
+			return lastSum, err
+		}
+
+		return sum, nil
+	}
+
+	if bytes.Equal(existing.Data[key], data) {
+		return sum, nil
+	}
+
+	if err := checkSize(existing.Data, key, data); err != nil {
+		return lastSum, err
+	}
+
+	log.V(1).Info("Updating the hub snapshot", "namespace", w.TargetNamespace)
+
+	if existing.Data == nil {
+		existing.Data = map[string][]byte{}
+	}
+
+	existing.Data[key] = data
+
+	if err := w.ManagedClient.Update(ctx, existing); err != nil {
+		return lastSum, err
+	}
+
+	return sum, nil
+}
+
+// checkSize returns an error if the Secret data with the snapshot under the key exceeds the maxSnapshotBytes, including
+// the snapshots of the other policy selectors.
+func checkSize(secretData map[string][]byte, key string, data []byte) error {
+	size := len(data)
+
+	for otherKey, otherData := range secretData {
+		if otherKey != key {
+			size += len(otherData)
+		}
+	}
+
+	if size > maxSnapshotBytes {
+		return fmt.Errorf("the hub snapshots of %d bytes exceed the limit of %d bytes", size, maxSnapshotBytes)
+	}
+
+	return nil
+}
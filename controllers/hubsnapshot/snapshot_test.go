@@ -0,0 +1,272 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package hubsnapshot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/secretsync"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	return scheme
+}
+
+func hubObjects() []client.Object {
+	return []client.Object{
+		&policiesv1.Policy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "policies.policy-b",
+				Namespace: "managed-hub",
+				Labels:    map[string]string{"env": "prod"},
+			},
+			Spec: policiesv1.PolicySpec{RemediationAction: policiesv1.Enforce},
+		},
+		&policiesv1.Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policies.policy-a", Namespace: "managed-hub"},
+			Spec:       policiesv1.PolicySpec{RemediationAction: policiesv1.Inform},
+		},
+		&policiesv1.Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policies.policy-c", Namespace: "other-hub"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretsync.SecretName, Namespace: "managed-hub"},
+			Data:       map[string][]byte{"key": []byte("secret")},
+		},
+	}
+}
+
+func TestWriterAndLoad(t *testing.T) {
+	t.Parallel()
+
+	scheme := testScheme(t)
+	managedClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	writer := &Writer{
+		HubClient:             fake.NewClientBuilder().WithScheme(scheme).WithObjects(hubObjects()...).Build(),
+		ManagedClient:         managedClient,
+		ManagedReader:         managedClient,
+		ClusterNamespaceOnHub: "managed-hub",
+		TargetNamespace:       "managed",
+		Interval:              time.Minute,
+	}
+
+	sum, err := writer.write(context.TODO(), [32]byte{})
+	if err != nil {
+		t.Fatalf("Failed to write the hub snapshot: %v", err)
+	}
+
+	snapshot, err := Load(context.TODO(), managedClient, "managed", nil)
+	if err != nil {
+		t.Fatalf("Failed to load the hub snapshot: %v", err)
+	}
+
+	if len(snapshot.Policies) != 2 {
+		t.Fatalf("Expected 2 policies in the hub snapshot but got %d", len(snapshot.Policies))
+	}
+
+	if snapshot.Policies[0].Name != "policies.policy-a" || snapshot.Policies[1].ManagedFields != nil {
+		t.Fatalf("Expected the policies to be sorted by name without managed fields: %v", snapshot.Policies)
+	}
+
+	if string(snapshot.EncryptionKey["key"]) != "secret" {
+		t.Fatalf("Expected the encryption key in the hub snapshot but got: %v", snapshot.EncryptionKey)
+	}
+
+	secret := &corev1.Secret{}
+
+	err = managedClient.Get(context.TODO(), types.NamespacedName{Namespace: "managed", Name: SecretName}, secret)
+	if err != nil {
+		t.Fatalf("Failed to get the hub snapshot secret: %v", err)
+	}
+
+	if _, err := writer.write(context.TODO(), sum); err != nil {
+		t.Fatalf("Failed to write the unchanged hub snapshot: %v", err)
+	}
+
+	unchanged := &corev1.Secret{}
+
+	err = managedClient.Get(context.TODO(), types.NamespacedName{Namespace: "managed", Name: SecretName}, unchanged)
+	if err != nil {
+		t.Fatalf("Failed to get the hub snapshot secret: %v", err)
+	}
+
+	if unchanged.ResourceVersion != secret.ResourceVersion {
+		t.Fatal("Expected the unchanged hub snapshot to not be written again")
+	}
+
+	snapshot, err = Load(context.TODO(), managedClient, "other", nil)
+	if err != nil || snapshot != nil {
+		t.Fatalf("Expected no hub snapshot in another namespace but got: %v, %v", snapshot, err)
+	}
+}
+
+func TestWriterPolicySelectors(t *testing.T) {
+	t.Parallel()
+
+	scheme := testScheme(t)
+	managedClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	selector, err := labels.Parse("env=prod")
+	if err != nil {
+		t.Fatalf("Failed to parse the selector: %v", err)
+	}
+
+	// The hub cache of the addon instance with the policy selector only has the policies that it selects.
+	hubObjs := hubObjects()[:1]
+
+	for _, writer := range []*Writer{
+		{
+			HubClient:             fake.NewClientBuilder().WithScheme(scheme).WithObjects(hubObjects()...).Build(),
+			ClusterNamespaceOnHub: "managed-hub",
+		},
+		{
+			HubClient:             fake.NewClientBuilder().WithScheme(scheme).WithObjects(hubObjs...).Build(),
+			PolicySelector:        selector,
+			ClusterNamespaceOnHub: "managed-hub",
+		},
+	} {
+		writer.ManagedClient = managedClient
+		writer.ManagedReader = managedClient
+		writer.TargetNamespace = "managed"
+
+		if _, err := writer.write(context.TODO(), [32]byte{}); err != nil {
+			t.Fatalf("Failed to write the hub snapshot: %v", err)
+		}
+	}
+
+	// Each addon instance loads its own snapshot.
+	snapshot, err := Load(context.TODO(), managedClient, "managed", nil)
+	if err != nil || snapshot == nil || len(snapshot.Policies) != 2 {
+		t.Fatalf("Expected the hub snapshot of all the policies but got: %v, %v", snapshot, err)
+	}
+
+	snapshot, err = Load(context.TODO(), managedClient, "managed", selector)
+	if err != nil || snapshot == nil || len(snapshot.Policies) != 1 || snapshot.PolicySelector != "env=prod" {
+		t.Fatalf("Expected the hub snapshot of the selected policies but got: %v, %v", snapshot, err)
+	}
+
+	other, err := labels.Parse("env=dev")
+	if err != nil {
+		t.Fatalf("Failed to parse the selector: %v", err)
+	}
+
+	snapshot, err = Load(context.TODO(), managedClient, "managed", other)
+	if err != nil || snapshot != nil {
+		t.Fatalf("Expected no hub snapshot for another policy selector but got: %v, %v", snapshot, err)
+	}
+
+	// A snapshot that was written for another policy selector is refused.
+	secret := &corev1.Secret{}
+
+	err = managedClient.Get(context.TODO(), types.NamespacedName{Namespace: "managed", Name: SecretName}, secret)
+	if err != nil {
+		t.Fatalf("Failed to get the hub snapshot secret: %v", err)
+	}
+
+	secret.Data[SnapshotKey(other)] = secret.Data[SnapshotKey(selector)]
+
+	if err := managedClient.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("Failed to update the hub snapshot secret: %v", err)
+	}
+
+	snapshot, err = Load(context.TODO(), managedClient, "managed", other)
+	if err == nil || snapshot != nil {
+		t.Fatalf("Expected the hub snapshot of another policy selector to be refused but got: %v, %v", snapshot, err)
+	}
+}
+
+func TestOfflineClient(t *testing.T) {
+	t.Parallel()
+
+	scheme := testScheme(t)
+
+	writer := &Writer{
+		HubClient:             fake.NewClientBuilder().WithScheme(scheme).WithObjects(hubObjects()...).Build(),
+		ClusterNamespaceOnHub: "managed-hub",
+	}
+
+	snapshot, err := writer.snapshot(context.TODO())
+	if err != nil {
+		t.Fatalf("Failed to take the hub snapshot: %v", err)
+	}
+
+	offline := NewOfflineClient(snapshot, "managed-hub", scheme)
+
+	plc := &policiesv1.Policy{}
+
+	err = offline.Get(context.TODO(), types.NamespacedName{Namespace: "managed-hub", Name: "policies.policy-b"}, plc)
+	if err != nil || plc.Spec.RemediationAction != policiesv1.Enforce {
+		t.Fatalf("Expected the policy from the hub snapshot but got: %v, %v", plc, err)
+	}
+
+	err = offline.Get(context.TODO(), types.NamespacedName{Namespace: "managed-hub", Name: "policies.missing"}, plc)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("Expected a not found error but got: %v", err)
+	}
+
+	secret := &corev1.Secret{}
+
+	err = offline.Get(
+		context.TODO(), types.NamespacedName{Namespace: "managed-hub", Name: secretsync.SecretName}, secret,
+	)
+	if err != nil || string(secret.Data["key"]) != "secret" {
+		t.Fatalf("Expected the encryption key from the hub snapshot but got: %v, %v", secret, err)
+	}
+
+	policies := &policiesv1.PolicyList{}
+
+	err = offline.List(
+		context.TODO(), policies, client.InNamespace("managed-hub"), client.MatchingLabels{"env": "prod"},
+	)
+	if err != nil || len(policies.Items) != 1 {
+		t.Fatalf("Expected the matching policy from the hub snapshot but got: %v, %v", policies.Items, err)
+	}
+
+	err = offline.Status().Patch(context.TODO(), plc, client.MergeFrom(plc))
+	if !errors.Is(err, utils.ErrHubOffline) {
+		t.Fatalf("Expected the hub offline error but got: %v", err)
+	}
+
+	if err := offline.Create(context.TODO(), plc); !errors.Is(err, utils.ErrHubOffline) {
+		t.Fatalf("Expected the hub offline error but got: %v", err)
+	}
+
+	managedClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	if err := RestoreEncryptionKey(context.TODO(), managedClient, "managed", snapshot); err != nil {
+		t.Fatalf("Failed to restore the encryption key: %v", err)
+	}
+
+	err = managedClient.Get(
+		context.TODO(), types.NamespacedName{Namespace: "managed", Name: secretsync.SecretName}, secret,
+	)
+	if err != nil || string(secret.Data["key"]) != "secret" {
+		t.Fatalf("Expected the restored encryption key but got: %v, %v", secret, err)
+	}
+}
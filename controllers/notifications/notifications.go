@@ -0,0 +1,163 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var log = ctrl.Log.WithName("notifications")
+
+// Notification describes a compliance state change of a policy or of one of its templates.
+type Notification struct {
+	// ID uniquely identifies the notification. It's set once by the Dispatcher and is the same on every retry and
+	// for every sink, so that receivers can deduplicate the deliveries.
+	ID        string `json:"id"`
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Policy    string `json:"policy"`
+	// Template is the name of the policy template whose compliance changed. It is empty when the notification is
+	// about the overall policy compliance.
+	Template string                     `json:"template,omitempty"`
+	OldState policiesv1.ComplianceState `json:"oldState"`
+	NewState policiesv1.ComplianceState `json:"newState"`
+	Message  string                     `json:"message,omitempty"`
+	Time     time.Time                  `json:"time"`
+}
+
+// Sink publishes compliance notifications to a destination. Send is retried by the Dispatcher when it returns an
+// error, so it must not retry on its own.
+type Sink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	Send(ctx context.Context, notification Notification) error
+}
+
+// DefaultBackoff is the retry backoff of notifications when the Dispatcher doesn't set one.
+var DefaultBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+	Cap:      time.Minute,
+}
+
+// sinkQueueSize is the number of notifications buffered for each sink. When a sink is unavailable for long enough
+// that its queue is full, new notifications for that sink are dropped.
+const sinkQueueSize = 1000
+
+// Dispatcher delivers notifications to sinks asynchronously so that the reconciles aren't blocked. Each sink has its
+// own queue so that an unavailable sink doesn't delay the others. It implements manager.Runnable, and notifications
+// sent before it starts are buffered.
+type Dispatcher struct {
+	Sinks []Sink
+	// Backoff is the retry backoff of each notification. DefaultBackoff is used when it's not set.
+	Backoff *wait.Backoff
+	queues  []chan Notification
+}
+
+// NewDispatcher returns a Dispatcher for the input sinks.
+func NewDispatcher(sinks ...Sink) *Dispatcher {
+	d := &Dispatcher{Sinks: sinks, queues: make([]chan Notification, 0, len(sinks))}
+
+	for range sinks {
+		d.queues = append(d.queues, make(chan Notification, sinkQueueSize))
+	}
+
+	return d
+}
+
+// Notify queues the notification for all sinks. It never blocks. A nil Dispatcher is a no-op.
+func (d *Dispatcher) Notify(notification Notification) {
+	if d == nil {
+		return
+	}
+
+	if notification.ID == "" {
+		notification.ID = string(uuid.NewUUID())
+	}
+
+	if notification.Time.IsZero() {
+		notification.Time = time.Now().UTC()
+	}
+
+	for i, queue := range d.queues {
+		select {
+		case queue <- notification:
+		default:
+			log.Info(
+				"The notification queue of the sink is full. Dropping the notification.",
+				"sink", d.Sinks[i].Name(),
+				"policy", notification.Policy,
+				"template", notification.Template,
+			)
+		}
+	}
+}
+
+// Start delivers the queued notifications until the context is canceled.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	backoff := DefaultBackoff
+	if d.Backoff != nil {
+		backoff = *d.Backoff
+	}
+
+	done := make(chan struct{}, len(d.Sinks))
+
+	for i, sink := range d.Sinks {
+		go func() {
+			defer func() { done <- struct{}{} }()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case notification := <-d.queues[i]:
+					err := send(ctx, sink, notification, backoff)
+					if err != nil {
+						log.Error(
+							err, "Failed to send the notification",
+							"sink", sink.Name(),
+							"policy", notification.Policy,
+							"template", notification.Template,
+						)
+					}
+				}
+			}
+		}()
+	}
+
+	for range d.Sinks {
+		<-done
+	}
+
+	return nil
+}
+
+// send sends the notification to the sink, retrying with the backoff until it succeeds or the retries are exhausted.
+func send(ctx context.Context, sink Sink, notification Notification, backoff wait.Backoff) error {
+	var lastErr error
+
+	err := wait.ExponentialBackoffWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		lastErr = sink.Send(ctx, notification)
+		if lastErr != nil {
+			log.V(2).Info("Failed to send the notification, will retry", "sink", sink.Name(), "error", lastErr)
+
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if err != nil && lastErr != nil {
+		return fmt.Errorf("%w: %w", err, lastErr)
+	}
+
+	return err
+}
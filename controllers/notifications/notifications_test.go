@@ -0,0 +1,218 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+var testNotification = Notification{
+	Cluster:   "cluster1",
+	Namespace: "cluster1",
+	Policy:    "policy-a",
+	Template:  "config-a",
+	OldState:  policiesv1.Compliant,
+	NewState:  policiesv1.NonCompliant,
+	Message:   "NonCompliant; violation - pods not found",
+	Time:      time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC),
+}
+
+func TestDispatcherRetriesWebhook(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	received := make(chan Notification, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first attempt to verify the retry.
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		notification := Notification{}
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		received <- notification
+	}))
+	defer server.Close()
+
+	sink, err := ParseSink("webhook="+server.URL, "")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	dispatcher := NewDispatcher(sink)
+	dispatcher.Backoff = &wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = dispatcher.Start(ctx)
+	}()
+
+	dispatcher.Notify(testNotification)
+
+	select {
+	case notification := <-received:
+		if notification.ID == "" {
+			t.Fatal("Expected the dispatcher to set the notification ID")
+		}
+
+		notification.ID = ""
+
+		if notification != testNotification {
+			t.Fatalf("Unexpected notification: %v", notification)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the notification")
+	}
+
+	if attempts.Load() != 2 {
+		t.Fatalf("Expected 2 attempts but got %d", attempts.Load())
+	}
+}
+
+func TestDispatcherRetriesCloudEventsWithSameID(t *testing.T) {
+	t.Parallel()
+
+	ids := make(chan string, 2)
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids <- r.Header.Get("ce-id")
+
+		// Fail the first attempt to verify that the retry has the same event ID.
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	sink := &CloudEventsSink{URL: server.URL, Source: "open-cluster-management.io/clusters/cluster1"}
+
+	dispatcher := NewDispatcher(sink)
+	dispatcher.Backoff = &wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = dispatcher.Start(ctx)
+	}()
+
+	dispatcher.Notify(testNotification)
+
+	received := make([]string, 0, 2)
+
+	for range 2 {
+		select {
+		case id := <-ids:
+			received = append(received, id)
+		case <-time.After(10 * time.Second):
+			t.Fatal("Timed out waiting for the notification")
+		}
+	}
+
+	if received[0] == "" || received[0] != received[1] {
+		t.Fatalf("Expected both attempts to have the same ce-id but got %v", received)
+	}
+}
+
+func TestCloudEventsSink(t *testing.T) {
+	t.Parallel()
+
+	receivedHeaders := make(chan http.Header, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		receivedHeaders <- r.Header
+	}))
+	defer server.Close()
+
+	sink := &CloudEventsSink{URL: server.URL, Source: "open-cluster-management.io/clusters/cluster1"}
+
+	notification := testNotification
+	notification.ID = "3f0c6c1e-7c5b-4c7e-9a1d-2f1e5b8d9a10"
+
+	if err := sink.Send(context.TODO(), notification); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	headers := <-receivedHeaders
+
+	expected := map[string]string{
+		"ce-specversion": "1.0",
+		"ce-type":        CloudEventType,
+		"ce-source":      "open-cluster-management.io/clusters/cluster1",
+		"ce-subject":     "cluster1/policy-a/config-a",
+		"Content-Type":   "application/json",
+	}
+
+	for header, value := range expected {
+		if headers.Get(header) != value {
+			t.Errorf("Expected the %s header to be %s but got %s", header, value, headers.Get(header))
+		}
+	}
+
+	if headers.Get("ce-id") != notification.ID {
+		t.Errorf("Expected the ce-id header to be %s but got %s", notification.ID, headers.Get("ce-id"))
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	sink := &FileSink{Path: path}
+
+	for range 2 {
+		if err := sink.Send(context.TODO(), testNotification); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines but got %d", len(lines))
+	}
+
+	notification := Notification{}
+	if err := json.Unmarshal([]byte(lines[1]), &notification); err != nil || notification != testNotification {
+		t.Fatalf("Unexpected JSON line %s: %v", lines[1], err)
+	}
+}
+
+func TestParseSinkInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, value := range []string{"webhook", "webhook=", "smtp=admin@example.com"} {
+		if _, err := ParseSink(value, ""); err == nil {
+			t.Errorf("Expected an error for %s", value)
+		}
+	}
+}
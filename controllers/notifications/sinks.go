@@ -0,0 +1,170 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CloudEventType is the CloudEvents type of compliance notifications.
+const CloudEventType = "io.open-cluster-management.policy.compliance.changed"
+
+// WebhookSink POSTs each notification as JSON to a URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w *WebhookSink) Name() string {
+	return "webhook " + w.URL
+}
+
+func (w *WebhookSink) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	return post(ctx, w.Client, w.URL, body, map[string]string{"Content-Type": "application/json"})
+}
+
+// CloudEventsSink POSTs each notification as a CloudEvents v1.0 event in binary content mode of the HTTP protocol
+// binding, with the notification as the JSON data.
+type CloudEventsSink struct {
+	URL string
+	// Source is the CloudEvents source attribute, which identifies the cluster.
+	Source string
+	Client *http.Client
+}
+
+func (c *CloudEventsSink) Name() string {
+	return "cloudevents " + c.URL
+}
+
+func (c *CloudEventsSink) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	subject := notification.Namespace + "/" + notification.Policy
+	if notification.Template != "" {
+		subject += "/" + notification.Template
+	}
+
+	return post(ctx, c.Client, c.URL, body, map[string]string{
+		"Content-Type":   "application/json",
+		"ce-specversion": "1.0",
+		"ce-id":          notification.ID,
+		"ce-type":        CloudEventType,
+		"ce-source":      c.Source,
+		"ce-subject":     subject,
+		"ce-time":        notification.Time.Format(time.RFC3339Nano),
+	})
+}
+
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// Drain the body so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the notification request to %s failed with the status code %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// FileSink appends each notification as a JSON line to a file, or writes it to stdout when the path is "-".
+type FileSink struct {
+	Path string
+	lock sync.Mutex
+}
+
+func (f *FileSink) Name() string {
+	return "file " + f.Path
+}
+
+func (f *FileSink) Send(_ context.Context, notification Notification) error {
+	line, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.Path == "-" {
+		_, err = os.Stdout.Write(line)
+
+		return err
+	}
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(line)
+	if err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+// ParseSink returns the sink for a type=destination value, where the type is webhook, cloudevents, or file. The
+// source is the CloudEvents source attribute.
+func ParseSink(value string, source string) (Sink, error) {
+	sinkType, destination, found := strings.Cut(value, "=")
+	if !found || destination == "" {
+		return nil, fmt.Errorf("the notification sink %s must be in the type=destination format", value)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	switch sinkType {
+	case "webhook":
+		return &WebhookSink{URL: destination, Client: client}, nil
+	case "cloudevents":
+		return &CloudEventsSink{URL: destination, Source: source, Client: client}, nil
+	case "file":
+		return &FileSink{Path: destination}, nil
+	default:
+		return nil, fmt.Errorf(
+			"the notification sink type %s is not supported. Use webhook, cloudevents, or file.", sinkType,
+		)
+	}
+}
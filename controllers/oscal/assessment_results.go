@@ -0,0 +1,389 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package oscal
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const (
+	// Version is the OSCAL version of the generated documents.
+	Version = "1.1.2"
+	// PropNamespace is the namespace of the OSCAL properties that are specific to Open Cluster Management.
+	PropNamespace = "https://open-cluster-management.io/ns/oscal"
+)
+
+// idNamespace is used to generate stable UUIDs for the observations and findings, so that they can be correlated
+// across documents.
+var (
+	idNamespace  = uuid.MustParse("5f4c1a0e-8e0f-4c36-9c59-38a0b6f4c8d2")
+	invalidToken = regexp.MustCompile(`[^a-z0-9_.-]+`)
+)
+
+// Document is an OSCAL assessment-results document. Only the fields populated from the policies are defined.
+type Document struct {
+	AssessmentResults AssessmentResults `json:"assessment-results"`
+}
+
+type AssessmentResults struct {
+	UUID     string   `json:"uuid"`
+	Metadata Metadata `json:"metadata"`
+	ImportAP ImportAP `json:"import-ap"`
+	Results  []Result `json:"results"`
+}
+
+type Metadata struct {
+	Title        string     `json:"title"`
+	LastModified time.Time  `json:"last-modified"`
+	Version      string     `json:"version"`
+	OSCALVersion string     `json:"oscal-version"`
+	Props        []Property `json:"props,omitempty"`
+}
+
+type ImportAP struct {
+	Href string `json:"href"`
+}
+
+type Property struct {
+	Name  string `json:"name"`
+	NS    string `json:"ns,omitempty"`
+	Value string `json:"value"`
+}
+
+type Result struct {
+	UUID             string           `json:"uuid"`
+	Title            string           `json:"title"`
+	Description      string           `json:"description"`
+	Start            time.Time        `json:"start"`
+	End              time.Time        `json:"end"`
+	ReviewedControls ReviewedControls `json:"reviewed-controls"`
+	Observations     []Observation    `json:"observations,omitempty"`
+	Findings         []Finding        `json:"findings,omitempty"`
+}
+
+type ReviewedControls struct {
+	ControlSelections []ControlSelection `json:"control-selections"`
+}
+
+type ControlSelection struct {
+	IncludeAll      *struct{}           `json:"include-all,omitempty"`
+	IncludeControls []SelectControlByID `json:"include-controls,omitempty"`
+}
+
+type SelectControlByID struct {
+	ControlID string `json:"control-id"`
+}
+
+type Observation struct {
+	UUID             string             `json:"uuid"`
+	Title            string             `json:"title"`
+	Description      string             `json:"description"`
+	Props            []Property         `json:"props,omitempty"`
+	Methods          []string           `json:"methods"`
+	RelevantEvidence []RelevantEvidence `json:"relevant-evidence,omitempty"`
+	Collected        time.Time          `json:"collected"`
+}
+
+type RelevantEvidence struct {
+	Description string     `json:"description"`
+	Props       []Property `json:"props,omitempty"`
+}
+
+type Finding struct {
+	UUID                string               `json:"uuid"`
+	Title               string               `json:"title"`
+	Description         string               `json:"description"`
+	Props               []Property           `json:"props,omitempty"`
+	Target              FindingTarget        `json:"target"`
+	RelatedObservations []RelatedObservation `json:"related-observations,omitempty"`
+}
+
+type FindingTarget struct {
+	Type     string          `json:"type"`
+	TargetID string          `json:"target-id"`
+	Title    string          `json:"title,omitempty"`
+	Status   ObjectiveStatus `json:"status"`
+}
+
+type ObjectiveStatus struct {
+	State  string `json:"state"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type RelatedObservation struct {
+	ObservationUUID string `json:"observation-uuid"`
+}
+
+// control accumulates the policies that map to a control annotation value.
+type control struct {
+	title        string
+	policies     []string
+	observations []string
+	standards    []string
+	categories   []string
+	compliant    bool
+	noncompliant bool
+}
+
+// Build returns an OSCAL assessment-results document for the input replicated policies of the cluster. Each policy
+// template is an observation with its compliance history as evidence, and each value of the controls annotation of the
+// policies is a finding that is satisfied when all of the policies with that control are compliant.
+func Build(clusterName string, policies []policiesv1.Policy, now time.Time) *Document {
+	now = now.UTC().Truncate(time.Second)
+	start := now
+
+	sorted := slices.Clone(policies)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	observations := []Observation{}
+	controls := map[string]*control{}
+
+	for i := range sorted {
+		policy := &sorted[i]
+
+		if policy.DeletionTimestamp != nil {
+			continue
+		}
+
+		policyObservations := policyObservations(clusterName, policy, now)
+
+		for _, observation := range policyObservations {
+			if observation.Collected.Before(start) {
+				start = observation.Collected
+			}
+		}
+
+		observations = append(observations, policyObservations...)
+
+		annotations := policy.GetAnnotations()
+
+		for _, controlTitle := range utils.SplitAnnotation(annotations[common.APIGroup+"/controls"]) {
+			ctrl, ok := controls[controlTitle]
+			if !ok {
+				ctrl = &control{title: controlTitle, compliant: true}
+				controls[controlTitle] = ctrl
+			}
+
+			ctrl.policies = append(ctrl.policies, fmt.Sprintf("%s (%s)", policy.Name, complianceOf(policy)))
+			ctrl.standards = appendUnique(
+				ctrl.standards, utils.SplitAnnotation(annotations[common.APIGroup+"/standards"]),
+			)
+			ctrl.categories = appendUnique(
+				ctrl.categories, utils.SplitAnnotation(annotations[common.APIGroup+"/categories"]),
+			)
+
+			for _, observation := range policyObservations {
+				ctrl.observations = append(ctrl.observations, observation.UUID)
+			}
+
+			switch policy.Status.ComplianceState {
+			case policiesv1.Compliant:
+			case policiesv1.NonCompliant:
+				ctrl.noncompliant = true
+				ctrl.compliant = false
+			default:
+				ctrl.compliant = false
+			}
+		}
+	}
+
+	controlTitles := make([]string, 0, len(controls))
+	for title := range controls {
+		controlTitles = append(controlTitles, title)
+	}
+
+	sort.Strings(controlTitles)
+
+	findings := make([]Finding, 0, len(controlTitles))
+	selection := ControlSelection{}
+
+	for _, title := range controlTitles {
+		finding := controlFinding(clusterName, controls[title])
+		findings = append(findings, finding)
+		selection.IncludeControls = append(
+			selection.IncludeControls, SelectControlByID{ControlID: finding.Target.TargetID},
+		)
+	}
+
+	if len(selection.IncludeControls) == 0 {
+		selection.IncludeAll = &struct{}{}
+	}
+
+	return &Document{AssessmentResults: AssessmentResults{
+		UUID: uuid.NewString(),
+		Metadata: Metadata{
+			Title:        "Policy compliance of the " + clusterName + " cluster",
+			LastModified: now,
+			Version:      now.Format(time.RFC3339),
+			OSCALVersion: Version,
+			Props:        []Property{{Name: "cluster", NS: PropNamespace, Value: clusterName}},
+		},
+		ImportAP: ImportAP{Href: "#"},
+		Results: []Result{{
+			UUID:  uuid.NewString(),
+			Title: "Open Cluster Management policy compliance",
+			Description: "The compliance of the policies replicated to the " + clusterName + " cluster, based on " +
+				"the compliance history of their policy templates",
+			Start:            start,
+			End:              now,
+			ReviewedControls: ReviewedControls{ControlSelections: []ControlSelection{selection}},
+			Observations:     observations,
+			Findings:         findings,
+		}},
+	}}
+}
+
+// policyObservations returns an observation for each policy template of the policy. The compliance history messages
+// of the policy template are the evidence, and the time of the latest history entry is the collection time.
+func policyObservations(clusterName string, policy *policiesv1.Policy, now time.Time) []Observation {
+	observations := []Observation{}
+
+	for _, policyT := range policy.Spec.PolicyTemplates {
+		tmpl := utils.TemplateObject(policyT)
+		if tmpl == nil {
+			continue
+		}
+
+		observation := Observation{
+			UUID: uuid.NewSHA1(
+				idNamespace, []byte(clusterName+"/"+policy.Namespace+"/"+policy.Name+"/"+tmpl.GetName()),
+			).String(),
+			Title: fmt.Sprintf("%s %s", tmpl.GetKind(), tmpl.GetName()),
+			Description: fmt.Sprintf(
+				"The compliance of the %s %s policy template of the %s policy",
+				tmpl.GetName(), tmpl.GetKind(), policy.Name,
+			),
+			Methods:   []string{"TEST"},
+			Collected: now,
+		}
+
+		compliance := "Unknown"
+
+		for _, dpt := range policy.Status.Details {
+			if dpt == nil || dpt.TemplateMeta.Name != tmpl.GetName() {
+				continue
+			}
+
+			if dpt.ComplianceState != "" {
+				compliance = string(dpt.ComplianceState)
+			}
+
+			for j, history := range dpt.History {
+				timestamp := history.LastTimestamp.UTC().Truncate(time.Second)
+
+				if j == 0 && !history.LastTimestamp.IsZero() {
+					observation.Collected = timestamp
+				}
+
+				evidence := RelevantEvidence{Description: history.Message}
+
+				if !history.LastTimestamp.IsZero() {
+					evidence.Props = []Property{
+						{Name: "timestamp", NS: PropNamespace, Value: timestamp.Format(time.RFC3339)},
+					}
+				}
+
+				observation.RelevantEvidence = append(observation.RelevantEvidence, evidence)
+			}
+
+			break
+		}
+
+		observation.Props = []Property{
+			{Name: "policy", NS: PropNamespace, Value: policy.Name},
+			{Name: "policy-namespace", NS: PropNamespace, Value: policy.Namespace},
+			{Name: "policy-template", NS: PropNamespace, Value: tmpl.GetName()},
+			{Name: "policy-template-kind", NS: PropNamespace, Value: tmpl.GroupVersionKind().GroupKind().String()},
+			{Name: "compliance", NS: PropNamespace, Value: compliance},
+		}
+
+		observations = append(observations, observation)
+	}
+
+	return observations
+}
+
+// controlFinding returns the finding of the control. The control is only satisfied when all of its policies are
+// compliant.
+func controlFinding(clusterName string, ctrl *control) Finding {
+	status := ObjectiveStatus{State: "not-satisfied", Reason: "other"}
+
+	if ctrl.compliant {
+		status = ObjectiveStatus{State: "satisfied", Reason: "pass"}
+	} else if ctrl.noncompliant {
+		status.Reason = "fail"
+	}
+
+	finding := Finding{
+		UUID:  uuid.NewSHA1(idNamespace, []byte(clusterName+"/control/"+ctrl.title)).String(),
+		Title: ctrl.title,
+		Description: fmt.Sprintf(
+			"The %s control is %s based on the compliance of the policies: %s",
+			ctrl.title, strings.ReplaceAll(status.State, "-", " "), strings.Join(ctrl.policies, ", "),
+		),
+		Target: FindingTarget{
+			Type:     "objective-id",
+			TargetID: controlID(ctrl.title),
+			Title:    ctrl.title,
+			Status:   status,
+		},
+	}
+
+	for _, standard := range ctrl.standards {
+		finding.Props = append(finding.Props, Property{Name: "standard", NS: PropNamespace, Value: standard})
+	}
+
+	for _, category := range ctrl.categories {
+		finding.Props = append(finding.Props, Property{Name: "category", NS: PropNamespace, Value: category})
+	}
+
+	for _, observationUUID := range ctrl.observations {
+		finding.RelatedObservations = append(
+			finding.RelatedObservations, RelatedObservation{ObservationUUID: observationUUID},
+		)
+	}
+
+	return finding
+}
+
+// controlID converts a controls annotation value, such as "CM-2 Baseline Configuration", to an OSCAL token, such as
+// "cm-2-baseline-configuration".
+func controlID(title string) string {
+	return strings.Trim(invalidToken.ReplaceAllString(strings.ToLower(title), "-"), "-.")
+}
+
+// complianceOf returns the compliance state of the policy, or Unknown when it has none.
+func complianceOf(policy *policiesv1.Policy) string {
+	if policy.Status.ComplianceState == "" {
+		return "Unknown"
+	}
+
+	return string(policy.Status.ComplianceState)
+}
+
+func appendUnique(values []string, toAdd []string) []string {
+	for _, value := range toAdd {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,170 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package oscal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutils "open-cluster-management.io/governance-policy-framework-addon/test/utils"
+)
+
+const clusterName = "managed"
+
+// controlledPolicy returns a policy of the controls with a ConfigurationPolicy template of the same compliance state
+// and with the compliance history.
+func controlledPolicy(
+	name string, controls string, state policiesv1.ComplianceState, history ...policiesv1.ComplianceHistory,
+) policiesv1.Policy {
+	meta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: clusterName,
+		Annotations: map[string]string{
+			"policy.open-cluster-management.io/standards":  "NIST SP 800-53",
+			"policy.open-cluster-management.io/categories": "CM Configuration Management",
+			"policy.open-cluster-management.io/controls":   controls,
+		},
+	}
+
+	return testutils.NewPolicy(
+		meta, state, testutils.PolicyTemplate{Name: name + "-config", ComplianceState: state, History: history},
+	)
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+
+	policies := []policiesv1.Policy{
+		controlledPolicy("compliant", "CM-2 Baseline Configuration, CM-6 Configuration Settings", policiesv1.Compliant,
+			policiesv1.ComplianceHistory{
+				LastTimestamp: metav1.NewTime(earlier),
+				Message:       "Compliant; notification - namespaces [prod] found as specified",
+			},
+		),
+		controlledPolicy("violation", "CM-6 Configuration Settings", policiesv1.NonCompliant,
+			policiesv1.ComplianceHistory{
+				LastTimestamp: metav1.NewTime(now),
+				Message:       "NonCompliant; violation - namespaces [dev] not found",
+			},
+		),
+		controlledPolicy("pending", "CM-7 Least Functionality", policiesv1.Pending),
+	}
+
+	document := Build(clusterName, policies, now)
+	result := document.AssessmentResults.Results[0]
+
+	if !result.Start.Equal(earlier) || !result.End.Equal(now) {
+		t.Fatalf("unexpected result period: %s to %s", result.Start, result.End)
+	}
+
+	if len(result.Observations) != 3 {
+		t.Fatalf("expected an observation per policy template, got %d", len(result.Observations))
+	}
+
+	if evidence := result.Observations[0].RelevantEvidence; len(evidence) != 1 ||
+		evidence[0].Description != "Compliant; notification - namespaces [prod] found as specified" {
+		t.Fatalf("unexpected evidence: %+v", evidence)
+	}
+
+	expected := map[string]ObjectiveStatus{
+		"cm-2-baseline-configuration": {State: "satisfied", Reason: "pass"},
+		"cm-6-configuration-settings": {State: "not-satisfied", Reason: "fail"},
+		"cm-7-least-functionality":    {State: "not-satisfied", Reason: "other"},
+	}
+
+	if len(result.Findings) != len(expected) {
+		t.Fatalf("expected a finding per control, got %d", len(result.Findings))
+	}
+
+	for _, finding := range result.Findings {
+		if finding.Target.Status != expected[finding.Target.TargetID] {
+			t.Fatalf("unexpected status of %s: %+v", finding.Target.TargetID, finding.Target.Status)
+		}
+	}
+
+	if related := result.Findings[1].RelatedObservations; len(related) != 2 {
+		t.Fatalf("expected the CM-6 finding to relate to two observations, got %d", len(related))
+	}
+
+	if controls := result.ReviewedControls.ControlSelections[0].IncludeControls; len(controls) != 3 {
+		t.Fatalf("expected three reviewed controls, got %d", len(controls))
+	}
+
+	// The observation and finding UUIDs are stable across documents.
+	again := Build(clusterName, policies, now).AssessmentResults.Results[0]
+
+	if again.Findings[0].UUID != result.Findings[0].UUID || again.Observations[0].UUID != result.Observations[0].UUID {
+		t.Fatal("expected stable finding and observation UUIDs")
+	}
+}
+
+func TestWriterWrite(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := controlledPolicy("compliant", "CM-2 Baseline Configuration", policiesv1.Compliant)
+	path := filepath.Join(t.TempDir(), "assessment-results.json")
+
+	// A policy of a hub source can have the name of a policy of the hub since it's in another namespace.
+	sourcePolicy := controlledPolicy("compliant", "CM-2 Baseline Configuration", policiesv1.Compliant)
+	sourcePolicy.Namespace = "managed-hub2"
+
+	w := &Writer{
+		Client:            fake.NewClientBuilder().WithScheme(scheme).WithObjects(&policy, &sourcePolicy).Build(),
+		ClusterName:       clusterName,
+		ClusterNamespaces: []string{clusterName, "managed-hub2"},
+		Path:              path,
+		Interval:          time.Hour,
+	}
+
+	if err := w.write(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document := &Document{}
+	if err := json.Unmarshal(data, document); err != nil {
+		t.Fatal(err)
+	}
+
+	if document.AssessmentResults.Metadata.OSCALVersion != Version {
+		t.Fatalf("unexpected OSCAL version: %s", document.AssessmentResults.Metadata.OSCALVersion)
+	}
+
+	if findings := document.AssessmentResults.Results[0].Findings; len(findings) != 1 {
+		t.Fatalf("expected one finding, got %d", len(findings))
+	}
+
+	observations := document.AssessmentResults.Results[0].Observations
+	if len(observations) != 2 || observations[0].UUID == observations[1].UUID {
+		t.Fatalf("expected an observation with its own UUID per policy namespace, got %+v", observations)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected the temporary file to be removed, got %d files", len(entries))
+	}
+}
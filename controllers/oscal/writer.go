@@ -0,0 +1,185 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package oscal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/zapr"
+	"github.com/spf13/pflag"
+	"github.com/stolostron/go-log-utils/zaputil"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+var log = ctrl.Log.WithName("oscal-writer")
+
+// Writer periodically writes the OSCAL assessment-results document of the policies in the cluster namespaces to a
+// file. It implements the manager.Runnable interface.
+type Writer struct {
+	Client      client.Reader
+	ClusterName string
+	// ClusterNamespaces are the namespaces of the replicated policies of the hub and of the hub sources.
+	ClusterNamespaces []string
+	Path              string
+	Interval          time.Duration
+}
+
+// Start writes the document immediately and then every interval until ctx is closed. Failures are logged and retried on
+// the next interval.
+func (w *Writer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.write(ctx); err != nil {
+			log.Error(err, "Failed to write the OSCAL assessment results", "path", w.Path)
+		} else {
+			log.V(2).Info("Wrote the OSCAL assessment results", "path", w.Path)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// write replaces the file at the writer's path with the current document.
+func (w *Writer) write(ctx context.Context) error {
+	document, err := generate(ctx, w.Client, w.ClusterName, w.ClusterNamespaces)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(w.Path, document)
+}
+
+// writeFile replaces the file at path with data. A temporary file is renamed over the path so that readers never see a
+// partial document.
+func writeFile(path string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// generate lists the policies in the namespaces and returns the indented JSON of their assessment-results document.
+func generate(ctx context.Context, c client.Reader, clusterName string, namespaces []string) ([]byte, error) {
+	policies := []policiesv1.Policy{}
+
+	for _, namespace := range namespaces {
+		namespacePolicies := &policiesv1.PolicyList{}
+
+		if err := c.List(ctx, namespacePolicies, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("failed to list the policies in the %s namespace: %w", namespace, err)
+		}
+
+		policies = append(policies, namespacePolicies.Items...)
+	}
+
+	document, err := json.MarshalIndent(Build(clusterName, policies, time.Now()), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(document, '\n'), nil
+}
+
+// Export writes the OSCAL assessment-results document of the policies in a namespace to a file or to stdout. It takes
+// command line arguments to configure itself.
+func Export(args []string) error {
+	exportFlagSet := pflag.NewFlagSet("oscal-export", pflag.ExitOnError)
+
+	var policyNamespace, clusterName, output string
+
+	exportFlagSet.StringVar(
+		&policyNamespace, "policy-namespace", "", "The namespace where the Policy objects are stored",
+	)
+	exportFlagSet.StringVar(
+		&clusterName, "cluster-name", "", "The name of the cluster in the document (default the policy namespace)",
+	)
+	exportFlagSet.StringVar(&output, "output", "-", "The file to write the document to, or - for stdout")
+
+	zflags := zaputil.FlagConfig{
+		LevelName:   "log-level",
+		EncoderName: "log-encoder",
+	}
+
+	zflags.Bind(flag.CommandLine)
+	exportFlagSet.AddGoFlagSet(flag.CommandLine)
+
+	if err := exportFlagSet.Parse(args); err != nil {
+		return err
+	}
+
+	ctrlZap, err := zflags.BuildForCtrl()
+	if err != nil {
+		return err
+	}
+
+	ctrl.SetLogger(zapr.NewLogger(ctrlZap))
+
+	if policyNamespace == "" {
+		return errors.New("--policy-namespace must have a value")
+	}
+
+	if clusterName == "" {
+		clusterName = policyNamespace
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		return err
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+
+	document, err := generate(ctx, c, clusterName, []string{policyNamespace})
+	if err != nil {
+		return err
+	}
+
+	if output == "-" {
+		_, err = os.Stdout.Write(document)
+
+		return err
+	}
+
+	return writeFile(output, document)
+}
@@ -0,0 +1,363 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policyreport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/uninstall"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const (
+	ControllerName = "policy-report-sync"
+	// CRDName is the name of the PolicyReport CRD that must be installed for this controller to run.
+	CRDName = "policyreports.wgpolicyk8s.io"
+	// ClusterPolicyReportName is the name of the ClusterPolicyReport that aggregates the results of all policies in
+	// the cluster namespace.
+	ClusterPolicyReportName = "open-cluster-management-governance"
+	// Source is the source of the generated policy report results.
+	Source = "open-cluster-management-governance"
+)
+
+var (
+	policyReportGVK = schema.GroupVersionKind{
+		Group: "wgpolicyk8s.io", Version: "v1alpha2", Kind: "PolicyReport",
+	}
+	clusterPolicyReportGVK = schema.GroupVersionKind{
+		Group: "wgpolicyk8s.io", Version: "v1alpha2", Kind: "ClusterPolicyReport",
+	}
+	managedByLabels = map[string]string{"app.kubernetes.io/managed-by": "governance-policy-framework-addon"}
+)
+
+// SetupWithManager sets up the controller with the Manager. Every Policy event also triggers a reconcile of the
+// ClusterPolicyReport, which is represented by a request without a namespace.
+func (r *PolicyReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	skipNameValidation := true // we need to be able to stop and restart this controller
+
+	policyReport := &unstructured.Unstructured{}
+	policyReport.SetGroupVersionKind(policyReportGVK)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&policiesv1.Policy{}).
+		Watches(
+			&policiesv1.Policy{},
+			handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: ClusterPolicyReportName}}}
+			}),
+		).
+		Owns(policyReport).
+		WithOptions(controller.Options{
+			SkipNameValidation:      &skipNameValidation,
+			MaxConcurrentReconciles: r.ConcurrentReconciles,
+		}).
+		Named(ControllerName).
+		WithLogConstructor(func(req *reconcile.Request) logr.Logger {
+			return utils.LogConstructor(ControllerName, "Policy", req)
+		}).
+		Complete(r)
+}
+
+// blank assignment to verify that PolicyReportReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &PolicyReportReconciler{}
+
+// PolicyReportReconciler translates the status of the replicated policies in the cluster namespaces to wg-policy
+// PolicyReports in the namespace of each policy, and to a single ClusterPolicyReport with the results of all policies.
+type PolicyReportReconciler struct {
+	client.Client
+	// ClusterNamespaces are the namespaces of the replicated policies of the hub and of the hub sources.
+	ClusterNamespaces    []string
+	ConcurrentReconciles int
+}
+
+//+kubebuilder:rbac:groups=wgpolicyk8s.io,resources=policyreports,verbs=create;get;list;watch;update
+//+kubebuilder:rbac:groups=wgpolicyk8s.io,resources=clusterpolicyreports,verbs=create;get;list;watch;update
+
+// Reconcile creates or updates the PolicyReport of the policy in the request, or the ClusterPolicyReport when the
+// request has no namespace. PolicyReports are owned by their policy, so they are garbage collected with it.
+func (r *PolicyReportReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if uninstall.DeploymentIsUninstalling {
+		log.Info("Skipping reconcile because the deployment is in uninstallation mode")
+
+		return reconcile.Result{}, nil
+	}
+
+	if request.Namespace == "" {
+		return reconcile.Result{}, r.reconcileClusterPolicyReport(ctx, log)
+	}
+
+	policy := &policiesv1.Policy{}
+
+	err := r.Get(ctx, request.NamespacedName, policy)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			log.V(2).Info("The policy was deleted, so its PolicyReport is garbage collected")
+
+			return reconcile.Result{}, nil
+		}
+
+		log.Error(err, "Failed to get the policy")
+
+		return reconcile.Result{}, err
+	}
+
+	if policy.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	results := policyResults(policy)
+
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"scope": map[string]interface{}{
+			"apiVersion": policiesv1.GroupVersion.String(),
+			"kind":       policiesv1.Kind,
+			"name":       policy.Name,
+			"namespace":  policy.Namespace,
+			"uid":        string(policy.UID),
+		},
+		"summary": reportSummary(results),
+		"results": results,
+	}}
+	desired.SetGroupVersionKind(policyReportGVK)
+	desired.SetName(policy.Name)
+	desired.SetNamespace(policy.Namespace)
+	desired.SetLabels(managedByLabels)
+
+	if err := controllerutil.SetControllerReference(policy, desired, r.Scheme()); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, r.apply(ctx, log, desired)
+}
+
+// reconcileClusterPolicyReport creates or updates the ClusterPolicyReport from the results of all policies in the
+// cluster namespaces. It's skipped when the ClusterPolicyReport CRD isn't installed.
+func (r *PolicyReportReconciler) reconcileClusterPolicyReport(ctx context.Context, log logr.Logger) error {
+	policies := []policiesv1.Policy{}
+
+	for _, namespace := range r.ClusterNamespaces {
+		namespacePolicies := &policiesv1.PolicyList{}
+
+		if err := r.List(ctx, namespacePolicies, client.InNamespace(namespace)); err != nil {
+			log.Error(err, "Failed to list the policies", "namespace", namespace)
+
+			return err
+		}
+
+		policies = append(policies, namespacePolicies.Items...)
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Namespace != policies[j].Namespace {
+			return policies[i].Namespace < policies[j].Namespace
+		}
+
+		return policies[i].Name < policies[j].Name
+	})
+
+	results := []interface{}{}
+
+	for i := range policies {
+		if policies[i].DeletionTimestamp == nil {
+			results = append(results, policyResults(&policies[i])...)
+		}
+	}
+
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"summary": reportSummary(results),
+		"results": results,
+	}}
+	desired.SetGroupVersionKind(clusterPolicyReportGVK)
+	desired.SetName(ClusterPolicyReportName)
+	desired.SetLabels(managedByLabels)
+
+	err := r.apply(ctx, log, desired)
+	if apimeta.IsNoMatchError(err) {
+		log.V(2).Info("The ClusterPolicyReport CRD is not installed, so the ClusterPolicyReport is not generated")
+
+		return nil
+	}
+
+	return err
+}
+
+// apply creates the input report or updates its summary, results, scope, labels and owner references when they
+// differ from the existing report.
+func (r *PolicyReportReconciler) apply(ctx context.Context, log logr.Logger, desired *unstructured.Unstructured) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(desired.GroupVersionKind())
+
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	if k8serrors.IsNotFound(err) {
+		log.Info("Creating the report", "kind", desired.GetKind(), "name", desired.GetName())
+
+		return r.Create(ctx, desired)
+	} else if err != nil {
+		return err
+	}
+
+	updated := existing.DeepCopy()
+
+	for _, field := range []string{"scope", "summary", "results"} {
+		if value, found := desired.Object[field]; found {
+			updated.Object[field] = value
+		} else {
+			delete(updated.Object, field)
+		}
+	}
+
+	labels := updated.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	for key, value := range managedByLabels {
+		labels[key] = value
+	}
+
+	updated.SetLabels(labels)
+	updated.SetOwnerReferences(desired.GetOwnerReferences())
+
+	// Compare through a JSON round trip so that the integer types of the desired object match the existing object.
+	desiredJSON, err := updated.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	if err := updated.UnmarshalJSON(desiredJSON); err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Object, updated.Object) {
+		return nil
+	}
+
+	log.V(1).Info("Updating the report", "kind", desired.GetKind(), "name", desired.GetName())
+
+	return r.Update(ctx, updated)
+}
+
+// policyResults returns a policy report result for each policy template of the policy, based on the compliance and
+// latest message in the policy status details of the template.
+func policyResults(policy *policiesv1.Policy) []interface{} {
+	results := []interface{}{}
+	categories := policy.GetAnnotations()[common.APIGroup+"/categories"]
+
+	for _, policyT := range policy.Spec.PolicyTemplates {
+		tmpl := utils.TemplateObject(policyT)
+		if tmpl == nil {
+			continue
+		}
+
+		var dpt *policiesv1.DetailsPerTemplate
+
+		for _, details := range policy.Status.Details {
+			if details != nil && details.TemplateMeta.Name == tmpl.GetName() {
+				dpt = details
+
+				break
+			}
+		}
+
+		result := map[string]interface{}{
+			"source": Source,
+			"policy": policy.Name,
+			"rule":   tmpl.GetName(),
+			"result": "skip",
+			"resources": []interface{}{
+				map[string]interface{}{
+					"apiVersion": tmpl.GetAPIVersion(),
+					"kind":       tmpl.GetKind(),
+					"name":       tmpl.GetName(),
+					"namespace":  policy.Namespace,
+				},
+			},
+			"properties": map[string]interface{}{
+				"policyNamespace": policy.Namespace,
+				"templateKind":    tmpl.GroupVersionKind().GroupKind().String(),
+			},
+		}
+
+		if categories != "" {
+			result["category"] = categories
+		}
+
+		if severity, _, _ := unstructured.NestedString(tmpl.Object, "spec", "severity"); severity != "" {
+			result["severity"] = severity
+		}
+
+		message := "No compliance status has been reported for the policy template"
+
+		if dpt != nil {
+			result["result"] = complianceResult(dpt.ComplianceState)
+
+			if len(dpt.History) > 0 {
+				message = dpt.History[0].Message
+
+				if !dpt.History[0].LastTimestamp.IsZero() {
+					result["timestamp"] = map[string]interface{}{
+						"seconds": dpt.History[0].LastTimestamp.Unix(),
+						"nanos":   int64(0),
+					}
+				}
+			} else if dpt.ComplianceState != "" {
+				message = fmt.Sprintf("The policy template is %s", dpt.ComplianceState)
+			}
+		}
+
+		result["message"] = message
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// complianceResult maps a compliance state to a policy report result. Pending and unknown compliance are skipped.
+func complianceResult(state policiesv1.ComplianceState) string {
+	switch state {
+	case policiesv1.Compliant:
+		return "pass"
+	case policiesv1.NonCompliant:
+		return "fail"
+	default:
+		return "skip"
+	}
+}
+
+// reportSummary counts the results of each type.
+func reportSummary(results []interface{}) map[string]interface{} {
+	summary := map[string]interface{}{
+		"pass": int64(0), "fail": int64(0), "warn": int64(0), "error": int64(0), "skip": int64(0),
+	}
+
+	for _, result := range results {
+		if resultType, ok := result.(map[string]interface{})["result"].(string); ok {
+			if count, ok := summary[resultType].(int64); ok {
+				summary[resultType] = count + 1
+			}
+		}
+	}
+
+	return summary
+}
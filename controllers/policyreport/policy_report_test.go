@@ -0,0 +1,200 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policyreport
+
+import (
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	testutils "open-cluster-management.io/governance-policy-framework-addon/test/utils"
+)
+
+const clusterName = "managed"
+
+// reportedPolicy returns a policy with a compliant and a violated ConfigurationPolicy template and a new one that has
+// no status yet.
+func reportedPolicy() *policiesv1.Policy {
+	meta := metav1.ObjectMeta{
+		Name:        "policy",
+		Namespace:   clusterName,
+		UID:         "2b7a6f5e-4e1c-4d43-9c4b-8ad8f1c0b1d9",
+		Annotations: map[string]string{"policy.open-cluster-management.io/categories": "CM Configuration Management"},
+	}
+
+	policy := testutils.NewPolicy(meta, "",
+		testutils.PolicyTemplate{
+			Name:            "compliant",
+			Spec:            `{"severity":"low"}`,
+			ComplianceState: policiesv1.Compliant,
+			History: []policiesv1.ComplianceHistory{{
+				LastTimestamp: metav1.NewTime(time.Unix(1700000000, 0)),
+				Message:       "Compliant; notification - pods [nginx] found as specified in namespace default",
+			}},
+		},
+		testutils.PolicyTemplate{
+			Name:            "violation",
+			ComplianceState: policiesv1.NonCompliant,
+			History: []policiesv1.ComplianceHistory{{
+				Message: "NonCompliant; violation - pods [nginx] not found in namespace default",
+			}},
+		},
+		testutils.PolicyTemplate{Name: "new"},
+	)
+
+	return &policy
+}
+
+func TestPolicyResults(t *testing.T) {
+	t.Parallel()
+
+	results := policyResults(reportedPolicy())
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	expected := []struct {
+		rule   string
+		result string
+	}{
+		{"compliant", "pass"},
+		{"violation", "fail"},
+		{"new", "skip"},
+	}
+
+	for i, exp := range expected {
+		result := results[i].(map[string]interface{})
+
+		if result["rule"] != exp.rule || result["result"] != exp.result {
+			t.Fatalf("unexpected result %d: %v", i, result)
+		}
+
+		if result["category"] != "CM Configuration Management" {
+			t.Fatalf("unexpected category in result %d: %v", i, result["category"])
+		}
+	}
+
+	first := results[0].(map[string]interface{})
+
+	if first["severity"] != "low" {
+		t.Fatalf("expected the severity of the template, got %v", first["severity"])
+	}
+
+	if first["timestamp"].(map[string]interface{})["seconds"] != int64(1700000000) {
+		t.Fatalf("unexpected timestamp: %v", first["timestamp"])
+	}
+
+	summary := reportSummary(results)
+	if summary["pass"] != int64(1) || summary["fail"] != int64(1) || summary["skip"] != int64(1) {
+		t.Fatalf("unexpected summary: %v", summary)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the PolicyReport CRD is installed.
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	mapper.Add(policiesv1.GroupVersion.WithKind(policiesv1.Kind), apimeta.RESTScopeNamespace)
+	mapper.Add(policyReportGVK, apimeta.RESTScopeNamespace)
+
+	policy := reportedPolicy()
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(policy).Build()
+	r := &PolicyReportReconciler{Client: fakeClient, ClusterNamespaces: []string{clusterName}}
+	key := types.NamespacedName{Namespace: clusterName, Name: policy.Name}
+
+	if _, err := r.Reconcile(t.Context(), reconcile.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := &unstructured.Unstructured{}
+	report.SetGroupVersionKind(policyReportGVK)
+
+	if err := fakeClient.Get(t.Context(), key, report); err != nil {
+		t.Fatal(err)
+	}
+
+	if owners := report.GetOwnerReferences(); len(owners) != 1 || owners[0].UID != policy.UID {
+		t.Fatalf("expected the report to be owned by the policy, got %v", owners)
+	}
+
+	if fail, _, _ := unstructured.NestedInt64(report.Object, "summary", "fail"); fail != 1 {
+		t.Fatalf("expected one failed result, got %d", fail)
+	}
+
+	// An unchanged report isn't updated.
+	if _, err := r.Reconcile(t.Context(), reconcile.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	unchanged := &unstructured.Unstructured{}
+	unchanged.SetGroupVersionKind(policyReportGVK)
+
+	if err := fakeClient.Get(t.Context(), key, unchanged); err != nil {
+		t.Fatal(err)
+	}
+
+	if unchanged.GetResourceVersion() != report.GetResourceVersion() {
+		t.Fatal("expected the PolicyReport not to be updated")
+	}
+
+	// The ClusterPolicyReport is skipped since its CRD isn't installed.
+	clusterKey := types.NamespacedName{Name: ClusterPolicyReportName}
+
+	if _, err := r.Reconcile(t.Context(), reconcile.Request{NamespacedName: clusterKey}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcileClusterPolicyReport(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	mapper.Add(policiesv1.GroupVersion.WithKind(policiesv1.Kind), apimeta.RESTScopeNamespace)
+	mapper.Add(clusterPolicyReportGVK, apimeta.RESTScopeRoot)
+
+	// A policy of a hub source is in its own cluster namespace.
+	sourcePolicy := reportedPolicy()
+	sourcePolicy.Namespace = "managed-hub2"
+	sourcePolicy.UID = "6f0c2d4a-1b3e-4f5a-8c7d-9e0f1a2b3c4d"
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(
+		reportedPolicy(), sourcePolicy,
+	).Build()
+	r := &PolicyReportReconciler{Client: fakeClient, ClusterNamespaces: []string{clusterName, "managed-hub2"}}
+	clusterKey := types.NamespacedName{Name: ClusterPolicyReportName}
+
+	if _, err := r.Reconcile(t.Context(), reconcile.Request{NamespacedName: clusterKey}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := &unstructured.Unstructured{}
+	report.SetGroupVersionKind(clusterPolicyReportGVK)
+
+	if err := fakeClient.Get(t.Context(), clusterKey, report); err != nil {
+		t.Fatal(err)
+	}
+
+	results, _, _ := unstructured.NestedSlice(report.Object, "results")
+	if len(results) != 6 {
+		t.Fatalf("expected the results of the policies in both cluster namespaces, got %d", len(results))
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// AckDeletionAnnotation is set to "true" by a local administrator on a replicated policy on the managed cluster to
+// release its deletion when it was held by the mass-deletion guard.
+const AckDeletionAnnotation = common.APIGroup + "/ack-deletion"
+
+// heldDeletionRequeue is how often a held deletion is checked for the AckDeletionAnnotation.
+const heldDeletionRequeue = 30 * time.Second
+
+var policyHeldDeletionsGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "policy_spec_sync_held_deletions",
+		Help: "The number of replicated policies whose deletion is held by the mass-deletion guard until it's " +
+			"acknowledged",
+	},
+	[]string{"namespace"},
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	alreadyReg := &prometheus.AlreadyRegisteredError{}
+
+	regErr := metrics.Registry.Register(policyHeldDeletionsGauge)
+	if regErr != nil && !errors.As(regErr, alreadyReg) {
+		panic(regErr)
+	}
+}
+
+// DeletionGuard holds the deletions of replicated policies when more than MaxPolicies policies, or more than
+// MaxPercent percent of the replicated policies, would be deleted within Window. This protects against a
+// misconfiguration on the hub wiping out the replicated policies, and the resources that they prune. Once the guard is
+// tripped, every deletion is held until it's acknowledged with the AckDeletionAnnotation or the hub policy is back.
+// A zero MaxPolicies or MaxPercent disables that threshold. The held deletions are recorded with the
+// utils.DeletionHeldAnnotation on the replicated policies, and they're restored before the first deletion is checked,
+// so that they stay held after a restart or a leader change.
+type DeletionGuard struct {
+	MaxPolicies int
+	MaxPercent  int
+	Window      time.Duration
+
+	lock sync.Mutex
+	// The times of the deletions within the window.
+	deletions []time.Time
+	held      map[string]bool
+	restored  bool
+}
+
+// restore holds the deletions of the policies with the utils.DeletionHeldAnnotation, once. The lock is kept while they
+// are listed so that no deletion is checked or released before they're restored.
+func (g *DeletionGuard) restore(list func() ([]policiesv1.Policy, error)) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.restored {
+		return nil
+	}
+
+	policies, err := list()
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if _, held := policy.GetAnnotations()[utils.DeletionHeldAnnotation]; !held {
+			continue
+		}
+
+		if g.held == nil {
+			g.held = map[string]bool{}
+		}
+
+		g.held[policy.Name] = true
+	}
+
+	g.restored = true
+
+	return nil
+}
+
+// hold returns whether the deletion of the policy is held, and whether it was newly held. The total is the number of
+// replicated policies on the managed cluster, including the policy.
+func (g *DeletionGuard) hold(now time.Time, name string, total int) (held bool, newlyHeld bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.held[name] {
+		return true, false
+	}
+
+	recent := make([]time.Time, 0, len(g.deletions))
+
+	for _, deletion := range g.deletions {
+		if now.Sub(deletion) < g.Window {
+			recent = append(recent, deletion)
+		}
+	}
+
+	g.deletions = recent
+
+	if len(g.held) == 0 && !g.exceeds(len(recent)+1, total+len(recent)) {
+		g.deletions = append(g.deletions, now)
+
+		return false, false
+	}
+
+	if g.held == nil {
+		g.held = map[string]bool{}
+	}
+
+	g.held[name] = true
+
+	return true, true
+}
+
+// exceeds returns whether deleting count policies out of total exceeds a threshold.
+func (g *DeletionGuard) exceeds(count int, total int) bool {
+	if g.MaxPolicies > 0 && count > g.MaxPolicies {
+		return true
+	}
+
+	return g.MaxPercent > 0 && count*100 > g.MaxPercent*total
+}
+
+// release stops holding the deletion of the policy and returns whether it was held. Once no deletion is held, the
+// guard is reset so that the acknowledged deletions don't trip it again.
+func (g *DeletionGuard) release(name string) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if !g.held[name] {
+		return false
+	}
+
+	delete(g.held, name)
+
+	if len(g.held) == 0 {
+		g.deletions = nil
+	}
+
+	return true
+}
+
+// heldCount returns the number of held deletions.
+func (g *DeletionGuard) heldCount() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return len(g.held)
+}
+
+// restoreHeldDeletions restores the held deletions of the mass-deletion guard from the replicated policies, once.
+func (r *PolicyReconciler) restoreHeldDeletions(ctx context.Context) error {
+	err := r.DeletionGuard.restore(func() ([]policiesv1.Policy, error) {
+		policies := &policiesv1.PolicyList{}
+
+		if err := r.ManagedClient.List(ctx, policies, client.InNamespace(r.TargetNamespace)); err != nil {
+			return nil, err
+		}
+
+		matching := make([]policiesv1.Policy, 0, len(policies.Items))
+
+		for i := range policies.Items {
+			// The deletions of the other policies are held by the addon instance that syncs them.
+			if utils.MatchesPolicySelector(r.PolicySelector, &policies.Items[i]) {
+				matching = append(matching, policies.Items[i])
+			}
+		}
+
+		return matching, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore the held deletions: %w", err)
+	}
+
+	policyHeldDeletionsGauge.WithLabelValues(r.TargetNamespace).Set(float64(r.DeletionGuard.heldCount()))
+
+	return nil
+}
+
+// holdDeletion returns whether the deletion of the replicated policy, whose policy on the hub is missing, is held by
+// the mass-deletion guard. A held deletion is recorded with the utils.DeletionHeldAnnotation on the policy, and a
+// newly held deletion is reported with a warning Event.
+func (r *PolicyReconciler) holdDeletion(ctx context.Context, managedPlc *policiesv1.Policy) (bool, error) {
+	if r.DeletionGuard == nil {
+		return false, nil
+	}
+
+	if err := r.restoreHeldDeletions(ctx); err != nil {
+		return false, err
+	}
+
+	reqLogger := ctrl.LoggerFrom(ctx)
+
+	if strings.EqualFold(managedPlc.GetAnnotations()[AckDeletionAnnotation], "true") {
+		reqLogger.Info("The deletion of the policy was acknowledged")
+
+		return false, nil
+	}
+
+	policies := &policiesv1.PolicyList{}
+
+	if err := r.ManagedClient.List(ctx, policies, client.InNamespace(r.TargetNamespace)); err != nil {
+		return false, err
+	}
+
+	held, newlyHeld := r.DeletionGuard.hold(time.Now(), managedPlc.Name, len(policies.Items))
+
+	policyHeldDeletionsGauge.WithLabelValues(r.TargetNamespace).Set(float64(r.DeletionGuard.heldCount()))
+
+	if _, recorded := managedPlc.GetAnnotations()[utils.DeletionHeldAnnotation]; held && !recorded {
+		heldPlc := managedPlc.DeepCopy()
+		annotations := heldPlc.GetAnnotations()
+
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations[utils.DeletionHeldAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		heldPlc.SetAnnotations(annotations)
+
+		err := r.ManagedClient.Patch(ctx, heldPlc, client.MergeFrom(managedPlc), client.FieldOwner(ControllerName))
+		if err != nil {
+			return true, err
+		}
+	}
+
+	if !newlyHeld {
+		return held, nil
+	}
+
+	reqLogger.Info(
+		"Holding the deletion of the policy because too many policies would be deleted",
+		"maxPolicies", r.DeletionGuard.MaxPolicies, "maxPercent", r.DeletionGuard.MaxPercent,
+		"window", r.DeletionGuard.Window.String(),
+	)
+
+	msg := fmt.Sprintf(
+		"The deletion of policy %s in cluster namespace %s is held because too many policies would be deleted "+
+			"within %s. Set the %s annotation to true on the policy to delete it.",
+		managedPlc.Name, r.TargetNamespace, r.DeletionGuard.Window, AckDeletionAnnotation,
+	)
+
+	r.ManagedRecorder.Eventf(managedPlc, nil, corev1.EventTypeWarning, "PolicyDeletionHeld", "PolicySpecSync", msg)
+
+	return true, nil
+}
+
+// releaseDeletion stops holding the deletion of the policy, such as when it's deleted or the hub policy is back, and
+// removes the utils.DeletionHeldAnnotation from the policy when it still exists.
+func (r *PolicyReconciler) releaseDeletion(ctx context.Context, name string) error {
+	if r.DeletionGuard == nil {
+		return nil
+	}
+
+	if err := r.restoreHeldDeletions(ctx); err != nil {
+		return err
+	}
+
+	if !r.DeletionGuard.release(name) {
+		return nil
+	}
+
+	policyHeldDeletionsGauge.WithLabelValues(r.TargetNamespace).Set(float64(r.DeletionGuard.heldCount()))
+
+	managedPlc := &policiesv1.Policy{}
+
+	err := r.ManagedClient.Get(ctx, types.NamespacedName{Namespace: r.TargetNamespace, Name: name}, managedPlc)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if _, recorded := managedPlc.GetAnnotations()[utils.DeletionHeldAnnotation]; !recorded {
+		return nil
+	}
+
+	releasedPlc := managedPlc.DeepCopy()
+	annotations := releasedPlc.GetAnnotations()
+	delete(annotations, utils.DeletionHeldAnnotation)
+	releasedPlc.SetAnnotations(annotations)
+
+	return r.ManagedClient.Patch(ctx, releasedPlc, client.MergeFrom(managedPlc), client.FieldOwner(ControllerName))
+}
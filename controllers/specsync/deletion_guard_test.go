@@ -0,0 +1,218 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestDeletionGuardHold(t *testing.T) {
+	t.Parallel()
+
+	guard := &DeletionGuard{MaxPercent: 50, Window: time.Minute}
+	start := time.Now()
+
+	// 2 out of 4 policies is at the limit.
+	for i, name := range []string{"a", "b"} {
+		if held, _ := guard.hold(start, name, 4-i); held {
+			t.Fatalf("Expected the deletion of %s to not be held", name)
+		}
+	}
+
+	if held, newlyHeld := guard.hold(start, "c", 2); !held || !newlyHeld {
+		t.Fatal("Expected the deletion of c to be newly held")
+	}
+
+	if held, newlyHeld := guard.hold(start, "c", 2); !held || newlyHeld {
+		t.Fatal("Expected the deletion of c to still be held")
+	}
+
+	// Once tripped, the guard holds deletions even after the window.
+	if held, _ := guard.hold(start.Add(2*time.Minute), "d", 2); !held {
+		t.Fatal("Expected the deletion of d to be held")
+	}
+
+	guard.release("c")
+	guard.release("d")
+
+	if held, _ := guard.hold(start.Add(2*time.Minute), "e", 4); held {
+		t.Fatal("Expected the deletion of e to not be held once the held deletions are released")
+	}
+
+	countGuard := &DeletionGuard{MaxPolicies: 1, Window: time.Minute}
+
+	countGuard.hold(start, "a", 100)
+
+	if held, _ := countGuard.hold(start.Add(30*time.Second), "b", 99); !held {
+		t.Fatal("Expected the second deletion within the window to be held")
+	}
+
+	countGuard.release("b")
+
+	if held, _ := countGuard.hold(start.Add(2*time.Minute), "b", 99); held {
+		t.Fatal("Expected the deletion after the window to not be held")
+	}
+}
+
+func TestReconcileDeletionGuard(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	managedObjs := make([]client.Object, 0, 3)
+
+	for i := range 3 {
+		managedObjs = append(managedObjs, &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("policies.guarded-%d", i), Namespace: "guarded",
+		}})
+	}
+
+	recorder := events.NewFakeRecorder(10)
+
+	r := &PolicyReconciler{
+		// Every hub policy is missing.
+		HubClient:          fake.NewClientBuilder().WithScheme(scheme).Build(),
+		ManagedClient:      fake.NewClientBuilder().WithScheme(scheme).WithObjects(managedObjs...).Build(),
+		ManagedRecorder:    recorder,
+		TargetNamespace:    "guarded",
+		StatusSyncRequests: make(chan event.GenericEvent, 10),
+		DeletionGuard:      &DeletionGuard{MaxPolicies: 1, Window: time.Hour},
+	}
+
+	reconcilePolicy := func(name string) reconcile.Result {
+		t.Helper()
+
+		result, err := r.Reconcile(context.TODO(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "managed-hub", Name: name},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		return result
+	}
+
+	exists := func(name string) bool {
+		t.Helper()
+
+		err := r.ManagedClient.Get(
+			context.TODO(), types.NamespacedName{Namespace: "guarded", Name: name}, &policiesv1.Policy{},
+		)
+		if err != nil && !errors.IsNotFound(err) {
+			t.Fatalf("Failed to get the policy: %v", err)
+		}
+
+		return err == nil
+	}
+
+	reconcilePolicy("policies.guarded-0")
+
+	if exists("policies.guarded-0") {
+		t.Fatal("Expected the first policy to be deleted")
+	}
+
+	for _, name := range []string{"policies.guarded-1", "policies.guarded-2"} {
+		if result := reconcilePolicy(name); result.RequeueAfter != heldDeletionRequeue {
+			t.Fatalf("Expected the held deletion of %s to be requeued but got: %v", name, result)
+		}
+
+		if !exists(name) {
+			t.Fatalf("Expected the deletion of %s to be held", name)
+		}
+	}
+
+	if len(recorder.Events) != 2 {
+		t.Fatalf("Expected a warning event per held deletion but got %d", len(recorder.Events))
+	}
+
+	if held := testutil.ToFloat64(policyHeldDeletionsGauge.WithLabelValues("guarded")); held != 2 {
+		t.Fatalf("Expected 2 held deletions in the metric but got %v", held)
+	}
+
+	plc := &policiesv1.Policy{}
+
+	err := r.ManagedClient.Get(
+		context.TODO(), types.NamespacedName{Namespace: "guarded", Name: "policies.guarded-1"}, plc,
+	)
+	if err != nil {
+		t.Fatalf("Failed to get the policy: %v", err)
+	}
+
+	plc.SetAnnotations(map[string]string{AckDeletionAnnotation: "true"})
+
+	if err := r.ManagedClient.Update(context.TODO(), plc); err != nil {
+		t.Fatalf("Failed to acknowledge the deletion: %v", err)
+	}
+
+	reconcilePolicy("policies.guarded-1")
+
+	if exists("policies.guarded-1") {
+		t.Fatal("Expected the acknowledged deletion to be released")
+	}
+
+	if !exists("policies.guarded-2") {
+		t.Fatal("Expected the unacknowledged deletion to still be held")
+	}
+
+	if held := testutil.ToFloat64(policyHeldDeletionsGauge.WithLabelValues("guarded")); held != 1 {
+		t.Fatalf("Expected 1 held deletion in the metric but got %v", held)
+	}
+
+	err = r.ManagedClient.Get(
+		context.TODO(), types.NamespacedName{Namespace: "guarded", Name: "policies.guarded-2"}, plc,
+	)
+	if err != nil {
+		t.Fatalf("Failed to get the policy: %v", err)
+	}
+
+	if _, recorded := plc.GetAnnotations()[utils.DeletionHeldAnnotation]; !recorded {
+		t.Fatal("Expected the held deletion to be recorded on the policy")
+	}
+
+	// A restart or a leader change re-creates the reconciler with an empty guard, which would allow the next deletion.
+	r = &PolicyReconciler{
+		HubClient:          r.HubClient,
+		ManagedClient:      r.ManagedClient,
+		ManagedRecorder:    recorder,
+		TargetNamespace:    "guarded",
+		StatusSyncRequests: make(chan event.GenericEvent, 10),
+		DeletionGuard:      &DeletionGuard{MaxPolicies: 1, Window: time.Hour},
+	}
+
+	if result := reconcilePolicy("policies.guarded-2"); result.RequeueAfter != heldDeletionRequeue {
+		t.Fatalf("Expected the held deletion to be requeued after the restart but got: %v", result)
+	}
+
+	if !exists("policies.guarded-2") {
+		t.Fatal("Expected the deletion to still be held after the restart")
+	}
+
+	if held := testutil.ToFloat64(policyHeldDeletionsGauge.WithLabelValues("guarded")); held != 1 {
+		t.Fatalf("Expected 1 held deletion in the metric after the restart but got %v", held)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const (
+	// DriftModeStrict reverts local changes to replicated policies immediately.
+	DriftModeStrict = "strict"
+	// DriftModeReport reports local changes to replicated policies and leaves them until the hub policy changes.
+	DriftModeReport = "report"
+)
+
+var policySpecDriftCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "policy_spec_drift_total",
+		Help: "The number of times a replicated policy was changed on the managed cluster without a change to the " +
+			"policy on the hub",
+	},
+	[]string{"namespace", "policy"},
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	alreadyReg := &prometheus.AlreadyRegisteredError{}
+
+	regErr := metrics.Registry.Register(policySpecDriftCounter)
+	if regErr != nil && !errors.As(regErr, alreadyReg) {
+		panic(regErr)
+	}
+}
+
+// lastEditor returns the field manager that most recently changed the annotations or spec of the replicated policy,
+// excluding the spec sync itself and the template sync recording the applied generation. This relies on the API
+// server only giving a manager ownership of the fields that it changed in an update.
+func lastEditor(managedPlc *policiesv1.Policy) string {
+	editor := "an unknown manager"
+
+	var editTime time.Time
+
+	for _, entry := range managedPlc.GetManagedFields() {
+		if entry.Manager == ControllerName || entry.Manager == utils.TemplatesAppliedFieldManager {
+			continue
+		}
+
+		if entry.Subresource != "" || entry.FieldsV1 == nil || entry.Time == nil {
+			continue
+		}
+
+		fields := string(entry.FieldsV1.Raw)
+		if !strings.Contains(fields, `"f:spec"`) && !strings.Contains(fields, `"f:annotations"`) {
+			continue
+		}
+
+		if entry.Time.After(editTime) {
+			editor = entry.Manager
+			editTime = entry.Time.Time
+		}
+	}
+
+	return editor
+}
+
+// detectDrift returns whether the replicated policy that doesn't match the desired policy was changed on the managed
+// cluster rather than on the hub. This is the case when the desired policy didn't change since the last sync recorded
+// in the LastSyncedHashAnnotation. A drift is reported with Events on the managed and hub policies and the drift
+// metric, once per local change. In the report drift mode, the reported drift is recorded in the DriftedHashAnnotation
+// so that it's not reported again after a restart, and so that the status sync accepts the policy.
+func (r *PolicyReconciler) detectDrift(
+	ctx context.Context, instance, desired, managedPlc *policiesv1.Policy,
+) (drifted bool, manager string, err error) {
+	lastSynced := managedPlc.GetAnnotations()[utils.LastSyncedHashAnnotation]
+	if lastSynced == "" || lastSynced != utils.SyncedHash(desired) {
+		return false, "", nil
+	}
+
+	// A pause of the sync, which has expired at this point, is an intentional local change.
+	if _, paused := managedPlc.GetAnnotations()[utils.PauseSyncAnnotation]; paused {
+		return false, "", nil
+	}
+
+	manager = lastEditor(managedPlc)
+
+	if utils.DriftRecorded(managedPlc) {
+		return true, manager, nil
+	}
+
+	if r.DriftMode == DriftModeReport {
+		recorded := managedPlc.DeepCopy()
+		annotations := recorded.GetAnnotations()
+		annotations[utils.DriftedHashAnnotation] = utils.SyncedHash(managedPlc)
+		recorded.SetAnnotations(annotations)
+
+		err := r.ManagedClient.Patch(ctx, recorded, client.MergeFrom(managedPlc), client.FieldOwner(ControllerName))
+		if err != nil {
+			return true, manager, err
+		}
+	}
+
+	ctrl.LoggerFrom(ctx).Info(
+		"The policy was changed on the managed cluster and no longer matches the hub",
+		"manager", manager, "driftMode", r.DriftMode,
+	)
+
+	policySpecDriftCounter.WithLabelValues(managedPlc.Namespace, managedPlc.Name).Inc()
+
+	action := "reverted"
+	if r.DriftMode == DriftModeReport {
+		action = "left as is until the policy changes on the hub"
+	}
+
+	msg := fmt.Sprintf(
+		"Policy %s was changed in cluster namespace %s by %s without a change on the hub, and the change was %s",
+		managedPlc.Name, r.TargetNamespace, manager, action,
+	)
+
+	r.ManagedRecorder.Eventf(managedPlc, nil, corev1.EventTypeWarning, "PolicySpecDrift", "PolicySpecSync", msg)
+
+	if r.HubRecorder != nil {
+		r.HubRecorder.Eventf(instance, nil, corev1.EventTypeWarning, "PolicySpecDrift", "PolicySpecSync", msg)
+	}
+
+	return true, manager, nil
+}
+
+// setSyncedHash records the SyncedHash of the desired policy in the LastSyncedHashAnnotation of the replicated policy
+// and removes its DriftedHashAnnotation and RejectedHashAnnotation.
+func setSyncedHash(managedPlc *policiesv1.Policy, hash string) {
+	annotations := managedPlc.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[utils.LastSyncedHashAnnotation] = hash
+	delete(annotations, utils.DriftedHashAnnotation)
+	delete(annotations, utils.RejectedHashAnnotation)
+	managedPlc.SetAnnotations(annotations)
+}
+
+// recordSync patches the replicated policy that matches the desired policy to record the sync with setSyncedHash,
+// when it's not recorded yet, such as for a policy that was synced by a previous version, whose drift was reverted
+// locally, or whose hub policy was reverted to the synced version after its signature was rejected.
+func (r *PolicyReconciler) recordSync(ctx context.Context, managedPlc *policiesv1.Policy, hash string) error {
+	annotations := managedPlc.GetAnnotations()
+	_, drifted := annotations[utils.DriftedHashAnnotation]
+	_, rejected := annotations[utils.RejectedHashAnnotation]
+
+	if !drifted && !rejected && annotations[utils.LastSyncedHashAnnotation] == hash {
+		return nil
+	}
+
+	recorded := managedPlc.DeepCopy()
+	setSyncedHash(recorded, hash)
+
+	return r.ManagedClient.Patch(ctx, recorded, client.MergeFrom(managedPlc), client.FieldOwner(ControllerName))
+}
+
+// forgetSync removes the sync state of a deleted replicated policy.
+func (r *PolicyReconciler) forgetSync(name string) {
+	r.rejectedSignatures.Delete(name)
+	policySpecDriftCounter.DeleteLabelValues(r.TargetNamespace, name)
+}
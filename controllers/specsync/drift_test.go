@@ -0,0 +1,206 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestLastEditor(t *testing.T) {
+	t.Parallel()
+
+	entry := func(manager string, minute int, subresource string, fields string) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager:     manager,
+			Operation:   metav1.ManagedFieldsOperationUpdate,
+			Time:        &metav1.Time{Time: time.Date(2026, 3, 1, 12, minute, 0, 0, time.UTC)},
+			Subresource: subresource,
+			FieldsV1:    &metav1.FieldsV1{Raw: []byte(fields)},
+		}
+	}
+
+	policy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{
+		entry(ControllerName, 0, "", `{"f:spec":{"f:remediationAction":{}}}`),
+		entry("kubectl-edit", 1, "", `{"f:spec":{"f:remediationAction":{}}}`),
+		entry("governance-policy-framework-addon", 2, "status", `{"f:status":{}}`),
+		entry(ControllerName, 3, "", `{"f:metadata":{"f:labels":{}}}`),
+	}}}
+
+	if editor := lastEditor(policy); editor != "kubectl-edit" {
+		t.Fatalf("expected the kubectl-edit manager, got %s", editor)
+	}
+
+	if editor := lastEditor(&policiesv1.Policy{}); editor != "an unknown manager" {
+		t.Fatalf("expected an unknown manager, got %s", editor)
+	}
+}
+
+func driftTestReconciler(t *testing.T, driftMode string) (*PolicyReconciler, *events.FakeRecorder) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	hubPolicy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policies.drift-" + driftMode, Namespace: "managed-hub"},
+		Spec:       policiesv1.PolicySpec{RemediationAction: policiesv1.Enforce},
+	}
+
+	recorder := events.NewFakeRecorder(10)
+
+	return &PolicyReconciler{
+		HubClient:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(hubPolicy).Build(),
+		ManagedClient:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+		ManagedRecorder:    recorder,
+		TargetNamespace:    "managed",
+		StatusSyncRequests: make(chan event.GenericEvent, 10),
+		DriftMode:          driftMode,
+	}, recorder
+}
+
+func TestReconcileDrift(t *testing.T) {
+	t.Parallel()
+
+	for _, driftMode := range []string{DriftModeStrict, DriftModeReport} {
+		t.Run(driftMode, func(t *testing.T) {
+			t.Parallel()
+
+			r, recorder := driftTestReconciler(t, driftMode)
+			name := "policies.drift-" + driftMode
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "managed-hub", Name: name}}
+			managedNN := types.NamespacedName{Namespace: "managed", Name: name}
+
+			if _, err := r.Reconcile(context.TODO(), request); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			managedPolicy := &policiesv1.Policy{}
+			if err := r.ManagedClient.Get(context.TODO(), managedNN, managedPolicy); err != nil {
+				t.Fatalf("Expected the policy to be created but got: %v", err)
+			}
+
+			<-recorder.Events
+
+			// A local edit without a change on the hub
+			managedPolicy.Spec.RemediationAction = policiesv1.Inform
+			if err := r.ManagedClient.Update(context.TODO(), managedPolicy); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			for range 2 {
+				if _, err := r.Reconcile(context.TODO(), request); err != nil {
+					t.Fatalf("Expected no error but got: %v", err)
+				}
+			}
+
+			// The drift is only reported once per local change.
+			if count := testutil.ToFloat64(policySpecDriftCounter.WithLabelValues("managed", name)); count != 1 {
+				t.Fatalf("Expected the drift to be counted once, got %v", count)
+			}
+
+			if evt := <-recorder.Events; !strings.HasPrefix(evt, "Warning PolicySpecDrift") {
+				t.Fatalf("Expected a drift warning event, got %s", evt)
+			}
+
+			// Drain the remaining events, such as the update of the reverted drift.
+			for len(recorder.Events) != 0 {
+				<-recorder.Events
+			}
+
+			// The sync and the reported drift are recorded on the policy, so a restart neither reverts nor reports
+			// the drift again.
+			restarted := &PolicyReconciler{
+				HubClient:          r.HubClient,
+				ManagedClient:      r.ManagedClient,
+				ManagedRecorder:    recorder,
+				TargetNamespace:    "managed",
+				StatusSyncRequests: r.StatusSyncRequests,
+				DriftMode:          driftMode,
+			}
+
+			if _, err := restarted.Reconcile(context.TODO(), request); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			if count := testutil.ToFloat64(policySpecDriftCounter.WithLabelValues("managed", name)); count != 1 {
+				t.Fatalf("Expected the drift to not be counted again after a restart, got %v", count)
+			}
+
+			if len(recorder.Events) != 0 {
+				t.Fatalf("Expected no event after a restart, got %s", <-recorder.Events)
+			}
+
+			if err := r.ManagedClient.Get(context.TODO(), managedNN, managedPolicy); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			expected := policiesv1.Enforce
+			if driftMode == DriftModeReport {
+				expected = policiesv1.Inform
+			}
+
+			if managedPolicy.Spec.RemediationAction != expected {
+				t.Fatalf("Expected the remediationAction %s, got %s", expected, managedPolicy.Spec.RemediationAction)
+			}
+
+			hubPolicy := &policiesv1.Policy{}
+			if err := r.HubClient.Get(context.TODO(), request.NamespacedName, hubPolicy); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			// The status sync accepts a policy whose only difference with the hub is the reported drift.
+			reported := driftMode == DriftModeReport
+			if recorded := utils.OnlyRecordedDrift(managedPolicy, hubPolicy); recorded != reported {
+				t.Fatalf("Expected the drift to be recorded: %t, got %t", reported, recorded)
+			}
+
+			// A change on the hub is synced even after a reported drift.
+			hubPolicy.Spec.Disabled = true
+			if err := r.HubClient.Update(context.TODO(), hubPolicy); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			if utils.OnlyRecordedDrift(managedPolicy, hubPolicy) {
+				t.Fatal("Expected the changed hub policy to not only differ by the drift")
+			}
+
+			if _, err := restarted.Reconcile(context.TODO(), request); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			if err := r.ManagedClient.Get(context.TODO(), managedNN, managedPolicy); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			if !managedPolicy.Spec.Disabled || managedPolicy.Spec.RemediationAction != policiesv1.Enforce {
+				t.Fatalf("Expected the hub policy to be synced, got %v", managedPolicy.Spec)
+			}
+
+			if _, drifted := managedPolicy.Annotations[utils.DriftedHashAnnotation]; drifted {
+				t.Fatal("Expected the recorded drift to be removed after the sync")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const OverridesControllerName string = "policy-local-overrides"
+
+// invalidOverridesError is the error of a replicated policy whose local overrides in the local overrides ConfigMap are
+// invalid. The policy is still synced with its previously applied local overrides.
+type invalidOverridesError struct {
+	reason string
+}
+
+func (e *invalidOverridesError) Error() string {
+	return e.reason
+}
+
+// asInvalidOverridesError returns the invalidOverridesError in the error chain of err, if any.
+func asInvalidOverridesError(err error) (*invalidOverridesError, bool) {
+	var overridesErr *invalidOverridesError
+
+	ok := errors.As(err, &overridesErr)
+
+	return overridesErr, ok
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LocalOverridesReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controllerName := utils.HubSourceControllerName(OverridesControllerName, r.HubSourceName)
+
+	isOverrides := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == r.TargetNamespace && obj.GetName() == utils.LocalOverridesConfigMapName
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.ConfigMap{}, builder.WithPredicates(isOverrides)).
+		Named(controllerName).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		WithLogConstructor(func(req *reconcile.Request) logr.Logger {
+			return utils.LogConstructor(controllerName, "ConfigMap", req)
+		}).
+		Complete(r)
+}
+
+// blank assignment to verify that LocalOverridesReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &LocalOverridesReconciler{}
+
+// LocalOverridesReconciler triggers the spec-sync of the replicated policies when the local overrides ConfigMap in the
+// target namespace on the managed cluster changes, so that the overrides are applied or reverted.
+type LocalOverridesReconciler struct {
+	ManagedClient client.Client
+	// The namespace of the replicated policies and the local overrides ConfigMap on the managed cluster.
+	TargetNamespace string
+	// The namespace of the replicated policies on the hub.
+	ClusterNamespaceOnHub string
+	// SpecSyncRequests triggers spec-sync controller reconciles of the hub policies
+	SpecSyncRequests chan<- event.GenericEvent
+	// The name of the additional hub source that the policies are synced from. It's empty for the primary hub.
+	HubSourceName string
+}
+
+// Reconcile triggers the spec-sync of the replicated policies on the managed cluster. Policies that aren't replicated
+// yet get their local overrides applied when the spec-sync creates them.
+func (r *LocalOverridesReconciler) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	policies := &policiesv1.PolicyList{}
+
+	if err := r.ManagedClient.List(ctx, policies, client.InNamespace(r.TargetNamespace)); err != nil {
+		log.Error(err, "Failed to list the replicated policies")
+
+		return reconcile.Result{}, err
+	}
+
+	log.Info("The local overrides changed. Triggering the spec-sync of the replicated policies.")
+
+	for i := range policies.Items {
+		hubReplicatedPolicy := &unstructured.Unstructured{}
+		hubReplicatedPolicy.SetAPIVersion(policiesv1.GroupVersion.String())
+		hubReplicatedPolicy.SetKind(policiesv1.Kind)
+		hubReplicatedPolicy.SetName(policies.Items[i].Name)
+		hubReplicatedPolicy.SetNamespace(r.ClusterNamespaceOnHub)
+
+		r.SpecSyncRequests <- event.GenericEvent{Object: hubReplicatedPolicy}
+	}
+
+	return reconcile.Result{}, nil
+}
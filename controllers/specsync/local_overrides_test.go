@@ -0,0 +1,215 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func overridesTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	return scheme
+}
+
+func TestLocalOverridesReconcile(t *testing.T) {
+	t.Parallel()
+
+	managedObjs := []client.Object{
+		&policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policies.a", Namespace: "managed"}},
+		&policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policies.b", Namespace: "managed"}},
+		&policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policies.other", Namespace: "other"}},
+	}
+
+	specSyncRequests := make(chan event.GenericEvent, 10)
+
+	managedClient := fake.NewClientBuilder().WithScheme(overridesTestScheme(t)).WithObjects(managedObjs...).Build()
+
+	r := &LocalOverridesReconciler{
+		ManagedClient:         managedClient,
+		TargetNamespace:       "managed",
+		ClusterNamespaceOnHub: "managed-hub",
+		SpecSyncRequests:      specSyncRequests,
+	}
+
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "managed", Name: utils.LocalOverridesConfigMapName},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	close(specSyncRequests)
+
+	requested := []string{}
+
+	for request := range specSyncRequests {
+		if request.Object.GetNamespace() != "managed-hub" {
+			t.Fatalf("Expected the spec sync request in the hub namespace but got %s", request.Object.GetNamespace())
+		}
+
+		requested = append(requested, request.Object.GetName())
+	}
+
+	if strings.Join(requested, ",") != "policies.a,policies.b" {
+		t.Fatalf("Expected the spec sync of the replicated policies but got: %v", requested)
+	}
+}
+
+func TestReconcileLocalOverrides(t *testing.T) {
+	t.Parallel()
+
+	scheme := overridesTestScheme(t)
+
+	hubPolicies := []client.Object{
+		&policiesv1.Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policies.overridden", Namespace: "managed-hub"},
+			Spec:       policiesv1.PolicySpec{RemediationAction: policiesv1.Enforce},
+		},
+		&policiesv1.Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policies.invalid", Namespace: "managed-hub"},
+			Spec:       policiesv1.PolicySpec{RemediationAction: policiesv1.Enforce},
+		},
+	}
+
+	overrides := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.LocalOverridesConfigMapName, Namespace: "managed"},
+		Data: map[string]string{utils.LocalOverridesKey: `
+policies:
+  policies.overridden:
+    remediationAction: inform
+  policies.invalid:
+    remediationAction: inform
+`},
+	}
+
+	managedClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(overrides).Build()
+
+	recorder := events.NewFakeRecorder(20)
+
+	r := &PolicyReconciler{
+		HubClient:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(hubPolicies...).Build(),
+		ManagedClient:      managedClient,
+		ManagedRecorder:    recorder,
+		TargetNamespace:    "managed",
+		StatusSyncRequests: make(chan event.GenericEvent, 10),
+	}
+
+	reconcilePolicy := func(name string) *policiesv1.Policy {
+		t.Helper()
+
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "managed-hub", Name: name},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		managedPlc := &policiesv1.Policy{}
+
+		err = managedClient.Get(context.TODO(), types.NamespacedName{Namespace: "managed", Name: name}, managedPlc)
+		if err != nil {
+			t.Fatalf("Expected the replicated policy but got: %v", err)
+		}
+
+		return managedPlc
+	}
+
+	// invalidOverridesEvent returns the warning event about invalid local overrides emitted since the last call.
+	invalidOverridesEvent := func() string {
+		t.Helper()
+
+		found := ""
+
+		for len(recorder.Events) != 0 {
+			if evt := <-recorder.Events; strings.HasPrefix(evt, "Warning PolicyLocalOverridesInvalid") {
+				found = evt
+			}
+		}
+
+		return found
+	}
+
+	for _, name := range []string{"policies.overridden", "policies.invalid"} {
+		managedPlc := reconcilePolicy(name)
+
+		if managedPlc.Spec.RemediationAction != "inform" {
+			t.Fatalf("Expected the override of %s to be applied but got %s", name, managedPlc.Spec.RemediationAction)
+		}
+
+		if managedPlc.Annotations[utils.LocalOverridesAnnotation] != `{"remediationAction":"inform"}` {
+			t.Fatalf("Expected the override of %s to be recorded but got: %v", name, managedPlc.Annotations)
+		}
+
+		if evt := invalidOverridesEvent(); evt != "" {
+			t.Fatalf("Expected no invalid local overrides event for %s but got: %s", name, evt)
+		}
+	}
+
+	// An invalid entry only affects its policy, which keeps its previously applied override.
+	overrides.Data[utils.LocalOverridesKey] = `
+policies:
+  policies.overridden:
+    remediationAction: inform
+    disabled: true
+  policies.invalid:
+    remediationAction: delete
+`
+	if err := managedClient.Update(context.TODO(), overrides); err != nil {
+		t.Fatalf("Failed to update the local overrides: %v", err)
+	}
+
+	if managedPlc := reconcilePolicy("policies.overridden"); !managedPlc.Spec.Disabled {
+		t.Fatal("Expected the valid override to be applied")
+	}
+
+	managedPlc := reconcilePolicy("policies.invalid")
+
+	if managedPlc.Spec.RemediationAction != "inform" {
+		t.Fatalf("Expected the previous override to be kept but got %s", managedPlc.Spec.RemediationAction)
+	}
+
+	if evt := invalidOverridesEvent(); !strings.Contains(evt, "keeps the previously applied local overrides") {
+		t.Fatalf("Expected the invalid override to be reported but got: %q", evt)
+	}
+
+	// An unparsable ConfigMap keeps the previously applied overrides of every policy.
+	overrides.Data[utils.LocalOverridesKey] = "policies: ["
+	if err := managedClient.Update(context.TODO(), overrides); err != nil {
+		t.Fatalf("Failed to update the local overrides: %v", err)
+	}
+
+	managedPlc = reconcilePolicy("policies.overridden")
+
+	if !managedPlc.Spec.Disabled || managedPlc.Spec.RemediationAction != "inform" {
+		t.Fatalf("Expected the previous override to be kept but got: %v", managedPlc.Spec)
+	}
+
+	if evt := invalidOverridesEvent(); evt == "" {
+		t.Fatal("Expected the invalid overrides to be reported")
+	}
+}
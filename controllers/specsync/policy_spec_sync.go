@@ -6,6 +6,7 @@ package specsync
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -13,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
@@ -33,12 +35,14 @@ const ControllerName string = "policy-spec-sync"
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager, additionalSource source.Source) error {
+	controllerName := utils.HubSourceControllerName(ControllerName, r.HubSourceName)
+
 	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&policiesv1.Policy{}).
-		Named(ControllerName).
+		Named(controllerName).
 		WithOptions(controller.Options{MaxConcurrentReconciles: r.ConcurrentReconciles}).
 		WithLogConstructor(func(req *reconcile.Request) logr.Logger {
-			return utils.LogConstructor(ControllerName, "Policy", req)
+			return utils.LogConstructor(controllerName, "Policy", req)
 		})
 
 	if additionalSource != nil {
@@ -58,18 +62,33 @@ type PolicyReconciler struct {
 	HubClient       client.Client
 	ManagedClient   client.Client
 	ManagedRecorder events.EventRecorder
+	HubRecorder     events.EventRecorder
 	Scheme          *runtime.Scheme
 	// The namespace that the replicated policies should be synced to.
 	TargetNamespace      string
 	ConcurrentReconciles int
 	// StatusSyncRequests triggers status-sync controller reconciles based on what is observed on the hub
 	StatusSyncRequests chan<- event.GenericEvent
+	// The name of the additional hub source that the policies are synced from. It's empty for the primary hub.
+	HubSourceName string
+	// PolicySelector selects the hub policies that are synced. All policies are synced when it's nil.
+	PolicySelector labels.Selector
+	// DriftMode is either DriftModeStrict or DriftModeReport, and determines whether local changes to the replicated
+	// policies are reverted. An empty value is the same as DriftModeStrict.
+	DriftMode string
+	// DeletionGuard holds the deletions of replicated policies when too many would be deleted at once. It's optional.
+	DeletionGuard *DeletionGuard
+	// SignatureVerifier rejects the hub policies whose signature can't be verified. It's optional.
+	SignatureVerifier *SignatureVerifier
+	// The hub policy version and reason of the last reported signature rejection, by policy name.
+	rejectedSignatures sync.Map
 }
 
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies,verbs=create;delete;get;list;patch;update;watch
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/finalizers,verbs=update
 //+kubebuilder:rbac:groups=core;events.k8s.io,resources=events,verbs=create;delete;get;list;patch;update;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
 // This is required for the status lease for the addon framework
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
 
@@ -95,9 +114,48 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 	instance := &policiesv1.Policy{}
 
 	err := r.HubClient.Get(ctx, request.NamespacedName, instance)
+	if err == nil && !utils.MatchesPolicySelector(r.PolicySelector, instance) {
+		// The policy is synced by another addon instance, so it's handled like a deleted policy.
+		reqLogger.V(1).Info("Policy doesn't match the policy label selector")
+
+		err = errors.NewNotFound(policiesv1.GroupVersion.WithResource("policies").GroupResource(), request.Name)
+	}
+
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// replicated policy on hub was deleted, remove policy on managed cluster
+			managedPlc := &policiesv1.Policy{}
+
+			err = r.ManagedClient.Get(
+				ctx, types.NamespacedName{Namespace: r.TargetNamespace, Name: request.Name}, managedPlc,
+			)
+			if err == nil {
+				if !utils.MatchesPolicySelector(r.PolicySelector, managedPlc) {
+					reqLogger.V(1).Info("Leaving the policy on the managed cluster to the addon instance that syncs it")
+
+					return reconcile.Result{}, nil
+				}
+
+				if paused, result := r.syncPaused(ctx, managedPlc); paused {
+					return result, nil
+				}
+
+				held, err := r.holdDeletion(ctx, managedPlc)
+				if err != nil {
+					reqLogger.Error(err, "Failed to check the deletion against the mass-deletion guard")
+
+					return reconcile.Result{}, err
+				}
+
+				if held {
+					return reconcile.Result{RequeueAfter: heldDeletionRequeue}, nil
+				}
+			} else if !errors.IsNotFound(err) {
+				reqLogger.Error(err, "Failed to get policy from managed...")
+
+				return reconcile.Result{}, err
+			}
+
 			reqLogger.Info("Policy was deleted, removing on managed cluster...")
 
 			err = r.ManagedClient.Delete(ctx, &policiesv1.Policy{
@@ -117,6 +175,14 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 				return reconcile.Result{}, err
 			}
 
+			r.forgetSync(request.Name)
+
+			if err := r.releaseDeletion(ctx, request.Name); err != nil {
+				reqLogger.Error(err, "Failed to release the held deletion of the policy")
+
+				return reconcile.Result{}, err
+			}
+
 			reqLogger.Info("Policy has been removed from managed cluster...Reconciliation complete.")
 
 			return reconcile.Result{}, nil
@@ -127,6 +193,38 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, err
 	}
 
+	// The hub policy is back, so its deletion is no longer held.
+	if err := r.releaseDeletion(ctx, request.Name); err != nil {
+		reqLogger.Error(err, "Failed to release the held deletion of the policy")
+
+		return reconcile.Result{}, err
+	}
+
+	rejected, err := r.rejectSignature(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to verify the policy signature")
+
+		return reconcile.Result{}, err
+	}
+
+	if rejected {
+		return reconcile.Result{RequeueAfter: rejectedSignatureRequeue}, nil
+	}
+
+	override, overridesErr := r.localOverride(ctx, request.Name)
+	if overridesErr != nil {
+		if _, ok := asInvalidOverridesError(overridesErr); !ok {
+			reqLogger.Error(overridesErr, "Failed to get the local overrides of the policy")
+
+			return reconcile.Result{}, overridesErr
+		}
+
+		reqLogger.Error(overridesErr, "Keeping the previously applied local overrides of the policy")
+	}
+
+	// The desired replicated policy is the hub policy with the local overrides applied
+	desired := utils.ApplyPolicyOverride(instance, override)
+
 	managedPlc := &policiesv1.Policy{}
 
 	err = r.ManagedClient.Get(ctx, types.NamespacedName{Namespace: r.TargetNamespace, Name: request.Name}, managedPlc)
@@ -135,8 +233,9 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			// not found on managed cluster, create it
 			reqLogger.Info("Policy not found on managed cluster, creating it...")
 
-			managedPlc = instance.DeepCopy()
+			managedPlc = desired.DeepCopy()
 			managedPlc.Namespace = r.TargetNamespace
+			setSyncedHash(managedPlc, utils.SyncedHash(desired))
 
 			if managedPlc.Labels[common.ClusterNamespaceLabel] != "" {
 				managedPlc.Labels[common.ClusterNamespaceLabel] = r.TargetNamespace
@@ -145,7 +244,7 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			managedPlc.SetOwnerReferences(nil)
 			managedPlc.SetResourceVersion("")
 
-			err = r.ManagedClient.Create(ctx, managedPlc)
+			err = r.ManagedClient.Create(ctx, managedPlc, client.FieldOwner(ControllerName))
 			if err != nil {
 				reqLogger.Error(err, "Failed to create policy on managed...")
 
@@ -161,14 +260,39 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			return reconcile.Result{}, err
 		}
 	}
-	// found, then compare and update
-	if !utils.EquivalentReplicatedPolicies(instance, managedPlc) {
+	if paused, result := r.syncPaused(ctx, managedPlc); paused {
+		return result, nil
+	}
+
+	if overridesErr != nil {
+		r.reportInvalidOverrides(managedPlc, override, overridesErr)
+	}
+
+	// found, then compare and update. The overrides annotation is compared first since
+	// EquivalentReplicatedPolicies would otherwise apply the previously recorded overrides to the desired policy.
+	if desired.GetAnnotations()[utils.LocalOverridesAnnotation] !=
+		managedPlc.GetAnnotations()[utils.LocalOverridesAnnotation] ||
+		!utils.EquivalentReplicatedPolicies(desired, managedPlc) {
+		drifted, manager, err := r.detectDrift(ctx, instance, desired, managedPlc)
+		if err != nil {
+			reqLogger.Error(err, "Failed to record the drift of the policy")
+
+			return reconcile.Result{}, err
+		}
+
+		if drifted && r.DriftMode == DriftModeReport {
+			reqLogger.V(1).Info("Keeping the local change of the policy", "manager", manager)
+
+			return reconcile.Result{}, nil
+		}
+
 		// update needed
 		reqLogger.Info("Policy mismatch between hub and managed, updating it...")
-		managedPlc.SetAnnotations(instance.GetAnnotations())
-		managedPlc.Spec = instance.Spec
-		err = r.ManagedClient.Update(ctx, managedPlc)
-
+		desiredHash := utils.SyncedHash(desired)
+		managedPlc.SetAnnotations(utils.WithManagedOnlyAnnotations(desired.GetAnnotations(), managedPlc))
+		setSyncedHash(managedPlc, desiredHash)
+		managedPlc.Spec = desired.Spec
+		err = r.ManagedClient.Update(ctx, managedPlc, client.FieldOwner(ControllerName))
 		if err != nil && errors.IsNotFound(err) {
 			reqLogger.Error(err, "Failed to update policy on managed...")
 
@@ -184,7 +308,113 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		r.StatusSyncRequests <- event.GenericEvent{Object: managedPlc}
 	}
 
+	if err := r.recordSync(ctx, managedPlc, utils.SyncedHash(desired)); err != nil {
+		reqLogger.Error(err, "Failed to record the sync of the policy")
+
+		return reconcile.Result{}, err
+	}
+
 	reqLogger.V(2).Info("Reconciliation complete.")
 
 	return reconcile.Result{}, nil
 }
+
+// syncPaused returns whether the sync of the replicated policy is paused by the pause-sync annotation, and the result
+// to requeue the request when the pause expires. An invalid annotation value is logged and doesn't pause the sync.
+func (r *PolicyReconciler) syncPaused(ctx context.Context, managedPlc *policiesv1.Policy) (bool, reconcile.Result) {
+	reqLogger := ctrl.LoggerFrom(ctx)
+
+	paused, until, err := utils.SyncPausedUntil(managedPlc, time.Now())
+	if err != nil {
+		reqLogger.Error(err, "Ignoring the invalid pause-sync annotation on the policy")
+
+		return false, reconcile.Result{}
+	}
+
+	if !paused {
+		return false, reconcile.Result{}
+	}
+
+	if until.IsZero() {
+		reqLogger.Info("Skipping the spec sync because the policy is paused")
+
+		return true, reconcile.Result{}
+	}
+
+	reqLogger.Info("Skipping the spec sync because the policy is paused", "until", until.Format(time.RFC3339))
+
+	return true, reconcile.Result{RequeueAfter: time.Until(until)}
+}
+
+// localOverride returns the local override of the replicated policy from the local overrides ConfigMap in the target
+// namespace, or nil if the policy has none. When the local overrides of the policy are invalid, the override recorded
+// on the existing replicated policy is kept and returned with an invalidOverridesError, so that fixing the ConfigMap
+// doesn't revert the policy in the meantime.
+func (r *PolicyReconciler) localOverride(ctx context.Context, policyName string) (*utils.PolicyOverride, error) {
+	configMap := &corev1.ConfigMap{}
+
+	err := r.ManagedClient.Get(
+		ctx, types.NamespacedName{Namespace: r.TargetNamespace, Name: utils.LocalOverridesConfigMapName}, configMap,
+	)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	overrides, err := utils.ParseLocalOverrides(configMap.Data[utils.LocalOverridesKey])
+	if err != nil {
+		return r.recordedOverride(ctx, policyName, &invalidOverridesError{reason: err.Error()})
+	}
+
+	if reason, invalid := overrides.Invalid[policyName]; invalid {
+		return r.recordedOverride(
+			ctx, policyName, &invalidOverridesError{reason: "the local overrides of the policy are invalid: " + reason},
+		)
+	}
+
+	return overrides.Policies[policyName], nil
+}
+
+// recordedOverride returns the override recorded on the replicated policy on the managed cluster with the
+// invalidOverridesError, or nil if the policy isn't replicated yet or has no recorded override.
+func (r *PolicyReconciler) recordedOverride(
+	ctx context.Context, policyName string, overridesErr *invalidOverridesError,
+) (*utils.PolicyOverride, error) {
+	managedPlc := &policiesv1.Policy{}
+
+	err := r.ManagedClient.Get(ctx, types.NamespacedName{Namespace: r.TargetNamespace, Name: policyName}, managedPlc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, overridesErr
+		}
+
+		return nil, err
+	}
+
+	override, err := utils.RecordedOverride(managedPlc)
+	if err != nil {
+		return nil, fmt.Errorf("the recorded local overrides of the policy are invalid: %w", err)
+	}
+
+	return override, overridesErr
+}
+
+// reportInvalidOverrides emits a warning event on the replicated policy when its local overrides are invalid, which
+// says whether the previously applied local overrides are kept.
+func (r *PolicyReconciler) reportInvalidOverrides(
+	managedPlc *policiesv1.Policy, override *utils.PolicyOverride, overridesErr error,
+) {
+	msg := fmt.Sprintf("Policy %s has no local overrides applied because %s", managedPlc.Name, overridesErr)
+
+	if override != nil {
+		msg = fmt.Sprintf("Policy %s keeps the previously applied local overrides because %s: %s",
+			managedPlc.Name, overridesErr, override.String())
+	}
+
+	r.ManagedRecorder.Eventf(
+		managedPlc, nil, corev1.EventTypeWarning, "PolicyLocalOverridesInvalid", "PolicySpecSync", msg,
+	)
+}
@@ -0,0 +1,84 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcilePolicySelector(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	policy := func(name, namespace, team string) *policiesv1.Policy {
+		return &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: namespace, Labels: map[string]string{"team": team},
+		}}
+	}
+
+	selector, err := labels.Parse("team=apps")
+	if err != nil {
+		t.Fatalf("Failed to parse the selector: %v", err)
+	}
+
+	r := &PolicyReconciler{
+		HubClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			policy("policies.apps", "managed-hub", "apps"), policy("policies.platform", "managed-hub", "platform"),
+		).Build(),
+		// The policy synced by another addon instance was deleted on the hub.
+		ManagedClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			policy("policies.other", "managed", "platform"),
+		).Build(),
+		ManagedRecorder:    events.NewFakeRecorder(10),
+		TargetNamespace:    "managed",
+		StatusSyncRequests: make(chan event.GenericEvent, 10),
+		PolicySelector:     selector,
+	}
+
+	for _, name := range []string{"policies.apps", "policies.platform", "policies.other"} {
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "managed-hub", Name: name},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+	}
+
+	for name, expected := range map[string]bool{
+		"policies.apps":     true,
+		"policies.platform": false,
+		"policies.other":    true,
+	} {
+		err := r.ManagedClient.Get(
+			context.TODO(), types.NamespacedName{Namespace: "managed", Name: name}, &policiesv1.Policy{},
+		)
+		if expected && err != nil {
+			t.Fatalf("Expected the %s policy on the managed cluster but got: %v", name, err)
+		}
+
+		if !expected && !errors.IsNotFound(err) {
+			t.Fatalf("Expected the %s policy to not be synced but got: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const (
+	// SignatureAnnotation is set on a hub policy to the base64 encoded detached signature of its SignedContent.
+	SignatureAnnotation = common.APIGroup + "/signature"
+	// SignatureKeysSecretName is the name of the Secret that holds the PEM encoded public keys that hub policy
+	// signatures are verified against. Every key in its data is a public key, and a signature by any of them is valid.
+	SignatureKeysSecretName = "governance-policy-signature-keys"
+	// triggerUpdateAnnotation is changed by the propagator on the hub to force a resync, so it isn't signed.
+	triggerUpdateAnnotation = common.APIGroup + "/trigger-update"
+	// signatureKeysTTL is how long the public keys are cached before the Secret is read again.
+	signatureKeysTTL = time.Minute
+	// rejectedSignatureRequeue is how often a rejected policy is verified again, such as after the keys change.
+	rejectedSignatureRequeue = 5 * time.Minute
+)
+
+// signatureError is the sync error of a hub policy that was rejected because its signature couldn't be verified.
+type signatureError struct {
+	reason string
+}
+
+func (e *signatureError) Error() string {
+	return "the policy signature is not valid: " + e.reason
+}
+
+// asSignatureError returns the signatureError in the error chain of err, if any.
+func asSignatureError(err error) (*signatureError, bool) {
+	var sigErr *signatureError
+
+	ok := errors.As(err, &sigErr)
+
+	return sigErr, ok
+}
+
+// SignedContent returns the content that the signature of a policy covers, given the JSON of the policy as the API
+// server returns it, such as with kubectl get -o json. It's the canonical JSON of the name and namespace of the root
+// policy, the spec as it is in the policy JSON, and the annotations in the policy.open-cluster-management.io domain,
+// excluding the SignatureAnnotation and the trigger-update annotation. The root policy is the one of the
+// common.RootPolicyLabel of a replicated policy, or the policy itself when it has no such label, so that the root
+// policy is signed once on the hub, and the signature that the propagator copies to its replicated policies verifies
+// in every cluster namespace while it can't be replayed on another policy. The canonical JSON has sorted object keys,
+// no insignificant whitespace, numbers as they are in the policy JSON, and no HTML escaping of <, > and & in strings.
+func SignedContent(plcJSON []byte) ([]byte, error) {
+	var plc struct {
+		Metadata struct {
+			Name        string            `json:"name"`
+			Namespace   string            `json:"namespace"`
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Spec interface{} `json:"spec"`
+	}
+
+	// The spec is decoded generically so that only the fields that are set in the policy are signed. Numbers are kept
+	// as is to not lose precision.
+	decoder := json.NewDecoder(bytes.NewReader(plcJSON))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&plc); err != nil {
+		return nil, err
+	}
+
+	name, namespace := plc.Metadata.Name, plc.Metadata.Namespace
+
+	if rootPlc, ok := plc.Metadata.Labels[common.RootPolicyLabel]; ok {
+		var err error
+
+		name, namespace, err = common.ParseRootPolicyLabel(rootPlc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	annotations := map[string]string{}
+
+	for key, value := range plc.Metadata.Annotations {
+		if !strings.HasPrefix(key, common.APIGroup+"/") {
+			continue
+		}
+
+		if key == SignatureAnnotation || key == triggerUpdateAnnotation {
+			continue
+		}
+
+		annotations[key] = value
+	}
+
+	// The maps are encoded with sorted keys, including the ones of the raw policy templates.
+	content := &bytes.Buffer{}
+
+	encoder := json.NewEncoder(content)
+	encoder.SetEscapeHTML(false)
+
+	err := encoder.Encode(map[string]interface{}{
+		"annotations": annotations,
+		"name":        name,
+		"namespace":   namespace,
+		"spec":        plc.Spec,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(content.Bytes(), []byte("\n")), nil
+}
+
+// SignatureVerifier verifies the signatures of hub policies against the public keys in the SignatureKeysSecretName
+// Secret in Namespace on the managed cluster.
+type SignatureVerifier struct {
+	// Reader is an uncached reader since the Secret isn't in the cache of the managed manager.
+	Reader    client.Reader
+	Namespace string
+
+	lock     sync.Mutex
+	keys     []crypto.PublicKey
+	loadedAt time.Time
+}
+
+//+kubebuilder:rbac:groups=core,resources=secrets,resourceNames=governance-policy-signature-keys,verbs=get
+
+// Verify returns a signatureError if the replicated policy, as the API server returns it, isn't signed by one of the
+// public keys, or if it isn't named after its root policy. Other errors are returned when the public keys can't be
+// read.
+func (v *SignatureVerifier) Verify(ctx context.Context, plc *unstructured.Unstructured) error {
+	encoded, ok := plc.GetAnnotations()[SignatureAnnotation]
+	if !ok {
+		return &signatureError{reason: "the policy is not signed"}
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return &signatureError{reason: "the signature is not valid base64"}
+	}
+
+	// The replicated policy is named after its root policy, so the signature of a root policy only verifies on its
+	// replicated policies.
+	rootPlc := plc.GetLabels()[common.RootPolicyLabel]
+	if rootPlc == "" {
+		return &signatureError{reason: "the policy has no root policy label"}
+	}
+
+	if _, _, err := common.ParseRootPolicyLabel(rootPlc); err != nil || rootPlc != plc.GetName() {
+		return &signatureError{reason: "the policy name doesn't match its root policy label"}
+	}
+
+	keys, err := v.publicKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return &signatureError{
+			reason: fmt.Sprintf("there are no public keys in the %s/%s Secret", v.Namespace, SignatureKeysSecretName),
+		}
+	}
+
+	plcJSON, err := plc.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	content, err := SignedContent(plcJSON)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if verifySignature(key, content, signature) {
+			return nil
+		}
+	}
+
+	return &signatureError{reason: "the signature doesn't match any of the public keys"}
+}
+
+// publicKeys returns the public keys in the Secret, which are cached for the signatureKeysTTL. A missing Secret has no
+// public keys.
+func (v *SignatureVerifier) publicKeys(ctx context.Context) ([]crypto.PublicKey, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if !v.loadedAt.IsZero() && time.Since(v.loadedAt) < signatureKeysTTL {
+		return v.keys, nil
+	}
+
+	secret := &corev1.Secret{}
+
+	err := v.Reader.Get(ctx, types.NamespacedName{Namespace: v.Namespace, Name: SignatureKeysSecretName}, secret)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get the policy signature keys: %w", err)
+	}
+
+	keys, err := parsePublicKeys(secret.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	v.keys = keys
+	v.loadedAt = time.Now()
+
+	return keys, nil
+}
+
+// parsePublicKeys parses the PEM encoded ECDSA, Ed25519, and RSA public keys in the Secret data, in the order of the
+// data keys.
+func parsePublicKeys(data map[string][]byte) ([]crypto.PublicKey, error) {
+	names := make([]string, 0, len(data))
+
+	for name := range data {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	keys := make([]crypto.PublicKey, 0, len(names))
+
+	for _, name := range names {
+		block, _ := pem.Decode(data[name])
+		if block == nil || block.Type != "PUBLIC KEY" {
+			return nil, fmt.Errorf("the %s policy signature key is not a PEM encoded public key", name)
+		}
+
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("the %s policy signature key is invalid: %w", name, err)
+		}
+
+		switch key.(type) {
+		case *ecdsa.PublicKey, ed25519.PublicKey, *rsa.PublicKey:
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("the %s policy signature key has an unsupported type %T", name, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// verifySignature returns whether the signature of the content is valid for the public key. ECDSA signatures are
+// ASN.1 encoded and RSA signatures are PKCS #1 v1.5, both over the SHA-256 digest of the content, and Ed25519
+// signatures are over the content itself.
+func verifySignature(key crypto.PublicKey, content []byte, signature []byte) bool {
+	digest := sha256.Sum256(content)
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, content, signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+
+	return false
+}
+
+// rejectSignature returns whether the hub policy is rejected because its signature can't be verified. A rejected
+// policy isn't replicated, and a previous version of it on the managed cluster is kept so that the last synced policy
+// is still enforced. The rejection is recorded on the replicated policy with the RejectedHashAnnotation, so that the
+// status sync accepts it, and it's reported with warning Events on the hub and replicated policies once per hub policy
+// version. The signature is verified against the hub policy read from the API server, since the signed content depends
+// on the fields that are set in the policy JSON, which the typed policy in the cache doesn't keep.
+func (r *PolicyReconciler) rejectSignature(ctx context.Context, instance *policiesv1.Policy) (bool, error) {
+	if r.SignatureVerifier == nil {
+		return false, nil
+	}
+
+	// Unstructured objects aren't cached by the hub client, so this reads the policy from the API server.
+	hubPlc := &unstructured.Unstructured{}
+	hubPlc.SetGroupVersionKind(policiesv1.GroupVersion.WithKind("Policy"))
+
+	err := r.HubClient.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, hubPlc)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the policy to verify its signature: %w", err)
+	}
+
+	if hubPlc.GetResourceVersion() != instance.ResourceVersion {
+		// The cache will get the newer version of the policy, which is verified then.
+		ctrl.LoggerFrom(ctx).V(1).Info("Waiting for the cache to get the policy version to verify")
+
+		return true, nil
+	}
+
+	err = r.SignatureVerifier.Verify(ctx, hubPlc)
+	if err == nil {
+		r.rejectedSignatures.Delete(instance.Name)
+
+		return false, nil
+	}
+
+	sigErr, ok := asSignatureError(err)
+	if !ok {
+		return false, err
+	}
+
+	managedPlc := &policiesv1.Policy{}
+
+	err = r.ManagedClient.Get(ctx, types.NamespacedName{Namespace: r.TargetNamespace, Name: instance.Name}, managedPlc)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return true, err
+		}
+
+		managedPlc = nil
+	}
+
+	if managedPlc != nil {
+		if err := r.recordRejection(ctx, instance, managedPlc); err != nil {
+			return true, err
+		}
+	}
+
+	// The rejection is only remembered once it's recorded, so that a failure to record it is retried.
+	rejection := instance.ResourceVersion + "/" + sigErr.reason
+	if reported, ok := r.rejectedSignatures.Load(instance.Name); ok && reported == rejection {
+		return true, nil
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Rejecting the policy from the hub", "reason", sigErr.reason)
+
+	msg := fmt.Sprintf(
+		"Policy %s was not synced to cluster namespace %s because %s", instance.Name, r.TargetNamespace, sigErr,
+	)
+
+	if r.HubRecorder != nil {
+		r.HubRecorder.Eventf(instance, nil, corev1.EventTypeWarning, "PolicySignatureInvalid", "PolicySpecSync", msg)
+	}
+
+	if managedPlc != nil {
+		r.ManagedRecorder.Eventf(
+			managedPlc, nil, corev1.EventTypeWarning, "PolicySignatureInvalid", "PolicySpecSync", msg,
+		)
+	}
+
+	r.rejectedSignatures.Store(instance.Name, rejection)
+
+	return true, nil
+}
+
+// recordRejection records the rejected signature of the hub policy on the replicated policy that is kept, in the
+// RejectedHashAnnotation.
+func (r *PolicyReconciler) recordRejection(ctx context.Context, instance, managedPlc *policiesv1.Policy) error {
+	hash := utils.SyncedHash(instance)
+
+	if managedPlc.GetAnnotations()[utils.RejectedHashAnnotation] != hash {
+		recorded := managedPlc.DeepCopy()
+
+		annotations := recorded.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations[utils.RejectedHashAnnotation] = hash
+		recorded.SetAnnotations(annotations)
+
+		err := r.ManagedClient.Patch(ctx, recorded, client.MergeFrom(managedPlc), client.FieldOwner(ControllerName))
+		if err != nil {
+			return fmt.Errorf("failed to record the rejected signature on the policy: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,511 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package specsync
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const reformattedTemplate = `{"kind": "ConfigurationPolicy", "apiVersion": "v1"}`
+
+// signedPolicy returns a replicated policy in the managed-hub cluster namespace, named after its root policy, that is
+// signed by the signer.
+func signedPolicy(t *testing.T, signer crypto.Signer, name string, template string) *policiesv1.Policy {
+	t.Helper()
+
+	plc := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "managed-hub",
+			Labels:    map[string]string{common.RootPolicyLabel: name},
+			Annotations: map[string]string{
+				"policy.open-cluster-management.io/standards": "NIST SP 800-53",
+				"example.com/unsigned":                        "value",
+			},
+		},
+		Spec: policiesv1.PolicySpec{
+			RemediationAction: policiesv1.Enforce,
+			PolicyTemplates: []*policiesv1.PolicyTemplate{
+				{ObjectDefinition: runtime.RawExtension{Raw: []byte(template)}},
+			},
+		},
+	}
+
+	signPolicy(t, signer, plc)
+
+	return plc
+}
+
+// signPolicy sets the signature of the policy by the signer.
+func signPolicy(t *testing.T, signer crypto.Signer, plc *policiesv1.Policy) {
+	t.Helper()
+
+	content, err := SignedContent(policyJSON(t, plc))
+	if err != nil {
+		t.Fatalf("Failed to get the signed content: %v", err)
+	}
+
+	var signature []byte
+
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		signature, err = signer.Sign(rand.Reader, content, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(content)
+		signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+
+	if err != nil {
+		t.Fatalf("Failed to sign the policy: %v", err)
+	}
+
+	plc.Annotations[SignatureAnnotation] = base64.StdEncoding.EncodeToString(signature)
+}
+
+// unstructuredPolicy returns the policy as the hub client reads it from the API server.
+func unstructuredPolicy(t *testing.T, plc *policiesv1.Policy) *unstructured.Unstructured {
+	t.Helper()
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(plc)
+	if err != nil {
+		t.Fatalf("Failed to convert the policy: %v", err)
+	}
+
+	return &unstructured.Unstructured{Object: obj}
+}
+
+// policyJSON returns the JSON of the policy as the API server returns it.
+func policyJSON(t *testing.T, plc *policiesv1.Policy) []byte {
+	t.Helper()
+
+	plcJSON, err := unstructuredPolicy(t, plc).MarshalJSON()
+	if err != nil {
+		t.Fatalf("Failed to marshal the policy: %v", err)
+	}
+
+	return plcJSON
+}
+
+func publicKeyPEM(t *testing.T, signer crypto.Signer) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		t.Fatalf("Failed to marshal the public key: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestSignedContent(t *testing.T) {
+	t.Parallel()
+
+	// The policy JSON as the API server returns it, with a template that has characters that Go escapes in HTML by
+	// default and without the disabled field.
+	plcJSON := `{
+  "apiVersion": "policy.open-cluster-management.io/v1",
+  "kind": "Policy",
+  "metadata": {
+    "name": "policies.policy",
+    "namespace": "managed-hub",
+    "labels": {"policy.open-cluster-management.io/root-policy": "policies.policy"},
+    "annotations": {
+      "policy.open-cluster-management.io/categories": "CM",
+      "policy.open-cluster-management.io/trigger-update": "1",
+      "policy.open-cluster-management.io/signature": "c2ln",
+      "example.com/unsigned": "value"
+    }
+  },
+  "spec": {
+    "remediationAction": "inform",
+    "policy-templates": [
+      {"objectDefinition": {"kind": "ConfigurationPolicy", "apiVersion": "v1", "spec": {"severity": 1.50}, ` +
+		`"metadata": {"name": "a<b&c"}}}
+    ]
+  }
+}`
+
+	content, err := SignedContent([]byte(plcJSON))
+	if err != nil {
+		t.Fatalf("Failed to get the signed content: %v", err)
+	}
+
+	// The name and namespace are the ones of the root policy, and the spec only has the fields that are set.
+	expected := `{"annotations":{"policy.open-cluster-management.io/categories":"CM"},"name":"policy",` +
+		`"namespace":"policies","spec":{"policy-templates":[{"objectDefinition":{"apiVersion":"v1",` +
+		`"kind":"ConfigurationPolicy","metadata":{"name":"a<b&c"},"spec":{"severity":1.50}}}],` +
+		`"remediationAction":"inform"}}`
+
+	if !bytes.Equal(content, []byte(expected)) {
+		t.Fatalf("Expected the signed content %s but got %s", expected, content)
+	}
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	t.Parallel()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	verifier := &SignatureVerifier{
+		Reader: fake.NewClientBuilder().WithObjects(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: SignatureKeysSecretName, Namespace: "keys"},
+			Data: map[string][]byte{
+				"ed25519.pem": publicKeyPEM(t, edKey),
+				"ecdsa.pem":   publicKeyPEM(t, ecKey),
+				"rsa.pem":     publicKeyPEM(t, rsaKey),
+			},
+		}).Build(),
+		Namespace: "keys",
+	}
+
+	template := `{"apiVersion":"v1","kind":"ConfigurationPolicy"}`
+
+	for _, signer := range []crypto.Signer{edKey, ecKey, rsaKey} {
+		plc := unstructuredPolicy(t, signedPolicy(t, signer, "policies.policy", template))
+
+		if err := verifier.Verify(context.TODO(), plc); err != nil {
+			t.Fatalf("Expected the %T signature to be valid but got: %v", signer, err)
+		}
+	}
+
+	// The formatting of the policy templates isn't signed.
+	reformatted := signedPolicy(t, edKey, "policies.policy", template)
+	reformatted.Spec.PolicyTemplates[0].ObjectDefinition.Raw = []byte(reformattedTemplate)
+
+	if err := verifier.Verify(context.TODO(), unstructuredPolicy(t, reformatted)); err != nil {
+		t.Fatalf("Expected the reformatted policy signature to be valid but got: %v", err)
+	}
+
+	tampered := signedPolicy(t, edKey, "policies.policy", template)
+	tampered.Spec.RemediationAction = policiesv1.Inform
+
+	unsigned := signedPolicy(t, edKey, "policies.policy", template)
+	delete(unsigned.Annotations, SignatureAnnotation)
+
+	// The signature of a root policy can't be replayed on another policy.
+	renamed := signedPolicy(t, edKey, "policies.policy", template)
+	renamed.Name = "policies.other-policy"
+
+	otherRoot := signedPolicy(t, edKey, "policies.policy", template)
+	otherRoot.Name = "policies.other-policy"
+	otherRoot.Labels[common.RootPolicyLabel] = "policies.other-policy"
+
+	unlabeled := signedPolicy(t, edKey, "policies.policy", template)
+	delete(unlabeled.Labels, common.RootPolicyLabel)
+
+	for name, plc := range map[string]*policiesv1.Policy{
+		"tampered":   tampered,
+		"unknown":    signedPolicy(t, otherKey, "policies.policy", template),
+		"unsigned":   unsigned,
+		"renamed":    renamed,
+		"other root": otherRoot,
+		"unlabeled":  unlabeled,
+	} {
+		if _, ok := asSignatureError(verifier.Verify(context.TODO(), unstructuredPolicy(t, plc))); !ok {
+			t.Fatalf("Expected the %s policy signature to be rejected", name)
+		}
+	}
+
+	noKeys := &SignatureVerifier{Reader: fake.NewClientBuilder().Build(), Namespace: "keys"}
+
+	plc := signedPolicy(t, edKey, "policies.policy", template)
+
+	if _, ok := asSignatureError(noKeys.Verify(context.TODO(), unstructuredPolicy(t, plc))); !ok {
+		t.Fatal("Expected the policy signature to be rejected without public keys")
+	}
+}
+
+func TestSignatureVerifierReplicatedPolicies(t *testing.T) {
+	t.Parallel()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	// The root policy is signed on the hub.
+	root := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "policy",
+			Namespace:   "policies",
+			Annotations: map[string]string{"policy.open-cluster-management.io/standards": "NIST SP 800-53"},
+		},
+		Spec: policiesv1.PolicySpec{
+			RemediationAction: policiesv1.Enforce,
+			PolicyTemplates: []*policiesv1.PolicyTemplate{
+				{ObjectDefinition: runtime.RawExtension{Raw: []byte(reformattedTemplate)}},
+			},
+		},
+	}
+
+	signPolicy(t, key, root)
+
+	verifier := &SignatureVerifier{
+		Reader: fake.NewClientBuilder().WithObjects(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: SignatureKeysSecretName, Namespace: "keys"},
+			Data:       map[string][]byte{"key.pem": publicKeyPEM(t, key)},
+		}).Build(),
+		Namespace: "keys",
+	}
+
+	// The propagator copies the annotations of the root policy, including its signature, to every replicated policy.
+	for _, clusterNamespace := range []string{"cluster1", "cluster2"} {
+		replica := &policiesv1.Policy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "policies.policy",
+				Namespace:   clusterNamespace,
+				Labels:      map[string]string{common.RootPolicyLabel: "policies.policy"},
+				Annotations: root.Annotations,
+			},
+			Spec: root.Spec,
+		}
+
+		if err := verifier.Verify(context.TODO(), unstructuredPolicy(t, replica)); err != nil {
+			t.Fatalf(
+				"Expected the signature of the replicated policy in %s to be valid but got: %v", clusterNamespace, err,
+			)
+		}
+	}
+}
+
+func TestReconcileSignature(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	template := `{"apiVersion":"v1","kind":"ConfigurationPolicy"}`
+	signed := signedPolicy(t, key, "policies.signed", template)
+	tampered := signedPolicy(t, key, "policies.tampered", template)
+	tampered.Spec.RemediationAction = policiesv1.Inform
+
+	// The signature of another policy is replayed on this policy.
+	replayed := signedPolicy(t, key, "policies.replayed", template)
+	replayed.Annotations[SignatureAnnotation] = signed.Annotations[SignatureAnnotation]
+
+	hubRecorder := events.NewFakeRecorder(10)
+
+	r := &PolicyReconciler{
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(signed, tampered, replayed).Build(),
+		ManagedClient:   fake.NewClientBuilder().WithScheme(scheme).Build(),
+		ManagedRecorder: events.NewFakeRecorder(10),
+		HubRecorder:     hubRecorder,
+		TargetNamespace: "managed",
+		SignatureVerifier: &SignatureVerifier{
+			Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: SignatureKeysSecretName, Namespace: "keys"},
+				Data:       map[string][]byte{"key.pem": publicKeyPEM(t, key)},
+			}).Build(),
+			Namespace: "keys",
+		},
+		StatusSyncRequests: make(chan event.GenericEvent, 10),
+	}
+
+	for _, name := range []string{"policies.signed", "policies.tampered", "policies.tampered", "policies.replayed"} {
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "managed-hub", Name: name},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+	}
+
+	err = r.ManagedClient.Get(
+		context.TODO(), types.NamespacedName{Namespace: "managed", Name: "policies.signed"}, &policiesv1.Policy{},
+	)
+	if err != nil {
+		t.Fatalf("Expected the signed policy on the managed cluster but got: %v", err)
+	}
+
+	for _, name := range []string{"policies.tampered", "policies.replayed"} {
+		err = r.ManagedClient.Get(
+			context.TODO(), types.NamespacedName{Namespace: "managed", Name: name}, &policiesv1.Policy{},
+		)
+		if !errors.IsNotFound(err) {
+			t.Fatalf("Expected the %s policy to not be synced but got: %v", name, err)
+		}
+	}
+
+	if len(hubRecorder.Events) != 2 {
+		t.Fatalf("Expected one warning event per rejected policy but got %d", len(hubRecorder.Events))
+	}
+}
+
+func TestReconcileRejectedSignatureKeepsPolicy(t *testing.T) {
+	t.Parallel()
+
+	scheme := overridesTestScheme(t)
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: %v", err)
+	}
+
+	template := `{"apiVersion":"v1","kind":"ConfigurationPolicy"}`
+	hubClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		signedPolicy(t, key, "policies.kept", template),
+	).Build()
+	managedClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	hubRecorder := events.NewFakeRecorder(10)
+	managedRecorder := events.NewFakeRecorder(10)
+
+	r := &PolicyReconciler{
+		HubClient:       hubClient,
+		ManagedClient:   managedClient,
+		ManagedRecorder: managedRecorder,
+		HubRecorder:     hubRecorder,
+		TargetNamespace: "managed",
+		SignatureVerifier: &SignatureVerifier{
+			Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: SignatureKeysSecretName, Namespace: "keys"},
+				Data:       map[string][]byte{"key.pem": publicKeyPEM(t, key)},
+			}).Build(),
+			Namespace: "keys",
+		},
+		StatusSyncRequests: make(chan event.GenericEvent, 10),
+	}
+
+	hubKey := types.NamespacedName{Namespace: "managed-hub", Name: "policies.kept"}
+
+	// updateHubPolicy replaces the spec and signature of the hub policy with the ones of the policy.
+	updateHubPolicy := func(plc *policiesv1.Policy) *policiesv1.Policy {
+		t.Helper()
+
+		hubPlc := &policiesv1.Policy{}
+
+		if err := hubClient.Get(context.TODO(), hubKey, hubPlc); err != nil {
+			t.Fatalf("Failed to get the hub policy: %v", err)
+		}
+
+		hubPlc.Annotations = plc.Annotations
+		hubPlc.Spec = plc.Spec
+
+		if err := hubClient.Update(context.TODO(), hubPlc); err != nil {
+			t.Fatalf("Failed to update the hub policy: %v", err)
+		}
+
+		return hubPlc
+	}
+
+	reconcilePolicy := func() *policiesv1.Policy {
+		t.Helper()
+
+		if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: hubKey}); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		managedPlc := &policiesv1.Policy{}
+
+		err := managedClient.Get(
+			context.TODO(), types.NamespacedName{Namespace: "managed", Name: "policies.kept"}, managedPlc,
+		)
+		if err != nil {
+			t.Fatalf("Expected the replicated policy but got: %v", err)
+		}
+
+		return managedPlc
+	}
+
+	reconcilePolicy()
+
+	for len(managedRecorder.Events) != 0 {
+		<-managedRecorder.Events
+	}
+
+	tampered := signedPolicy(t, key, "policies.kept", template)
+	tampered.Spec.RemediationAction = policiesv1.Inform
+	hubPlc := updateHubPolicy(tampered)
+
+	// The rejected hub policy leaves the replicated policy as is, and the rejection is recorded on it.
+	for range 2 {
+		managedPlc := reconcilePolicy()
+
+		if managedPlc.Spec.RemediationAction != policiesv1.Enforce {
+			t.Fatalf("Expected the replicated policy to be kept but got %s", managedPlc.Spec.RemediationAction)
+		}
+
+		if !utils.OnlyRejectedSignature(managedPlc, hubPlc) {
+			t.Fatalf("Expected the rejection to be recorded on the policy but got: %v", managedPlc.Annotations)
+		}
+	}
+
+	if len(hubRecorder.Events) != 1 {
+		t.Fatalf("Expected one warning event for the rejected policy but got %d", len(hubRecorder.Events))
+	}
+
+	if evt := <-managedRecorder.Events; !strings.HasPrefix(evt, "Warning PolicySignatureInvalid") {
+		t.Fatalf("Expected a warning event on the kept policy but got: %s", evt)
+	}
+
+	signed := signedPolicy(t, key, "policies.kept", template)
+	signed.Spec.RemediationAction = policiesv1.Inform
+	signPolicy(t, key, signed)
+	hubPlc = updateHubPolicy(signed)
+
+	managedPlc := reconcilePolicy()
+
+	if managedPlc.Spec.RemediationAction != policiesv1.Inform {
+		t.Fatalf("Expected the signed hub policy to be synced but got %s", managedPlc.Spec.RemediationAction)
+	}
+
+	_, rejected := managedPlc.Annotations[utils.RejectedHashAnnotation]
+	if rejected || utils.OnlyRejectedSignature(managedPlc, hubPlc) {
+		t.Fatalf("Expected the rejection to be removed from the replicated policy but got: %v", managedPlc.Annotations)
+	}
+}
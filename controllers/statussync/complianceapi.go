@@ -0,0 +1,149 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"crypto/sha1" //#nosec G505 -- the hash identifies the spec and isn't used for security
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/complianceapi"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const reportedBy = "governance-policy-framework-addon"
+
+// newComplianceEvents returns the compliance events for the history entries of the policy templates that aren't in
+// the base status, from oldest to newest. Templates that can't be decoded are skipped since the compliance history
+// API requires the template kind and spec.
+func newComplianceEvents(
+	cluster, clusterID string, instance *policiesv1.Policy, base policiesv1.PolicyStatus,
+) []complianceapi.ComplianceEvent {
+	complianceEvents := []complianceapi.ComplianceEvent{}
+
+	sentEvents := map[string]bool{}
+
+	for _, dpt := range base.Details {
+		if dpt == nil {
+			continue
+		}
+
+		for _, entry := range dpt.History {
+			sentEvents[dpt.TemplateMeta.Name+"/"+entry.EventName] = true
+		}
+	}
+
+	parentPolicy := parentPolicyFor(instance)
+	metadata := map[string]interface{}{"policySpecHash": specHash(instance.Spec)}
+
+	for i, dpt := range instance.Status.Details {
+		if dpt == nil || i >= len(instance.Spec.PolicyTemplates) {
+			continue
+		}
+
+		template := utils.TemplateObject(instance.Spec.PolicyTemplates[i])
+		if template == nil || template.GetName() != dpt.TemplateMeta.Name {
+			continue
+		}
+
+		spec, _, _ := unstructured.NestedMap(template.Object, "spec")
+		severity, _, _ := unstructured.NestedString(template.Object, "spec", "severity")
+
+		// The namespace of the applied object is preferred since namespaced templates are applied in the cluster
+		// namespace.
+		namespace := dpt.TemplateMeta.Namespace
+		if namespace == "" {
+			namespace = template.GetNamespace()
+		}
+
+		policy := complianceapi.Policy{
+			APIGroup:  template.GroupVersionKind().Group,
+			Kind:      template.GetKind(),
+			Name:      template.GetName(),
+			Namespace: namespace,
+			Spec:      spec,
+			SpecHash:  specHash(spec),
+			Severity:  severity,
+		}
+
+		// The history is sorted from newest to oldest, but the events are reported in the order they occurred.
+		for _, entry := range slices.Backward(dpt.History) {
+			if sentEvents[dpt.TemplateMeta.Name+"/"+entry.EventName] {
+				continue
+			}
+
+			compliance := parseComplianceFromMessage(entry.Message)
+
+			complianceEvents = append(complianceEvents, complianceapi.ComplianceEvent{
+				Cluster:      complianceapi.Cluster{Name: cluster, ClusterID: clusterID},
+				ParentPolicy: parentPolicy,
+				Policy:       policy,
+				Event: complianceapi.Event{
+					Compliance: string(compliance),
+					Message:    trimCompliancePrefix(entry.Message),
+					Timestamp:  entry.LastTimestamp.Time,
+					Metadata:   metadata,
+					ReportedBy: reportedBy,
+				},
+			})
+		}
+	}
+
+	return complianceEvents
+}
+
+// reportComplianceEvents sends the new compliance history entries of the policy to the compliance history API.
+func (r *PolicyReconciler) reportComplianceEvents(instance *policiesv1.Policy, base policiesv1.PolicyStatus) {
+	if r.ComplianceReporter == nil {
+		return
+	}
+
+	for _, event := range newComplianceEvents(r.ClusterNamespaceOnHub, r.ClusterID, instance, base) {
+		r.ComplianceReporter.Report(event)
+	}
+}
+
+// parentPolicyFor returns the root policy of the replicated policy from its root-policy label, with the standards,
+// categories, and controls from its annotations. It returns nil if the label is missing or invalid.
+func parentPolicyFor(instance *policiesv1.Policy) *complianceapi.ParentPolicy {
+	name, namespace, err := common.ParseRootPolicyLabel(instance.Labels[common.RootPolicyLabel])
+	if err != nil {
+		return nil
+	}
+
+	annotations := instance.GetAnnotations()
+
+	return &complianceapi.ParentPolicy{
+		Name:       name,
+		Namespace:  namespace,
+		Categories: utils.SplitAnnotation(annotations[common.APIGroup+"/categories"]),
+		Controls:   utils.SplitAnnotation(annotations[common.APIGroup+"/controls"]),
+		Standards:  utils.SplitAnnotation(annotations[common.APIGroup+"/standards"]),
+	}
+}
+
+// trimCompliancePrefix removes the compliance state prefix from a compliance message, such as "NonCompliant; ".
+func trimCompliancePrefix(message string) string {
+	_, trimmed, found := strings.Cut(message, ";")
+	if !found {
+		return message
+	}
+
+	return strings.TrimSpace(trimmed)
+}
+
+// specHash returns the SHA1 hex digest of the JSON encoding of the input.
+func specHash(spec any) string {
+	// The specs always serialize, so an error isn't possible.
+	specJSON, _ := json.Marshal(spec)
+	//#nosec G401 -- the hash identifies the spec and isn't used for security
+	digest := sha1.Sum(specJSON)
+
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,91 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const (
+	// EventInvolvedUIDIndex is the name of the field index on cached Events keyed by the UID of the involved object.
+	EventInvolvedUIDIndex = "involvedObject.uid"
+	// eventTemplateAnnotation holds the policy template name parsed from the Event reason. This is only set on the
+	// cached copy of the Event by TransformEvent.
+	eventTemplateAnnotation = "policy.open-cluster-management.io/template-name"
+)
+
+// eventReasonRgx matches compliance event reasons such as 'policy: calamari/policy-grc-rbactest-example'. The
+// second submatch is the policy template name.
+var eventReasonRgx = regexp.MustCompile(`(?i)^policy:\s*(?:([a-z0-9.-]+)\s*\/)?(.+)`)
+
+// TransformEvent is a cache transform function for Events that only keeps the fields that are utilized by the
+// controllers. The policy template name is parsed from the reason once, when the Event is cached, and stored in an
+// annotation so that reconciles don't need to parse it again. When the Event has a truncated message, only that is
+// cached since the full message is never written to the policy status.
+func TransformEvent(obj interface{}) (interface{}, error) {
+	event, ok := obj.(*corev1.Event)
+	if !ok {
+		return obj, nil
+	}
+
+	transformed := &corev1.Event{
+		InvolvedObject: event.InvolvedObject,
+		TypeMeta:       event.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      event.Name,
+			Namespace: event.Namespace,
+			UID:       event.UID,
+		},
+		LastTimestamp: event.LastTimestamp,
+		Message:       event.Message,
+		Reason:        event.Reason,
+	}
+
+	if truncated := event.Annotations[utils.TruncatedMessageAnnotation]; truncated != "" {
+		transformed.Message = truncated
+	}
+
+	if templateName := templateNameFromReason(event.Reason); templateName != "" {
+		transformed.Annotations = map[string]string{eventTemplateAnnotation: templateName}
+	}
+
+	return transformed, nil
+}
+
+// indexEventByInvolvedUID is the indexer function for the EventInvolvedUIDIndex field index.
+func indexEventByInvolvedUID(obj client.Object) []string {
+	event, ok := obj.(*corev1.Event)
+	if !ok || event.InvolvedObject.UID == "" {
+		return nil
+	}
+
+	return []string{string(event.InvolvedObject.UID)}
+}
+
+// eventTemplateName returns the policy template name of a compliance Event. It uses the name stored by
+// TransformEvent when available, and otherwise parses the reason. An empty string is returned if the Event is not a
+// compliance Event.
+func eventTemplateName(event *corev1.Event) string {
+	if templateName := event.GetAnnotations()[eventTemplateAnnotation]; templateName != "" {
+		return templateName
+	}
+
+	return templateNameFromReason(event.Reason)
+}
+
+// templateNameFromReason parses the policy template name from a compliance event reason. An empty string is returned
+// if the reason is not in the expected format.
+func templateNameFromReason(reason string) string {
+	matches := eventReasonRgx.FindStringSubmatch(reason)
+	if len(matches) != 3 {
+		return ""
+	}
+
+	return matches[2]
+}
@@ -0,0 +1,143 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+)
+
+// ComplianceFlappingAnnotation is set in the templateMeta of a policy template's status details when its compliance is
+// flapping. The value summarizes the flapping threshold that was reached.
+const ComplianceFlappingAnnotation = common.APIGroup + "/compliance-flapping"
+
+// countTransitions returns the number of compliance state changes in the history entries after since, and the
+// timestamp of the oldest counted transition. The history must be sorted with the most recent entry first.
+func countTransitions(history []policiesv1.ComplianceHistory, since time.Time) (int, time.Time) {
+	count := 0
+
+	var oldest time.Time
+
+	for i := 0; i+1 < len(history); i++ {
+		if !history[i].LastTimestamp.Time.After(since) {
+			break
+		}
+
+		if parseComplianceFromMessage(history[i].Message) != parseComplianceFromMessage(history[i+1].Message) {
+			count++
+			oldest = history[i].LastTimestamp.Time
+		}
+	}
+
+	return count, oldest
+}
+
+// markFlappingTemplates sets the compliance-flapping annotation on the status details of the policy templates whose
+// compliance changed at least r.FlappingTransitions times within r.FlappingWindow, and removes it from the others.
+// It returns how long until the oldest counted transition leaves the window so that the policy is reconciled when a
+// template stabilizes.
+func (r *PolicyReconciler) markFlappingTemplates(
+	instance *policiesv1.Policy, now time.Time,
+) (requeueAfter time.Duration) {
+	if r.FlappingTransitions == 0 {
+		return 0
+	}
+
+	for _, dpt := range instance.Status.Details {
+		count, oldest := countTransitions(dpt.History, now.Add(-r.FlappingWindow))
+		if count < r.FlappingTransitions {
+			setTemplateFlapping(instance, dpt, "")
+
+			continue
+		}
+
+		// The summary uses the threshold rather than the count so that it doesn't change on every transition, which
+		// would defeat suppressing the hub updates.
+		summary := fmt.Sprintf(
+			"at least %d compliance transitions in the last %s", r.FlappingTransitions, r.FlappingWindow,
+		)
+		setTemplateFlapping(instance, dpt, summary)
+
+		if untilStable := oldest.Add(r.FlappingWindow).Sub(now); requeueAfter == 0 || untilStable < requeueAfter {
+			requeueAfter = untilStable
+		}
+	}
+
+	return requeueAfter
+}
+
+// setTemplateFlapping sets the compliance-flapping annotation on the status details to the input summary, or removes
+// it when the summary is empty, and updates the flapping gauge accordingly.
+func setTemplateFlapping(instance *policiesv1.Policy, dpt *policiesv1.DetailsPerTemplate, summary string) {
+	if summary == "" {
+		if _, ok := dpt.TemplateMeta.Annotations[ComplianceFlappingAnnotation]; ok {
+			delete(dpt.TemplateMeta.Annotations, ComplianceFlappingAnnotation)
+
+			if len(dpt.TemplateMeta.Annotations) == 0 {
+				dpt.TemplateMeta.Annotations = nil
+			}
+		}
+
+		policyTemplateFlappingGauge.WithLabelValues(instance.Namespace, instance.Name, dpt.TemplateMeta.Name).Set(0)
+
+		return
+	}
+
+	if dpt.TemplateMeta.Annotations == nil {
+		dpt.TemplateMeta.Annotations = map[string]string{}
+	}
+
+	dpt.TemplateMeta.Annotations[ComplianceFlappingAnnotation] = summary
+
+	policyTemplateFlappingGauge.WithLabelValues(instance.Namespace, instance.Name, dpt.TemplateMeta.Name).Set(1)
+}
+
+// desiredHubStatus returns the policy status to set on the hub. It's the managed policy status, except when hub
+// updates are suppressed for flapping templates. In that case, the status details of flapping templates keep the
+// compliance history last sent to the hub, with the compliance-flapping summary, until the template stabilizes. The
+// overall compliance is then calculated from those details.
+func (r *PolicyReconciler) desiredHubStatus(
+	instance, hubInstance *policiesv1.Policy, log logr.Logger,
+) policiesv1.PolicyStatus {
+	if !r.SuppressFlappingHubUpdates {
+		return instance.Status
+	}
+
+	suppressed := false
+	hubPolicy := instance.DeepCopy()
+
+	for i, dpt := range hubPolicy.Status.Details {
+		summary, flapping := dpt.TemplateMeta.Annotations[ComplianceFlappingAnnotation]
+		if !flapping {
+			continue
+		}
+
+		for _, hubDPT := range hubInstance.Status.Details {
+			if hubDPT == nil || hubDPT.TemplateMeta.Name != dpt.TemplateMeta.Name {
+				continue
+			}
+
+			frozen := hubDPT.DeepCopy()
+			setTemplateFlapping(instance, frozen, summary)
+
+			hubPolicy.Status.Details[i] = frozen
+			suppressed = true
+
+			break
+		}
+	}
+
+	if suppressed {
+		log.V(1).Info("Suppressing hub status updates for flapping policy templates")
+
+		hubPolicy.Status.ComplianceState = statusComplianceState(rollupCompliance(hubPolicy, log))
+		// A frozen first status details entry still gets the current mirrored annotations.
+		mirrorAnnotations(hubPolicy, time.Now())
+	}
+
+	return hubPolicy.Status
+}
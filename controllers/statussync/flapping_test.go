@@ -0,0 +1,98 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestMarkFlappingTemplates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	entry := func(minutesAgo int, compliance string) policiesv1.ComplianceHistory {
+		return policiesv1.ComplianceHistory{
+			LastTimestamp: metav1.NewTime(now.Add(-time.Duration(minutesAgo) * time.Minute)),
+			Message:       compliance + "; some message",
+		}
+	}
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-flapping"},
+		Status: policiesv1.PolicyStatus{
+			Details: []*policiesv1.DetailsPerTemplate{
+				{
+					TemplateMeta:    metav1.ObjectMeta{Name: "flapping"},
+					ComplianceState: policiesv1.NonCompliant,
+					History: []policiesv1.ComplianceHistory{
+						entry(1, "NonCompliant"), entry(2, "Compliant"), entry(3, "NonCompliant"),
+						entry(4, "Compliant"), entry(30, "Compliant"),
+					},
+				},
+				{
+					TemplateMeta:    metav1.ObjectMeta{Name: "stable"},
+					ComplianceState: policiesv1.Compliant,
+					History: []policiesv1.ComplianceHistory{
+						entry(1, "Compliant"), entry(20, "NonCompliant"), entry(21, "Compliant"),
+						entry(22, "NonCompliant"),
+					},
+				},
+			},
+		},
+	}
+
+	r := &PolicyReconciler{FlappingTransitions: 3, FlappingWindow: 10 * time.Minute}
+
+	requeueAfter := r.markFlappingTemplates(policy, now)
+
+	// The oldest counted transition is 3 minutes old, so it leaves the 10 minute window in 7 minutes.
+	if requeueAfter != 7*time.Minute {
+		t.Fatalf("Expected a requeue after 7 minutes but got %s", requeueAfter)
+	}
+
+	if _, ok := policy.Status.Details[0].TemplateMeta.Annotations[ComplianceFlappingAnnotation]; !ok {
+		t.Fatal("Expected the flapping template to have the compliance-flapping annotation")
+	}
+
+	if policy.Status.Details[1].TemplateMeta.Annotations != nil {
+		t.Fatal("Expected the stable template to not have the compliance-flapping annotation")
+	}
+
+	hubPolicy := &policiesv1.Policy{
+		Status: policiesv1.PolicyStatus{
+			ComplianceState: policiesv1.Compliant,
+			Details: []*policiesv1.DetailsPerTemplate{
+				{
+					TemplateMeta:    metav1.ObjectMeta{Name: "flapping"},
+					ComplianceState: policiesv1.Compliant,
+					History:         []policiesv1.ComplianceHistory{entry(30, "Compliant")},
+				},
+			},
+		},
+	}
+
+	r.SuppressFlappingHubUpdates = true
+
+	hubStatus := r.desiredHubStatus(policy, hubPolicy, logr.Discard())
+	if hubStatus.ComplianceState != policiesv1.Compliant || len(hubStatus.Details[0].History) != 1 {
+		t.Fatalf("Expected the hub status of the flapping template to be frozen but got: %v", hubStatus)
+	}
+
+	if _, ok := hubStatus.Details[0].TemplateMeta.Annotations[ComplianceFlappingAnnotation]; !ok {
+		t.Fatal("Expected the frozen hub status to have the compliance-flapping annotation")
+	}
+
+	// Once the transitions leave the window, the template is no longer flapping.
+	if requeueAfter := r.markFlappingTemplates(policy, now.Add(time.Hour)); requeueAfter != 0 {
+		t.Fatalf("Expected no requeue but got %s", requeueAfter)
+	}
+
+	if policy.Status.Details[0].TemplateMeta.Annotations != nil {
+		t.Fatal("Expected the stabilized template to no longer have the compliance-flapping annotation")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// minTruncatedMessageBytes is the smallest budget a message is truncated to when the policy status budget is too
+// small for the most recent message of every template, so that the compliance state prefix is kept.
+const minTruncatedMessageBytes = 64
+
+// limitStatusSize keeps the serialized size of the status details under maxBytes. The oldest compliance history
+// entries across all templates are removed first, but the most recent entry of each template is always kept. If that
+// isn't enough, the remaining messages are truncated to an equal share of the budget. It returns whether the details
+// were changed. A maxBytes of 0 means no limit.
+func limitStatusSize(details []*policiesv1.DetailsPerTemplate, maxBytes int) bool {
+	if maxBytes <= 0 {
+		return false
+	}
+
+	size := jsonSize(details)
+	if size <= maxBytes {
+		return false
+	}
+
+	for size > maxBytes {
+		oldestDPT := -1
+
+		for i, dpt := range details {
+			if dpt == nil || len(dpt.History) < 2 {
+				continue
+			}
+
+			// Ties are broken by removing from the last template so that the result is deterministic.
+			if oldestDPT == -1 ||
+				!details[oldestDPT].History[len(details[oldestDPT].History)-1].LastTimestamp.Before(
+					&dpt.History[len(dpt.History)-1].LastTimestamp,
+				) {
+				oldestDPT = i
+			}
+		}
+
+		if oldestDPT == -1 {
+			break
+		}
+
+		history := details[oldestDPT].History
+		// Account for the entry and its separating comma.
+		size -= jsonSize(history[len(history)-1]) + 1
+		details[oldestDPT].History = history[:len(history)-1]
+	}
+
+	if size <= maxBytes {
+		return true
+	}
+
+	messages := 0
+	messageBytes := 0
+
+	for _, dpt := range details {
+		if dpt != nil && len(dpt.History) > 0 {
+			messages++
+			messageBytes += len(dpt.History[0].Message)
+		}
+	}
+
+	if messages == 0 {
+		return true
+	}
+
+	share := (maxBytes - (size - messageBytes)) / messages
+	if share < minTruncatedMessageBytes {
+		share = minTruncatedMessageBytes
+	}
+
+	for _, dpt := range details {
+		if dpt != nil && len(dpt.History) > 0 {
+			dpt.History[0].Message = utils.TruncateMessage(dpt.History[0].Message, share)
+		}
+	}
+
+	return true
+}
+
+func jsonSize(obj any) int {
+	// The status details always serialize, so an error isn't possible.
+	serialized, _ := json.Marshal(obj)
+
+	return len(serialized)
+}
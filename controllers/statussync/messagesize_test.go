@@ -0,0 +1,94 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestMergeDetailsTruncatesMessages(t *testing.T) {
+	t.Parallel()
+
+	violations := make([]string, 0, 20)
+	for i := range 20 {
+		violations = append(violations, fmt.Sprintf("violation - pod-%d is not allowed", i))
+	}
+
+	events := []policiesv1.ComplianceHistory{{
+		LastTimestamp: metav1.NewTime(time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)),
+		Message:       "NonCompliant; " + strings.Join(violations, "; "),
+		EventName:     "policy-a.17b80d88a995e12c",
+	}}
+
+	details := mergeDetails(events, nil, "config-a", 256, logr.Discard())
+
+	msg := details.History[0].Message
+	if len(msg) > 256 {
+		t.Fatalf("Expected the message to be at most 256 bytes but got %d", len(msg))
+	}
+
+	if !strings.HasPrefix(msg, "NonCompliant; violation - pod-0 is not allowed; ") ||
+		!strings.HasSuffix(msg, "more violations truncated)") {
+		t.Fatalf("Unexpected truncated message: %s", msg)
+	}
+
+	if details.ComplianceState != policiesv1.NonCompliant {
+		t.Fatalf("Expected the truncated message to keep the compliance but got %s", details.ComplianceState)
+	}
+}
+
+func TestLimitStatusSize(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	details := []*policiesv1.DetailsPerTemplate{}
+
+	for _, tName := range []string{"config-a", "config-b"} {
+		dpt := &policiesv1.DetailsPerTemplate{TemplateMeta: metav1.ObjectMeta{Name: tName}}
+
+		for i := range 10 {
+			dpt.History = append(dpt.History, policiesv1.ComplianceHistory{
+				LastTimestamp: metav1.NewTime(start.Add(-time.Duration(i) * time.Minute)),
+				Message:       "NonCompliant; " + strings.Repeat("x", 200),
+				EventName:     fmt.Sprintf("%s.%d", tName, i),
+			})
+		}
+
+		details = append(details, dpt)
+	}
+
+	if limitStatusSize(details, 0) {
+		t.Fatal("Expected no changes without a budget")
+	}
+
+	if !limitStatusSize(details, 2048) {
+		t.Fatal("Expected the details to be limited")
+	}
+
+	if size := jsonSize(details); size > 2048 {
+		t.Fatalf("Expected the details to be at most 2048 bytes but got %d", size)
+	}
+
+	// The oldest entries are removed evenly across the templates.
+	if len(details[0].History) != len(details[1].History) {
+		t.Fatalf("Expected an equal history length but got %d and %d",
+			len(details[0].History), len(details[1].History))
+	}
+
+	// A budget too small for the most recent messages truncates them.
+	limitStatusSize(details, 300)
+
+	for _, dpt := range details {
+		if len(dpt.History) != 1 || !strings.HasPrefix(dpt.History[0].Message, "NonCompliant; x") ||
+			!strings.HasSuffix(dpt.History[0].Message, "x...") {
+			t.Fatalf("Expected only the truncated most recent entry but got: %v", dpt.History)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var policyTemplateStaleGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "policy_template_compliance_stale",
+		Help: "Whether the most recent compliance history entry of a policy template is older than the configured " +
+			"staleness threshold for its kind (1) or not (0)",
+	},
+	[]string{
+		"namespace",
+		"policy",
+		"template",
+	},
+)
+
+var policyTemplateFlappingGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "policy_template_compliance_flapping",
+		Help: "Whether the compliance of a policy template changed more than the configured number of times within " +
+			"the configured window (1) or not (0)",
+	},
+	[]string{
+		"namespace",
+		"policy",
+		"template",
+	},
+)
+
+func init() {
+	// Register custom metrics with the global Prometheus registry
+	alreadyReg := &prometheus.AlreadyRegisteredError{}
+
+	for _, gauge := range []*prometheus.GaugeVec{policyTemplateStaleGauge, policyTemplateFlappingGauge} {
+		regErr := metrics.Registry.Register(gauge)
+		if regErr != nil && !errors.As(regErr, alreadyReg) {
+			panic(regErr)
+		}
+	}
+}
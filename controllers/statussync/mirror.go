@@ -0,0 +1,50 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"time"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// mirroredAnnotations are the annotations of the managed policy that are mirrored to the hub with mirrorAnnotations.
+var mirroredAnnotations = []string{utils.LocalOverridesAnnotation, utils.PauseSyncAnnotation}
+
+// mirrorAnnotations copies the LocalOverridesAnnotation of the managed policy, and its PauseSyncAnnotation while the
+// sync of the policy is paused, to the templateMeta annotations of its first status details entry, so that the applied
+// local overrides and the pause are synced to the hub with the rest of the status. The annotations of the replicated
+// policy on the hub are owned by the policy propagator, and the Policy CRD has no other status field that the API
+// server keeps them in. A policy without status details has no local overrides or pause reported on the hub.
+func mirrorAnnotations(instance *policiesv1.Policy, now time.Time) {
+	for i, dpt := range instance.Status.Details {
+		if dpt == nil {
+			continue
+		}
+
+		for _, annotation := range mirroredAnnotations {
+			value, ok := instance.GetAnnotations()[annotation]
+			if annotation == utils.PauseSyncAnnotation {
+				ok, _, _ = utils.SyncPausedUntil(instance, now)
+			}
+
+			if i != 0 || !ok {
+				delete(dpt.TemplateMeta.Annotations, annotation)
+
+				continue
+			}
+
+			if dpt.TemplateMeta.Annotations == nil {
+				dpt.TemplateMeta.Annotations = map[string]string{}
+			}
+
+			dpt.TemplateMeta.Annotations[annotation] = value
+		}
+
+		if len(dpt.TemplateMeta.Annotations) == 0 {
+			dpt.TemplateMeta.Annotations = nil
+		}
+	}
+}
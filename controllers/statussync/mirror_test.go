@@ -0,0 +1,67 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestMirrorAnnotations(t *testing.T) {
+	t.Parallel()
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "policy-a",
+			Namespace: "managed",
+			Annotations: map[string]string{
+				utils.PauseSyncAnnotation:      "true",
+				utils.LocalOverridesAnnotation: `{"remediationAction":"inform"}`,
+				"example.com/other":            "value",
+			},
+		},
+		Status: policiesv1.PolicyStatus{
+			Details: []*policiesv1.DetailsPerTemplate{
+				{TemplateMeta: metav1.ObjectMeta{Name: "template-a"}},
+				{TemplateMeta: metav1.ObjectMeta{
+					Name: "template-b",
+					Annotations: map[string]string{
+						utils.PauseSyncAnnotation:      "true",
+						utils.LocalOverridesAnnotation: `{}`,
+					},
+				}},
+			},
+		},
+	}
+
+	mirrorAnnotations(policy, time.Now())
+
+	expected := map[string]string{
+		utils.PauseSyncAnnotation:      "true",
+		utils.LocalOverridesAnnotation: `{"remediationAction":"inform"}`,
+	}
+
+	if !maps.Equal(policy.Status.Details[0].TemplateMeta.Annotations, expected) {
+		t.Fatalf("Expected the mirrored annotations on the first template but got: %v",
+			policy.Status.Details[0].TemplateMeta)
+	}
+
+	if policy.Status.Details[1].TemplateMeta.Annotations != nil {
+		t.Fatalf("Expected no mirrored annotations on the other templates but got: %v",
+			policy.Status.Details[1].TemplateMeta)
+	}
+
+	policy.Annotations = nil
+
+	mirrorAnnotations(policy, time.Now())
+
+	if policy.Status.Details[0].TemplateMeta.Annotations != nil {
+		t.Fatalf("Expected the mirrored annotations to be removed but got: %v", policy.Status.Details[0].TemplateMeta)
+	}
+}
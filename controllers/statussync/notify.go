@@ -0,0 +1,69 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/notifications"
+)
+
+// complianceChanges returns a notification for the policy and for each template whose compliance state differs
+// from the base status. History entries that don't change the compliance state don't result in notifications.
+func complianceChanges(
+	cluster string, instance *policiesv1.Policy, base policiesv1.PolicyStatus,
+) []notifications.Notification {
+	changes := []notifications.Notification{}
+
+	oldStates := make(map[string]policiesv1.ComplianceState, len(base.Details))
+
+	for _, dpt := range base.Details {
+		if dpt != nil {
+			oldStates[dpt.TemplateMeta.Name] = dpt.ComplianceState
+		}
+	}
+
+	for _, dpt := range instance.Status.Details {
+		if dpt == nil || oldStates[dpt.TemplateMeta.Name] == dpt.ComplianceState {
+			continue
+		}
+
+		notification := notifications.Notification{
+			Cluster:   cluster,
+			Namespace: instance.Namespace,
+			Policy:    instance.Name,
+			Template:  dpt.TemplateMeta.Name,
+			OldState:  oldStates[dpt.TemplateMeta.Name],
+			NewState:  dpt.ComplianceState,
+		}
+
+		if len(dpt.History) > 0 {
+			notification.Message = dpt.History[0].Message
+		}
+
+		changes = append(changes, notification)
+	}
+
+	if base.ComplianceState != instance.Status.ComplianceState {
+		changes = append(changes, notifications.Notification{
+			Cluster:   cluster,
+			Namespace: instance.Namespace,
+			Policy:    instance.Name,
+			OldState:  base.ComplianceState,
+			NewState:  instance.Status.ComplianceState,
+		})
+	}
+
+	return changes
+}
+
+// notifyComplianceChanges publishes the compliance state changes of the policy and its templates to the notifier.
+func (r *PolicyReconciler) notifyComplianceChanges(instance *policiesv1.Policy, base policiesv1.PolicyStatus) {
+	if r.Notifier == nil {
+		return
+	}
+
+	for _, notification := range complianceChanges(r.ClusterNamespaceOnHub, instance, base) {
+		r.Notifier.Notify(notification)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// untilResumed returns how long until the pause of the sync of the policy expires, so that the policy is reconciled to
+// stop reporting the pause to the hub when the sync resumes. It's 0 when the sync isn't paused or the pause doesn't
+// expire. An invalid pause-sync annotation doesn't pause the sync and is logged.
+func untilResumed(instance *policiesv1.Policy, now time.Time, log logr.Logger) time.Duration {
+	paused, until, err := utils.SyncPausedUntil(instance, now)
+	if err != nil {
+		log.Info("The sync of the policy is not paused because of an invalid annotation", "reason", err.Error())
+
+		return 0
+	}
+
+	if !paused || until.IsZero() {
+		return 0
+	}
+
+	return until.Sub(now)
+}
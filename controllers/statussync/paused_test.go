@@ -0,0 +1,60 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestPausedReporting(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Hour).Format(time.RFC3339)
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "policy-a",
+			Namespace:   "managed",
+			Annotations: map[string]string{utils.PauseSyncAnnotation: until},
+		},
+		Status: policiesv1.PolicyStatus{
+			Details: []*policiesv1.DetailsPerTemplate{{TemplateMeta: metav1.ObjectMeta{Name: "template-a"}}},
+		},
+	}
+
+	mirrorAnnotations(policy, now)
+
+	if got := policy.Status.Details[0].TemplateMeta.Annotations[utils.PauseSyncAnnotation]; got != until {
+		t.Fatalf("Expected the pause to be reported until %s but got %q", until, got)
+	}
+
+	if requeueAfter := untilResumed(policy, now, logr.Discard()); requeueAfter != time.Hour {
+		t.Fatalf("Expected a 1h requeue but got %s", requeueAfter)
+	}
+
+	// The pause expired.
+	mirrorAnnotations(policy, now.Add(2*time.Hour))
+
+	if policy.Status.Details[0].TemplateMeta.Annotations != nil {
+		t.Fatalf("Expected the pause to no longer be reported but got: %v", policy.Status.Details[0].TemplateMeta)
+	}
+
+	if requeueAfter := untilResumed(policy, now.Add(2*time.Hour), logr.Discard()); requeueAfter != 0 {
+		t.Fatalf("Expected no requeue but got %s", requeueAfter)
+	}
+
+	policy.Annotations = map[string]string{utils.PauseSyncAnnotation: "tomorrow"}
+
+	mirrorAnnotations(policy, now)
+
+	if policy.Status.Details[0].TemplateMeta.Annotations != nil {
+		t.Fatalf("Expected an invalid pause to not be reported but got: %v", policy.Status.Details[0].TemplateMeta)
+	}
+}
@@ -8,20 +8,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
 	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
 	corev1 "k8s.io/api/core/v1"
+	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
@@ -31,9 +35,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/complianceapi"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/notifications"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/uninstall"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
 )
@@ -42,19 +49,37 @@ const (
 	ControllerName string = "policy-status-sync"
 )
 
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. This also registers the EventInvolvedUIDIndex field index
+// on the manager's cache, once per manager since the status sync of each hub source runs on the same manager.
 func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager, additionalSources ...source.Source) error {
+	if _, indexed := indexedManagers.LoadOrStore(mgr, true); !indexed {
+		err := mgr.GetFieldIndexer().IndexField(
+			context.TODO(), &corev1.Event{}, EventInvolvedUIDIndex, indexEventByInvolvedUID,
+		)
+		if err != nil {
+			indexedManagers.Delete(mgr)
+
+			return err
+		}
+	}
+
+	inScope := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return r.inScope(obj)
+	})
+
+	controllerName := utils.HubSourceControllerName(ControllerName, r.HubSourceName)
+
 	builder := ctrl.NewControllerManagedBy(mgr).
-		For(&policiesv1.Policy{}).
+		For(&policiesv1.Policy{}, builder.WithPredicates(inScope)).
 		Watches(
 			&corev1.Event{},
 			handler.EnqueueRequestsFromMapFunc(eventMapper),
-			builder.WithPredicates(eventPredicateFuncs),
+			builder.WithPredicates(eventPredicateFuncs, predicate.NewPredicateFuncs(r.inNamespace)),
 		).
 		WithOptions(controller.Options{MaxConcurrentReconciles: r.ConcurrentReconciles}).
-		Named(ControllerName).
+		Named(controllerName).
 		WithLogConstructor(func(req *reconcile.Request) logr.Logger {
-			return utils.LogConstructor(ControllerName, "Policy", req)
+			return utils.LogConstructor(controllerName, "Policy", req)
 		})
 
 	for _, addlSource := range additionalSources {
@@ -69,6 +94,9 @@ func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager, additionalSources
 // blank assignment to verify that ReconcilePolicy implements reconcile.Reconciler
 var _ reconcile.Reconciler = &PolicyReconciler{}
 
+// indexedManagers are the managers that the EventInvolvedUIDIndex field index is registered on.
+var indexedManagers sync.Map
+
 // ReconcilePolicy reconciles a Policy object
 type PolicyReconciler struct {
 	// This client, initialized using mgr.Client() above, is a split client
@@ -83,12 +111,54 @@ type PolicyReconciler struct {
 	ConcurrentReconciles  int
 	SpecSyncRequests      chan<- event.GenericEvent
 	OnMulticlusterhub     bool
+	// EventlessKinds are the policy template kinds whose compliance is only read from the template's status.history
+	// field. This is in addition to kinds with the compliance-events=disabled label on their CRD.
+	EventlessKinds []schema.GroupKind
+	// StaleThresholds is the maximum age of the most recent compliance history entry of a policy template, per
+	// template kind, before its compliance is reported as stale.
+	StaleThresholds map[schema.GroupKind]time.Duration
+	// FlappingTransitions is the number of compliance transitions of a policy template within FlappingWindow at which
+	// its compliance is considered flapping. A value of 0 disables flapping detection.
+	FlappingTransitions int
+	FlappingWindow      time.Duration
+	// SuppressFlappingHubUpdates keeps the hub status of flapping policy templates from changing until they stabilize.
+	SuppressFlappingHubUpdates bool
+	// MaxMessageBytes is the byte budget of each compliance message in the policy status. A value of 0 means no limit.
+	MaxMessageBytes int
+	// MaxStatusBytes is the byte budget of the status details of a policy. A value of 0 means no limit.
+	MaxStatusBytes int
+	// Notifier publishes notifications when the compliance of a policy or template changes. It's optional.
+	Notifier *notifications.Dispatcher
+	// ComplianceReporter sends every new compliance event to the compliance history API on the hub. It's optional.
+	ComplianceReporter *complianceapi.Reporter
+	// ClusterID is the unique identifier of the managed cluster reported to the compliance history API.
+	ClusterID string
+	// PolicySelector selects the policies whose status is synced. All policies are synced when it's nil.
+	PolicySelector labels.Selector
+	// ClusterNamespace is the namespace of the managed policies of the hub source. Policies in other namespaces are
+	// synced by the status sync of other hub sources. All namespaces are handled when it's empty.
+	ClusterNamespace string
+	// The name of the additional hub source that the status is synced to. It's empty for the primary hub.
+	HubSourceName string
+}
+
+// inNamespace returns whether the object is in the namespace of the managed policies of the hub source.
+func (r *PolicyReconciler) inNamespace(obj client.Object) bool {
+	return r.ClusterNamespace == "" || obj.GetNamespace() == r.ClusterNamespace
+}
+
+// inScope returns whether the status of the managed policy is synced by this status sync.
+func (r *PolicyReconciler) inScope(obj client.Object) bool {
+	return r.inNamespace(obj) && utils.MatchesPolicySelector(r.PolicySelector, obj)
 }
 
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/finalizers,verbs=update
 //+kubebuilder:rbac:groups=core;events.k8s.io,resources=events,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=list;watch
+// This is required to report the cluster ID to the compliance history API
+//+kubebuilder:rbac:groups=core,resources=namespaces,resourceNames=kube-system,verbs=get
 // This is required for the status lease for the addon framework
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
 
@@ -133,20 +203,63 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 	reqLogger.Info("Recalculating details for policy templates")
 
 	oldStatus := *instance.Status.DeepCopy()
+	// The compliance notifications and reported compliance events are relative to the hub status when it's seeded so
+	// that a reinstall doesn't notify of every compliance state or report the seeded history again.
+	notifyBase := oldStatus
+
+	if seedStatusFromHub(instance, hubInstance) {
+		reqLogger.Info("The policy status is empty on the managed cluster. Seeded the history from the hub.")
+
+		notifyBase = *hubInstance.Status.DeepCopy()
+	}
 
 	instance.Status.Details, err = r.getDetails(ctx, instance)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	err = r.updateStatuses(ctx, instance, hubInstance, oldStatus)
+	r.setAppliedPolicyGenerations(instance, hubInstance)
+	mirrorAnnotations(instance, time.Now())
+
+	// Requeue when the next template would become stale since no events trigger a reconcile when a policy engine
+	// stops reporting.
+	requeueAfter := r.markStaleTemplates(ctx, instance, oldStatus, time.Now())
+
+	// Also requeue when the next flapping template would stabilize to clear its flapping annotation.
+	untilStable := r.markFlappingTemplates(instance, time.Now())
+	if untilStable != 0 && (requeueAfter == 0 || untilStable < requeueAfter) {
+		requeueAfter = untilStable
+	}
+
+	// Also requeue when the pause of the sync expires to stop reporting it to the hub.
+	if untilResumed := untilResumed(instance, time.Now(), reqLogger); untilResumed != 0 &&
+		(requeueAfter == 0 || untilResumed < requeueAfter) {
+		requeueAfter = untilResumed
+	}
+
+	if limitStatusSize(instance.Status.Details, r.MaxStatusBytes) {
+		reqLogger.Info("The policy status exceeded the byte budget. Truncated the compliance history.",
+			"maxStatusBytes", r.MaxStatusBytes)
+	}
+
+	managedUpdated, err := r.updateStatuses(ctx, instance, hubInstance, oldStatus)
+	if managedUpdated {
+		r.notifyComplianceChanges(instance, notifyBase)
+		r.reportComplianceEvents(instance, notifyBase)
+	}
+
+	// The status is synced to the hub when the hub is back, so there's no need to retry with a backoff.
+	if errors.Is(err, utils.ErrHubOffline) {
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
+
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	reqLogger.V(1).Info("Reconciling complete")
 
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // getInstances retrieves both the managed cluster and hub cluster instances of
@@ -161,6 +274,10 @@ func (r *PolicyReconciler) getInstances(
 	err = r.ManagedClient.Get(ctx, request.NamespacedName, managedInstance)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
+			policyLabels := prometheus.Labels{"namespace": request.Namespace, "policy": request.Name}
+			_ = policyTemplateStaleGauge.DeletePartialMatch(policyLabels)
+			_ = policyTemplateFlappingGauge.DeletePartialMatch(policyLabels)
+
 			if r.OnMulticlusterhub {
 				return nil, nil, nil
 			}
@@ -198,6 +315,12 @@ func (r *PolicyReconciler) getInstances(
 		return nil, nil, err
 	}
 
+	if !r.inScope(managedInstance) {
+		reqLogger.V(1).Info("Policy isn't synced by this status sync")
+
+		return nil, nil, nil
+	}
+
 	if r.OnMulticlusterhub {
 		return managedInstance, nil, nil
 	}
@@ -224,6 +347,21 @@ func (r *PolicyReconciler) getInstances(
 		return nil, nil, err
 	}
 
+	// The spec sync leaves paused policies alone, so the status is synced even if the hub policy changed.
+	if paused, _, _ := utils.SyncPausedUntil(managedInstance, time.Now()); paused {
+		return managedInstance, hubInstance, nil
+	}
+
+	// The spec sync leaves policies with a reported drift alone in the report drift mode.
+	if utils.OnlyRecordedDrift(managedInstance, hubInstance) {
+		return managedInstance, hubInstance, nil
+	}
+
+	// The spec sync keeps the replicated policy when the signature of the hub policy is rejected.
+	if utils.OnlyRejectedSignature(managedInstance, hubInstance) {
+		return managedInstance, hubInstance, nil
+	}
+
 	if !utils.EquivalentReplicatedPolicies(managedInstance, hubInstance) {
 		if r.SpecSyncRequests != nil {
 			reqLogger.Info("Found a mismatch with the hub and managed policies. Triggering the spec-sync to handle it.")
@@ -237,41 +375,72 @@ func (r *PolicyReconciler) getInstances(
 	return managedInstance, hubInstance, nil
 }
 
+// seedStatusFromHub copies the status details from the hub policy to the managed
+// policy when the managed policy has an empty status. This happens when the
+// managed cluster is rebuilt or the addon is reinstalled, in which case the
+// compliance events are also gone. The seeded history is then treated as the
+// existing status by getDetails, so new events are merged on top of it without
+// duplicates. The caller must ensure the policies are equivalent. It returns
+// whether the status was seeded.
+func seedStatusFromHub(instance, hubInstance *policiesv1.Policy) bool {
+	if hubInstance == nil || len(hubInstance.Status.Details) == 0 {
+		return false
+	}
+
+	if len(instance.Status.Details) != 0 || instance.Status.ComplianceState != "" {
+		return false
+	}
+
+	instance.Status.Details = make([]*policiesv1.DetailsPerTemplate, 0, len(hubInstance.Status.Details))
+
+	for _, dpt := range hubInstance.Status.Details {
+		if dpt != nil {
+			instance.Status.Details = append(instance.Status.Details, dpt.DeepCopy())
+		}
+	}
+
+	return true
+}
+
 // getEventsInCluster retrieves and filters compliance events for a policy from
-// the managed cluster, organizing them by template name. If an event name has
-// the conventional hexadecimal timestamp suffix, that will be used for a
-// higher-precision timestamp in the returned history.
+// the managed cluster, organizing them by template name. The Events are looked
+// up with the EventInvolvedUIDIndex field index so that only the Events for the
+// current policy are read. If an event name has the conventional hexadecimal
+// timestamp suffix, that will be used for a higher-precision timestamp in the
+// returned history.
 func (r *PolicyReconciler) getEventsInCluster(
 	ctx context.Context, instance *policiesv1.Policy,
 ) (map[string][]policiesv1.ComplianceHistory, error) {
 	eventList := &corev1.EventList{}
 
-	err := r.ManagedClient.List(ctx, eventList, client.InNamespace(instance.GetNamespace()))
+	err := r.ManagedClient.List(
+		ctx,
+		eventList,
+		client.InNamespace(instance.GetNamespace()),
+		client.MatchingFields{EventInvolvedUIDIndex: string(instance.UID)},
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	// filter events to current policy instance and build map
 	eventForPolicyMap := make(map[string][]policiesv1.ComplianceHistory)
-	rgx := regexp.MustCompile(`(?i)^policy:\s*(?:([a-z0-9.-]+)\s*\/)?(.+)`)
 
 	for _, event := range eventList.Items {
 		// sample event.Reason -- reason: 'policy: calamari/policy-grc-rbactest-example'
-		reason := rgx.FindString(event.Reason)
-		// Only handle events that match the UID of the current Policy
-		if event.InvolvedObject.UID == instance.UID && reason != "" {
-			templateName := rgx.FindStringSubmatch(event.Reason)[2]
-
-			histEvent := policiesv1.ComplianceHistory{
-				// If available, a higher precision timestamp is added in mergeDetails() before sorting
-				LastTimestamp: event.LastTimestamp,
-				Message: strings.TrimSpace(strings.TrimPrefix(
-					event.Message, "(combined from similar events):")),
-				EventName: event.GetName(),
-			}
+		templateName := eventTemplateName(&event)
+		if templateName == "" {
+			continue
+		}
 
-			eventForPolicyMap[templateName] = append(eventForPolicyMap[templateName], histEvent)
+		histEvent := policiesv1.ComplianceHistory{
+			// If available, a higher precision timestamp is added in mergeDetails() before sorting
+			LastTimestamp: event.LastTimestamp,
+			Message: strings.TrimSpace(strings.TrimPrefix(
+				event.Message, "(combined from similar events):")),
+			EventName: event.GetName(),
 		}
+
+		eventForPolicyMap[templateName] = append(eventForPolicyMap[templateName], histEvent)
 	}
 
 	return eventForPolicyMap, nil
@@ -354,16 +523,28 @@ func templateOwnedByPolicy(obj *unstructured.Unstructured, policyName string) bo
 // building a history of compliance states and deduplicating similar events.
 // It limits history to 10 events per template, sorts by timestamp (most recent
 // first), and returns detailed status information for status synchronization.
+// The Events in the cluster namespace are only listed if at least one template
+// does not solely report its compliance in its status history.
 func (r *PolicyReconciler) getDetails(
 	ctx context.Context, instance *policiesv1.Policy,
 ) (allDetails []*policiesv1.DetailsPerTemplate, err error) {
 	reqLogger := ctrl.LoggerFrom(ctx).WithValues("HubNamespace", r.ClusterNamespaceOnHub)
 
-	eventForPolicyMap, err := r.getEventsInCluster(ctx, instance)
-	if err != nil {
-		reqLogger.Error(err, "Error listing events, will requeue the request")
+	var eventForPolicyMap map[string][]policiesv1.ComplianceHistory
 
-		return nil, err
+	// getClusterEvents lazily lists the compliance events for the policy so that policies with only eventless
+	// templates never list the Events in the cluster namespace.
+	getClusterEvents := func(tName string) ([]policiesv1.ComplianceHistory, error) {
+		if eventForPolicyMap == nil {
+			eventForPolicyMap, err = r.getEventsInCluster(ctx, instance)
+			if err != nil {
+				reqLogger.Error(err, "Error listing events, will requeue the request")
+
+				return nil, err
+			}
+		}
+
+		return eventForPolicyMap[tName], nil
 	}
 
 	policyObjID := policyID(instance.Name, instance.Namespace)
@@ -371,8 +552,16 @@ func (r *PolicyReconciler) getDetails(
 	for i, policyT := range instance.Spec.PolicyTemplates {
 		var tName string
 
+		// The events from the template status history are only used if the template exists and is owned by the
+		// policy. Otherwise, template-sync reports errors and pending dependencies with Kubernetes Events.
+		var templateEvents []policiesv1.ComplianceHistory
+
+		useClusterEvents := true
 		existingDPTs := instance.Status.Details
 
+		// The applied template object, which is only set when it's owned by the policy
+		var appliedObj *unstructured.Unstructured
+
 		object, tmplGVK, err := unstructured.UnstructuredJSONScheme.Decode(policyT.ObjectDefinition.Raw, nil, nil)
 		if err != nil {
 			reqLogger.Error(err, "Failed to decode policy template", "TemplateIdx", i)
@@ -404,8 +593,18 @@ func (r *PolicyReconciler) getDetails(
 				tmplOwnedByPolicy := templateOwnedByPolicy(tmplUnstruct, policyObjID.Name)
 
 				if tmplOwnedByPolicy {
-					templateEvents := getEventsInTemplate(tmplUnstruct, policyObjID.Name)
-					eventForPolicyMap[tName] = append(eventForPolicyMap[tName], templateEvents...)
+					appliedObj = tmplUnstruct
+					templateEvents = getEventsInTemplate(tmplUnstruct, policyObjID.Name)
+
+					eventless, err := r.isEventlessKind(ctx, tmplGVK.GroupKind())
+					if err != nil {
+						reqLogger.Error(err, "Failed to determine if the template kind is eventless, will requeue "+
+							"the request", "TemplateName", tName, "TemplateIdx", i)
+
+						return nil, err
+					}
+
+					useClusterEvents = !eventless
 				}
 
 				if tmplUnstruct != nil && !tmplOwnedByPolicy {
@@ -415,8 +614,20 @@ func (r *PolicyReconciler) getDetails(
 			}
 		}
 
+		if useClusterEvents {
+			clusterEvents, err := getClusterEvents(tName)
+			if err != nil {
+				return nil, err
+			}
+
+			templateEvents = append(clusterEvents, templateEvents...)
+		}
+
 		detailLogger := reqLogger.WithValues("TemplateName", tName, "TemplateIdx", i)
-		templateDetails := mergeDetails(eventForPolicyMap[tName], existingDPTs, tName, detailLogger)
+		prevLatestEvent := latestEventName(existingDPTs, tName)
+		templateDetails := mergeDetails(templateEvents, existingDPTs, tName, r.MaxMessageBytes, detailLogger)
+
+		setAppliedObjectMeta(templateDetails, appliedObj, prevLatestEvent)
 
 		allDetails = append(allDetails, templateDetails)
 
@@ -426,16 +637,48 @@ func (r *PolicyReconciler) getDetails(
 	return allDetails, nil
 }
 
+// isEventlessKind returns whether the compliance of templates of the input kind is only reported in the template's
+// status.history field. This is configured on the reconciler or with the compliance-events=disabled label on the CRD.
+// The CRD lookup is served by the cache, which only contains CRDs with the policy-type=template label.
+func (r *PolicyReconciler) isEventlessKind(ctx context.Context, gk schema.GroupKind) (bool, error) {
+	if slices.Contains(r.EventlessKinds, gk) {
+		return true, nil
+	}
+
+	crdList := &extensionsv1.CustomResourceDefinitionList{}
+
+	err := r.ManagedClient.List(ctx, crdList, client.MatchingLabelsSelector{
+		Selector: labels.SelectorFromSet(labels.Set{utils.ComplianceEventsLabel: "disabled"}),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, crd := range crdList.Items {
+		if crd.Spec.Group == gk.Group && crd.Spec.Names.Kind == gk.Kind {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 // mergeDetails combines new compliance events with existing template status
 // details, deduplicating events, sorting by timestamp, limiting history to 10
 // events, and determining the compliance state from the most recent event. It
-// preserves existing status details when available.
+// preserves existing status details when available. The messages of the new
+// events are truncated to maxMessageBytes, where 0 means no limit.
 func mergeDetails(
 	events []policiesv1.ComplianceHistory,
 	existingDPTs []*policiesv1.DetailsPerTemplate,
 	tName string,
+	maxMessageBytes int,
 	detailLogger logr.Logger,
 ) (details *policiesv1.DetailsPerTemplate) {
+	for i := range events {
+		events[i].Message = utils.TruncateMessage(events[i].Message, maxMessageBytes)
+	}
+
 	details = &policiesv1.DetailsPerTemplate{
 		TemplateMeta: metav1.ObjectMeta{
 			Name: tName,
@@ -533,39 +776,18 @@ func timestampsWithin(ts1, ts2 metav1.Time, threshold time.Duration) bool {
 }
 
 // updateStatuses determines the overall compliance state from template details
-// and synchronizes policy status between managed and hub clusters. It updates
-// the managed cluster first, then propagates changes to the hub cluster, only
-// when status changes are detected.
+// using the rollup rules selected on the policy, and synchronizes policy status
+// between managed and hub clusters. It updates the managed cluster first, then
+// propagates changes to the hub cluster, only when status changes are detected.
+// It returns whether the managed policy status was updated.
 func (r *PolicyReconciler) updateStatuses(
 	ctx context.Context, instance, hubInstance *policiesv1.Policy, oldStatus policiesv1.PolicyStatus,
-) (err error) {
+) (managedUpdated bool, err error) {
 	reqLogger := ctrl.LoggerFrom(ctx).WithValues("HubNamespace", r.ClusterNamespaceOnHub)
 
-	// one violation found in status of one template, set overall compliancy to NonCompliant
-	isCompliant := true
-
-Loop:
-	for _, dpt := range instance.Status.Details {
-		switch dpt.ComplianceState {
-		case policiesv1.NonCompliant:
-			instance.Status.ComplianceState = policiesv1.NonCompliant
-			isCompliant = false
-
-			break Loop
-		case policiesv1.Pending:
-			instance.Status.ComplianceState = policiesv1.Pending
-			isCompliant = false
-		case policiesv1.Compliant: // Continue if the status is compliant, no need to update the compliance state
-			continue Loop
-		case "":
-			isCompliant = false
-		}
-	}
+	markUnknownTemplates(instance)
 
-	// set to compliant only when all templates are compliant
-	if isCompliant {
-		instance.Status.ComplianceState = policiesv1.Compliant
-	}
+	instance.Status.ComplianceState = statusComplianceState(rollupCompliance(instance, reqLogger))
 
 	// Update status on managed cluster if needed.
 	match := equality.Semantic.DeepEqual(instance.Status.Details, oldStatus.Details) &&
@@ -578,9 +800,11 @@ Loop:
 		if err != nil {
 			reqLogger.Error(err, "Failed to get update policy status on managed")
 
-			return err
+			return false, err
 		}
 
+		managedUpdated = true
+
 		r.ManagedRecorder.Eventf(instance, nil, corev1.EventTypeNormal, "PolicyStatusSync", "PolicyStatusSync",
 			fmt.Sprintf("Policy %s status was updated in cluster namespace %s", instance.GetName(),
 				instance.GetNamespace()))
@@ -600,27 +824,40 @@ Loop:
 			hubInstance = updatedHubInstance
 		}
 
-		if !equality.Semantic.DeepEqual(hubInstance.Status, instance.Status) {
+		hubStatus := r.desiredHubStatus(instance, hubInstance, reqLogger)
+
+		if !equality.Semantic.DeepEqual(hubInstance.Status, hubStatus) {
 			reqLogger.Info("status not in sync, update the hub")
 
-			hubInstance.Status = instance.Status
+			hubInstance.Status = hubStatus
 
 			err = r.HubClient.Status().Update(ctx, hubInstance)
+			if errors.Is(err, utils.ErrHubOffline) {
+				reqLogger.Info("The hub is offline. The policy status is queued until the hub is back.")
+
+				return managedUpdated, err
+			}
+
 			if err != nil {
 				reqLogger.Error(err, "Failed to update policy status on hub")
 
-				return err
+				return managedUpdated, err
+			}
+
+			hubCompliance := hubInstance.Status.ComplianceState
+			if hubCompliance == "" {
+				hubCompliance = ComplianceUnknown
 			}
 
 			r.HubRecorder.Eventf(hubInstance, nil, corev1.EventTypeNormal, "PolicyStatusSync", "PolicyStatusSync",
 				fmt.Sprintf("Policy %s status was updated to %s in cluster namespace %s", hubInstance.GetName(),
-					hubInstance.Status.ComplianceState, hubInstance.GetNamespace()))
+					hubCompliance, hubInstance.GetNamespace()))
 		} else {
 			reqLogger.V(1).Info("status match on hub, nothing to update")
 		}
 	}
 
-	return nil
+	return managedUpdated, nil
 }
 
 func (r *PolicyReconciler) triggerSpecSyncReconcile(request reconcile.Request) {
@@ -5,10 +5,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
 )
@@ -200,3 +207,371 @@ func TestGetEventsInTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestIsEventlessKind(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+
+	err := extensionsv1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to set up the scheme: %s", err)
+	}
+
+	labeledCRD := &extensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "historypolicies.policy.open-cluster-management.io",
+			Labels: map[string]string{
+				utils.PolicyTypeLabel:       "template",
+				utils.ComplianceEventsLabel: "disabled",
+			},
+		},
+		Spec: extensionsv1.CustomResourceDefinitionSpec{
+			Group: "policy.open-cluster-management.io",
+			Names: extensionsv1.CustomResourceDefinitionNames{Kind: "HistoryPolicy"},
+		},
+	}
+	unlabeledCRD := &extensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "certificatepolicies.policy.open-cluster-management.io",
+			Labels: map[string]string{utils.PolicyTypeLabel: "template"},
+		},
+		Spec: extensionsv1.CustomResourceDefinitionSpec{
+			Group: "policy.open-cluster-management.io",
+			Names: extensionsv1.CustomResourceDefinitionNames{Kind: "CertificatePolicy"},
+		},
+	}
+
+	r := PolicyReconciler{
+		ManagedClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(labeledCRD, unlabeledCRD).Build(),
+		EventlessKinds: []schema.GroupKind{
+			{Group: "policy.open-cluster-management.io", Kind: "ConfigurationPolicy"},
+		},
+	}
+
+	tests := []struct {
+		kind string
+		want bool
+	}{
+		{kind: "ConfigurationPolicy", want: true},
+		{kind: "HistoryPolicy", want: true},
+		{kind: "CertificatePolicy", want: false},
+		{kind: "OperatorPolicy", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			t.Parallel()
+
+			gk := schema.GroupKind{Group: "policy.open-cluster-management.io", Kind: tt.kind}
+
+			got, err := r.isEventlessKind(t.Context(), gk)
+			if err != nil {
+				t.Fatalf("isEventlessKind() returned an unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("isEventlessKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEventsInClusterByIndex(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to set up the scheme: %s", err)
+	}
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-a", Namespace: "managed", UID: "policy-a-uid"},
+	}
+
+	makeEvent := func(name, reason string, uid types.UID) *corev1.Event {
+		event := &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "managed"},
+			InvolvedObject: corev1.ObjectReference{
+				Kind:       policiesv1.Kind,
+				APIVersion: policiesv1APIVersion,
+				Name:       "policy-a",
+				Namespace:  "managed",
+				UID:        uid,
+			},
+			Reason:  reason,
+			Message: "Compliant; notification - no violations",
+		}
+
+		transformed, err := TransformEvent(event)
+		if err != nil {
+			t.Fatalf("Failed to transform the event: %v", err)
+		}
+
+		return transformed.(*corev1.Event)
+	}
+
+	managedClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&corev1.Event{}, EventInvolvedUIDIndex, indexEventByInvolvedUID).
+		WithObjects(
+			makeEvent("policy-a.17b80d88a995e12c", "policy: managed/config-a", "policy-a-uid"),
+			makeEvent("policy-a.17b80d88a995e12d", "policy: config-b", "policy-a-uid"),
+			makeEvent("policy-a.17b80d88a995e12e", "PolicyTemplateSync", "policy-a-uid"),
+			makeEvent("policy-a.17b80d88a995e12f", "policy: managed/config-a", "old-policy-a-uid"),
+		).
+		Build()
+
+	r := PolicyReconciler{ManagedClient: managedClient}
+
+	eventMap, err := r.getEventsInCluster(t.Context(), policy)
+	if err != nil {
+		t.Fatalf("getEventsInCluster() returned an unexpected error: %v", err)
+	}
+
+	if len(eventMap) != 2 {
+		t.Fatalf("getEventsInCluster() returned %d templates, want 2", len(eventMap))
+	}
+
+	if len(eventMap["config-a"]) != 1 || eventMap["config-a"][0].EventName != "policy-a.17b80d88a995e12c" {
+		t.Errorf("getEventsInCluster() config-a events = %v, want only policy-a.17b80d88a995e12c",
+			eventMap["config-a"])
+	}
+
+	if len(eventMap["config-b"]) != 1 {
+		t.Errorf("getEventsInCluster() config-b events = %v, want 1 event", eventMap["config-b"])
+	}
+}
+
+func TestSeedStatusFromHub(t *testing.T) {
+	t.Parallel()
+
+	oldTime := metav1.NewTime(time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC))
+	newTime := metav1.NewTime(oldTime.Add(time.Hour))
+
+	hubPolicy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-a", Namespace: "managed-hub"},
+		Status: policiesv1.PolicyStatus{
+			ComplianceState: policiesv1.NonCompliant,
+			Details: []*policiesv1.DetailsPerTemplate{{
+				TemplateMeta:    metav1.ObjectMeta{Name: "config-a"},
+				ComplianceState: policiesv1.NonCompliant,
+				History: []policiesv1.ComplianceHistory{{
+					LastTimestamp: oldTime,
+					Message:       "NonCompliant; violation - pods not found",
+					EventName:     "policy-a.17b80d88a995e12c",
+				}},
+			}},
+		},
+	}
+
+	managedPolicy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-a", Namespace: "managed"},
+	}
+
+	if !seedStatusFromHub(managedPolicy, hubPolicy) {
+		t.Fatal("Expected the empty managed status to be seeded from the hub")
+	}
+
+	// The hub status must not be shared with the managed policy.
+	managedPolicy.Status.Details[0].TemplateMeta.Name = "changed"
+	if hubPolicy.Status.Details[0].TemplateMeta.Name != "config-a" {
+		t.Fatal("Expected the seeded status to be a copy of the hub status")
+	}
+
+	managedPolicy.Status.Details[0].TemplateMeta.Name = "config-a"
+
+	if seedStatusFromHub(managedPolicy, hubPolicy) {
+		t.Error("Expected a non-empty managed status to not be seeded again")
+	}
+
+	// A new event and a replay of the seeded event are merged without duplicates.
+	events := []policiesv1.ComplianceHistory{
+		{LastTimestamp: newTime, Message: "Compliant; notification - pods found", EventName: "policy-a.new"},
+		hubPolicy.Status.Details[0].History[0],
+	}
+
+	details := mergeDetails(events, managedPolicy.Status.Details, "config-a", 0, logr.Discard())
+
+	if len(details.History) != 2 {
+		t.Fatalf("Expected 2 history entries after merging but got %d: %v", len(details.History), details.History)
+	}
+
+	if details.ComplianceState != policiesv1.Compliant {
+		t.Errorf("Expected the compliance to be Compliant but got %s", details.ComplianceState)
+	}
+}
+
+func TestComplianceChanges(t *testing.T) {
+	t.Parallel()
+
+	base := policiesv1.PolicyStatus{
+		ComplianceState: policiesv1.Compliant,
+		Details: []*policiesv1.DetailsPerTemplate{
+			{TemplateMeta: metav1.ObjectMeta{Name: "config-a"}, ComplianceState: policiesv1.Compliant},
+			{TemplateMeta: metav1.ObjectMeta{Name: "config-b"}, ComplianceState: policiesv1.Compliant},
+		},
+	}
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-a", Namespace: "managed"},
+		Status: policiesv1.PolicyStatus{
+			ComplianceState: policiesv1.NonCompliant,
+			Details: []*policiesv1.DetailsPerTemplate{
+				{
+					TemplateMeta:    metav1.ObjectMeta{Name: "config-a"},
+					ComplianceState: policiesv1.NonCompliant,
+					History:         []policiesv1.ComplianceHistory{{Message: "NonCompliant; violation"}},
+				},
+				{
+					TemplateMeta:    metav1.ObjectMeta{Name: "config-b"},
+					ComplianceState: policiesv1.Compliant,
+					History:         []policiesv1.ComplianceHistory{{Message: "Compliant; a new history entry"}},
+				},
+			},
+		},
+	}
+
+	changes := complianceChanges("cluster1", policy, base)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes but got: %v", changes)
+	}
+
+	if changes[0].Template != "config-a" || changes[0].OldState != policiesv1.Compliant ||
+		changes[0].NewState != policiesv1.NonCompliant || changes[0].Message != "NonCompliant; violation" {
+		t.Errorf("Unexpected template change: %v", changes[0])
+	}
+
+	if changes[1].Template != "" || changes[1].Cluster != "cluster1" || changes[1].NewState != policiesv1.NonCompliant {
+		t.Errorf("Unexpected policy change: %v", changes[1])
+	}
+}
+
+func TestNewComplianceEvents(t *testing.T) {
+	t.Parallel()
+
+	oldTime := metav1.NewTime(time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC))
+	newTime := metav1.NewTime(oldTime.Add(time.Hour))
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "policies.policy-a",
+			Namespace: "managed",
+			Labels:    map[string]string{"policy.open-cluster-management.io/root-policy": "policies.policy-a"},
+			Annotations: map[string]string{
+				"policy.open-cluster-management.io/standards": "NIST SP 800-53",
+				"policy.open-cluster-management.io/controls":  "CM-2 Baseline Configuration, CM-6",
+			},
+		},
+		Spec: policiesv1.PolicySpec{
+			PolicyTemplates: []*policiesv1.PolicyTemplate{{
+				ObjectDefinition: runtime.RawExtension{Raw: []byte(`{
+					"apiVersion": "policy.open-cluster-management.io/v1",
+					"kind": "ConfigurationPolicy",
+					"metadata": {"name": "config-a"},
+					"spec": {"remediationAction": "inform", "severity": "high"}
+				}`)},
+			}},
+		},
+		Status: policiesv1.PolicyStatus{
+			Details: []*policiesv1.DetailsPerTemplate{{
+				TemplateMeta: metav1.ObjectMeta{Name: "config-a", Namespace: "managed"},
+				History: []policiesv1.ComplianceHistory{
+					{LastTimestamp: newTime, Message: "NonCompliant; violation - pod not found", EventName: "b"},
+					{LastTimestamp: oldTime, Message: "Compliant; notification - pod found", EventName: "a"},
+				},
+			}},
+		},
+	}
+
+	base := policiesv1.PolicyStatus{
+		Details: []*policiesv1.DetailsPerTemplate{{
+			TemplateMeta: metav1.ObjectMeta{Name: "config-a"},
+			History:      []policiesv1.ComplianceHistory{{EventName: "a"}},
+		}},
+	}
+
+	events := newComplianceEvents("cluster1", "1234", policy, base)
+	if len(events) != 1 {
+		t.Fatalf("Expected only the new event to be reported but got: %v", events)
+	}
+
+	event := events[0]
+
+	if event.Event.Compliance != "NonCompliant" || event.Event.Message != "violation - pod not found" ||
+		!event.Event.Timestamp.Equal(newTime.Time) {
+		t.Errorf("Unexpected event: %v", event.Event)
+	}
+
+	if event.Policy.Kind != "ConfigurationPolicy" || event.Policy.Namespace != "managed" ||
+		event.Policy.Severity != "high" || event.Policy.SpecHash == "" || event.Policy.Spec["severity"] != "high" {
+		t.Errorf("Unexpected policy: %v", event.Policy)
+	}
+
+	if event.ParentPolicy == nil || event.ParentPolicy.Name != "policy-a" ||
+		event.ParentPolicy.Namespace != "policies" || len(event.ParentPolicy.Controls) != 2 {
+		t.Errorf("Unexpected parent policy: %v", event.ParentPolicy)
+	}
+
+	if event.Cluster.Name != "cluster1" || event.Cluster.ClusterID != "1234" ||
+		event.Event.Metadata["policySpecHash"] == "" {
+		t.Errorf("Unexpected cluster or metadata: %v %v", event.Cluster, event.Event.Metadata)
+	}
+}
+
+func TestInScope(t *testing.T) {
+	t.Parallel()
+
+	selector, err := labels.Parse("team=a")
+	if err != nil {
+		t.Fatalf("Failed to parse the selector: %v", err)
+	}
+
+	plc := func(namespace string, lbls map[string]string) *policiesv1.Policy {
+		return &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: namespace, Labels: lbls}}
+	}
+
+	tests := map[string]struct {
+		reconciler PolicyReconciler
+		policy     *policiesv1.Policy
+		expected   bool
+	}{
+		"all namespaces": {
+			reconciler: PolicyReconciler{},
+			policy:     plc("other", nil),
+			expected:   true,
+		},
+		"same namespace": {
+			reconciler: PolicyReconciler{ClusterNamespace: "managed"},
+			policy:     plc("managed", nil),
+			expected:   true,
+		},
+		"namespace of another hub source": {
+			reconciler: PolicyReconciler{ClusterNamespace: "managed"},
+			policy:     plc("other", nil),
+			expected:   false,
+		},
+		"matching selector": {
+			reconciler: PolicyReconciler{ClusterNamespace: "managed", PolicySelector: selector},
+			policy:     plc("managed", map[string]string{"team": "a"}),
+			expected:   true,
+		},
+		"not matching selector": {
+			reconciler: PolicyReconciler{ClusterNamespace: "managed", PolicySelector: selector},
+			policy:     plc("managed", map[string]string{"team": "b"}),
+			expected:   false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := test.reconciler.inScope(test.policy); actual != test.expected {
+				t.Errorf("Expected %t but got %t", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/go-logr/logr"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+)
+
+const (
+	// ComplianceRollupAnnotation selects a comma-separated list of rollup rules that adjust how the compliance of
+	// the policy templates is combined into the policy compliance. See rollupRules for the supported rules.
+	ComplianceRollupAnnotation = common.APIGroup + "/compliance-rollup"
+	// ComplianceRollupMinSeverityAnnotation is the minimum template severity that makes the policy NonCompliant when
+	// the severity-weighted rollup rule is selected. This defaults to high.
+	ComplianceRollupMinSeverityAnnotation = common.APIGroup + "/compliance-rollup-min-severity"
+	// ComplianceUnknown is the rolled up compliance when the policy templates have never reported a compliance
+	// state. The Policy CRD only allows Compliant, NonCompliant, and Pending, so it's never written to the status. See
+	// statusComplianceState.
+	ComplianceUnknown policiesv1.ComplianceState = "Unknown"
+	// ComplianceUnknownAnnotation is set in the templateMeta of a policy template's status details to the reason that
+	// its compliance is unknown, so that an unknown compliance is told apart from a status that wasn't reported yet.
+	ComplianceUnknownAnnotation = common.APIGroup + "/compliance-unknown"
+)
+
+// severityRanks orders the severity values used by the policy template kinds.
+var severityRanks = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// rollupTemplate is the compliance of a single policy template as considered by the rollup rules.
+type rollupTemplate struct {
+	compliance policiesv1.ComplianceState
+	severity   string
+	// ignored templates are not considered in the policy compliance.
+	ignored bool
+}
+
+// rollupRule adjusts a template's compliance before it's rolled up into the policy compliance.
+type rollupRule func(policy *policiesv1.Policy, tmpl *rollupTemplate)
+
+// rollupRules are the rules that can be selected with the ComplianceRollupAnnotation annotation.
+var rollupRules = map[string]rollupRule{
+	// pending-as-compliant treats templates with unsatisfied dependencies as Compliant.
+	"pending-as-compliant": func(_ *policiesv1.Policy, tmpl *rollupTemplate) {
+		if tmpl.compliance == policiesv1.Pending {
+			tmpl.compliance = policiesv1.Compliant
+		}
+	},
+	// ignore-unknown skips templates that have not reported a compliance state.
+	"ignore-unknown": func(_ *policiesv1.Policy, tmpl *rollupTemplate) {
+		if tmpl.compliance == "" {
+			tmpl.ignored = true
+		}
+	},
+	// severity-weighted treats NonCompliant templates with a severity lower than the minimum severity as Compliant.
+	"severity-weighted": func(policy *policiesv1.Policy, tmpl *rollupTemplate) {
+		if tmpl.compliance != policiesv1.NonCompliant {
+			return
+		}
+
+		minSeverity := strings.ToLower(policy.GetAnnotations()[ComplianceRollupMinSeverityAnnotation])
+		if _, ok := severityRanks[minSeverity]; !ok {
+			minSeverity = "high"
+		}
+
+		// Templates without a known severity are always considered.
+		rank, ok := severityRanks[strings.ToLower(tmpl.severity)]
+		if ok && rank < severityRanks[minSeverity] {
+			tmpl.compliance = policiesv1.Compliant
+		}
+	},
+}
+
+// rollupCompliance combines the compliance of the policy templates in the policy status details into the policy
+// compliance. By default, any NonCompliant template makes the policy NonCompliant, then any Pending template makes
+// the policy Pending, then any template that has not reported or is stale makes the policy compliance
+// ComplianceUnknown. The policy is Compliant when all templates are Compliant, or when the policy has no templates. The
+// rules selected in the ComplianceRollupAnnotation annotation are applied to each template, except stale templates,
+// before the compliance is combined. Unsupported rules are logged and ignored.
+func rollupCompliance(policy *policiesv1.Policy, log logr.Logger) policiesv1.ComplianceState {
+	var rules []rollupRule
+
+	for _, ruleName := range strings.Split(policy.GetAnnotations()[ComplianceRollupAnnotation], ",") {
+		ruleName = strings.TrimSpace(ruleName)
+		if ruleName == "" || ruleName == "default" {
+			continue
+		}
+
+		rule, ok := rollupRules[ruleName]
+		if !ok {
+			log.Info("Ignoring the unsupported compliance rollup rule", "rule", ruleName)
+
+			continue
+		}
+
+		rules = append(rules, rule)
+	}
+
+	var hasPending, hasUnknown, hasCompliant bool
+
+	for i, dpt := range policy.Status.Details {
+		// A stale template keeps its last compliance state, which no longer applies. The rollup rules don't apply to
+		// it so that a policy engine that stopped reporting can't leave the policy Compliant.
+		if dpt.TemplateMeta.Annotations[ComplianceStaleAnnotation] == "true" {
+			hasUnknown = true
+
+			continue
+		}
+
+		tmpl := rollupTemplate{compliance: dpt.ComplianceState}
+
+		if len(rules) != 0 && i < len(policy.Spec.PolicyTemplates) {
+			tmpl.severity = templateSeverity(policy.Spec.PolicyTemplates[i])
+		}
+
+		for _, rule := range rules {
+			rule(policy, &tmpl)
+		}
+
+		if tmpl.ignored {
+			continue
+		}
+
+		switch tmpl.compliance {
+		case policiesv1.NonCompliant:
+			return policiesv1.NonCompliant
+		case policiesv1.Pending:
+			hasPending = true
+		case policiesv1.Compliant:
+			hasCompliant = true
+		default:
+			hasUnknown = true
+		}
+	}
+
+	switch {
+	case hasPending:
+		return policiesv1.Pending
+	case hasUnknown:
+		return ComplianceUnknown
+	case !hasCompliant && len(policy.Status.Details) != 0:
+		// All the templates were ignored by the rollup rules.
+		return ComplianceUnknown
+	default:
+		return policiesv1.Compliant
+	}
+}
+
+// statusComplianceState returns the compliance state to set in the policy status for the rolled up compliance. The
+// ComplianceUnknown outcome isn't in the enum of the Policy CRD, so it's an empty state, which removes the compliant
+// field from the status rather than keeping a previous state that no longer applies. The templates that make it
+// unknown have the ComplianceUnknownAnnotation set by markUnknownTemplates.
+func statusComplianceState(compliance policiesv1.ComplianceState) policiesv1.ComplianceState {
+	if compliance == ComplianceUnknown {
+		return ""
+	}
+
+	return compliance
+}
+
+// markUnknownTemplates sets the ComplianceUnknownAnnotation on the status details of the policy templates that
+// rollupCompliance treats as unknown, which are the templates that have never reported a compliance state and the
+// stale templates, and removes it from the others.
+func markUnknownTemplates(policy *policiesv1.Policy) {
+	for _, dpt := range policy.Status.Details {
+		if dpt == nil {
+			continue
+		}
+
+		reason := ""
+
+		switch {
+		case dpt.TemplateMeta.Annotations[ComplianceStaleAnnotation] == "true":
+			reason = "The policy template has not reported its compliance within the staleness threshold"
+		case dpt.ComplianceState == "":
+			reason = "The policy template has not reported a compliance state"
+		}
+
+		if reason != "" {
+			if dpt.TemplateMeta.Annotations == nil {
+				dpt.TemplateMeta.Annotations = map[string]string{}
+			}
+
+			dpt.TemplateMeta.Annotations[ComplianceUnknownAnnotation] = reason
+
+			continue
+		}
+
+		if _, ok := dpt.TemplateMeta.Annotations[ComplianceUnknownAnnotation]; ok {
+			delete(dpt.TemplateMeta.Annotations, ComplianceUnknownAnnotation)
+
+			if len(dpt.TemplateMeta.Annotations) == 0 {
+				dpt.TemplateMeta.Annotations = nil
+			}
+		}
+	}
+}
+
+// templateSeverity returns the spec.severity value of the policy template's object definition. An empty string is
+// returned if it's not set.
+func templateSeverity(policyT *policiesv1.PolicyTemplate) string {
+	if policyT == nil {
+		return ""
+	}
+
+	tmpl := struct {
+		Spec struct {
+			Severity string `json:"severity"`
+		} `json:"spec"`
+	}{}
+
+	if err := json.Unmarshal(policyT.ObjectDefinition.Raw, &tmpl); err != nil {
+		return ""
+	}
+
+	return tmpl.Spec.Severity
+}
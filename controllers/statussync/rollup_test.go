@@ -0,0 +1,204 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRollupCompliance(t *testing.T) {
+	t.Parallel()
+
+	const (
+		c  = policiesv1.Compliant
+		nc = policiesv1.NonCompliant
+		p  = policiesv1.Pending
+		// A template that never reported has an empty state.
+		u  policiesv1.ComplianceState = ""
+		uk                            = ComplianceUnknown
+	)
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		severities  []string
+		states      []policiesv1.ComplianceState
+		want        policiesv1.ComplianceState
+	}{
+		{name: "no templates", want: c},
+		{name: "all compliant", states: []policiesv1.ComplianceState{c, c}, want: c},
+		{name: "noncompliant wins", states: []policiesv1.ComplianceState{p, nc, u}, want: nc},
+		{name: "pending before unknown", states: []policiesv1.ComplianceState{c, u, p}, want: p},
+		{name: "never reported", states: []policiesv1.ComplianceState{c, u}, want: uk},
+		{
+			name:        "pending as compliant",
+			annotations: map[string]string{ComplianceRollupAnnotation: "pending-as-compliant"},
+			states:      []policiesv1.ComplianceState{c, p},
+			want:        c,
+		},
+		{
+			name:        "ignore unknown",
+			annotations: map[string]string{ComplianceRollupAnnotation: "ignore-unknown"},
+			states:      []policiesv1.ComplianceState{c, u},
+			want:        c,
+		},
+		{
+			name:        "ignore unknown with only unknown templates",
+			annotations: map[string]string{ComplianceRollupAnnotation: "ignore-unknown"},
+			states:      []policiesv1.ComplianceState{u, u},
+			want:        uk,
+		},
+		{
+			name:        "combined rules",
+			annotations: map[string]string{ComplianceRollupAnnotation: "pending-as-compliant, ignore-unknown"},
+			states:      []policiesv1.ComplianceState{p, u, c},
+			want:        c,
+		},
+		{
+			name:        "severity weighted with the default minimum",
+			annotations: map[string]string{ComplianceRollupAnnotation: "severity-weighted"},
+			severities:  []string{"low", "medium"},
+			states:      []policiesv1.ComplianceState{nc, nc},
+			want:        c,
+		},
+		{
+			name:        "severity weighted with a high severity violation",
+			annotations: map[string]string{ComplianceRollupAnnotation: "severity-weighted"},
+			severities:  []string{"low", "critical"},
+			states:      []policiesv1.ComplianceState{c, nc},
+			want:        nc,
+		},
+		{
+			name: "severity weighted with a custom minimum",
+			annotations: map[string]string{
+				ComplianceRollupAnnotation:            "severity-weighted",
+				ComplianceRollupMinSeverityAnnotation: "medium",
+			},
+			severities: []string{"medium", ""},
+			states:     []policiesv1.ComplianceState{nc, c},
+			want:       nc,
+		},
+		{
+			name:        "unsupported rule",
+			annotations: map[string]string{ComplianceRollupAnnotation: "does-not-exist"},
+			states:      []policiesv1.ComplianceState{c, p},
+			want:        p,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policiesv1.Policy{
+				ObjectMeta: metav1.ObjectMeta{Name: "policy-a", Annotations: tt.annotations},
+			}
+
+			for i, state := range tt.states {
+				severity := ""
+				if i < len(tt.severities) {
+					severity = tt.severities[i]
+				}
+
+				policy.Spec.PolicyTemplates = append(policy.Spec.PolicyTemplates, &policiesv1.PolicyTemplate{
+					ObjectDefinition: runtime.RawExtension{
+						Raw: []byte(`{"kind":"ConfigurationPolicy","spec":{"severity":"` + severity + `"}}`),
+					},
+				})
+				policy.Status.Details = append(policy.Status.Details, &policiesv1.DetailsPerTemplate{
+					ComplianceState: state,
+				})
+			}
+
+			if got := rollupCompliance(policy, logr.Discard()); got != tt.want {
+				t.Errorf("rollupCompliance() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusComplianceState(t *testing.T) {
+	t.Parallel()
+
+	// The Policy CRD enum has no Unknown value, so the compliant field is removed instead.
+	if got := statusComplianceState(ComplianceUnknown); got != "" {
+		t.Errorf("statusComplianceState(ComplianceUnknown) = %q, want an empty state", got)
+	}
+
+	if got := statusComplianceState(policiesv1.Pending); got != policiesv1.Pending {
+		t.Errorf("statusComplianceState(Pending) = %q, want Pending", got)
+	}
+}
+
+func TestUpdateStatusesUnknownCompliance(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	managedPolicy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-a", Namespace: "managed"},
+		Status:     policiesv1.PolicyStatus{ComplianceState: policiesv1.Compliant},
+	}
+	hubPolicy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-a", Namespace: "managed-hub"},
+		Status:     policiesv1.PolicyStatus{ComplianceState: policiesv1.Compliant},
+	}
+	hubRecorder := events.NewFakeRecorder(10)
+
+	r := &PolicyReconciler{
+		ManagedClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(managedPolicy).
+			WithStatusSubresource(managedPolicy).Build(),
+		HubClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(hubPolicy).
+			WithStatusSubresource(hubPolicy).Build(),
+		ManagedRecorder:       events.NewFakeRecorder(10),
+		HubRecorder:           hubRecorder,
+		ClusterNamespaceOnHub: "managed-hub",
+	}
+
+	instance := managedPolicy.DeepCopy()
+	oldStatus := *instance.Status.DeepCopy()
+	instance.Status.Details = []*policiesv1.DetailsPerTemplate{
+		{TemplateMeta: metav1.ObjectMeta{Name: "reported"}, ComplianceState: policiesv1.Compliant},
+		{TemplateMeta: metav1.ObjectMeta{Name: "never-reported"}},
+	}
+
+	if _, err := r.updateStatuses(context.TODO(), instance, hubPolicy.DeepCopy(), oldStatus); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	hubStatus := &policiesv1.Policy{}
+	if err := r.HubClient.Get(context.TODO(), client.ObjectKeyFromObject(hubPolicy), hubStatus); err != nil {
+		t.Fatalf("Failed to get the hub policy: %v", err)
+	}
+
+	// The unknown compliance removes the compliant field, and the template that makes it unknown says why.
+	if hubStatus.Status.ComplianceState != "" || len(hubStatus.Status.Details) != 2 {
+		t.Fatalf("Expected an unknown compliance on the hub but got: %v", hubStatus.Status)
+	}
+
+	if _, ok := hubStatus.Status.Details[0].TemplateMeta.Annotations[ComplianceUnknownAnnotation]; ok {
+		t.Fatal("Expected the reported template to not be marked as unknown")
+	}
+
+	if hubStatus.Status.Details[1].TemplateMeta.Annotations[ComplianceUnknownAnnotation] == "" {
+		t.Fatalf("Expected the never reported template to be marked as unknown but got: %v",
+			hubStatus.Status.Details[1].TemplateMeta)
+	}
+
+	if evt := <-hubRecorder.Events; !strings.Contains(evt, "status was updated to Unknown") {
+		t.Fatalf("Expected the hub event to report the unknown compliance but got: %s", evt)
+	}
+}
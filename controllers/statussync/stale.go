@@ -0,0 +1,125 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// ComplianceStaleAnnotation is set to "true" in the templateMeta of a policy template's status details when the most
+// recent compliance history entry is older than the staleness threshold for the template kind.
+const ComplianceStaleAnnotation = common.APIGroup + "/compliance-stale"
+
+// markStaleTemplates marks policy templates as stale with the ComplianceStaleAnnotation when their most recent
+// compliance history entry is older than the staleness threshold for their kind. A stale template keeps its last
+// compliance state, since the Policy CRD has no state for it, but rollupCompliance treats it as unknown. This prevents
+// a policy engine that stopped reporting from leaving a policy Compliant. A warning event is emitted when a template
+// becomes stale. The returned duration is how long until the next template would become stale, or 0 if no templates
+// need to be checked again.
+func (r *PolicyReconciler) markStaleTemplates(
+	ctx context.Context, instance *policiesv1.Policy, oldStatus policiesv1.PolicyStatus, now time.Time,
+) (requeueAfter time.Duration) {
+	if len(r.StaleThresholds) == 0 {
+		return 0
+	}
+
+	reqLogger := ctrl.LoggerFrom(ctx)
+
+	for i, dpt := range instance.Status.Details {
+		if i >= len(instance.Spec.PolicyTemplates) {
+			break
+		}
+
+		threshold, ok := r.StaleThresholds[templateGroupKind(instance.Spec.PolicyTemplates[i])]
+		if !ok || len(dpt.History) == 0 {
+			setTemplateStale(instance, dpt, false)
+
+			continue
+		}
+
+		age := now.Sub(dpt.History[0].LastTimestamp.Time)
+		if age < threshold {
+			setTemplateStale(instance, dpt, false)
+
+			if untilStale := threshold - age; requeueAfter == 0 || untilStale < requeueAfter {
+				requeueAfter = untilStale
+			}
+
+			continue
+		}
+
+		setTemplateStale(instance, dpt, true)
+
+		if wasTemplateStale(oldStatus, dpt.TemplateMeta.Name) {
+			continue
+		}
+
+		reqLogger.Info("The policy template compliance is stale", "TemplateName", dpt.TemplateMeta.Name,
+			"lastTimestamp", dpt.History[0].LastTimestamp, "threshold", threshold)
+
+		r.ManagedRecorder.Eventf(instance, nil, corev1.EventTypeWarning, "PolicyStatusSync", "ComplianceStale",
+			fmt.Sprintf("Policy template %s has not reported compliance since %s, which is longer than %s ago. "+
+				"The policy compliance is unknown until it reports again.", dpt.TemplateMeta.Name,
+				dpt.History[0].LastTimestamp.UTC().Format(time.RFC3339), threshold))
+	}
+
+	return requeueAfter
+}
+
+// setTemplateStale sets or removes the stale marker and metric on the policy template's status details. The compliance
+// state of the template is left as is.
+func setTemplateStale(instance *policiesv1.Policy, dpt *policiesv1.DetailsPerTemplate, stale bool) {
+	if !stale {
+		if _, ok := dpt.TemplateMeta.Annotations[ComplianceStaleAnnotation]; ok {
+			delete(dpt.TemplateMeta.Annotations, ComplianceStaleAnnotation)
+
+			if len(dpt.TemplateMeta.Annotations) == 0 {
+				dpt.TemplateMeta.Annotations = nil
+			}
+		}
+
+		policyTemplateStaleGauge.WithLabelValues(instance.Namespace, instance.Name, dpt.TemplateMeta.Name).Set(0)
+
+		return
+	}
+
+	if dpt.TemplateMeta.Annotations == nil {
+		dpt.TemplateMeta.Annotations = map[string]string{}
+	}
+
+	dpt.TemplateMeta.Annotations[ComplianceStaleAnnotation] = "true"
+
+	policyTemplateStaleGauge.WithLabelValues(instance.Namespace, instance.Name, dpt.TemplateMeta.Name).Set(1)
+}
+
+// wasTemplateStale returns whether the policy template was marked as stale in the input status.
+func wasTemplateStale(status policiesv1.PolicyStatus, tName string) bool {
+	for _, dpt := range status.Details {
+		if dpt != nil && dpt.TemplateMeta.Name == tName {
+			return dpt.TemplateMeta.Annotations[ComplianceStaleAnnotation] == "true"
+		}
+	}
+
+	return false
+}
+
+// templateGroupKind returns the GroupKind of the policy template's object definition. An empty GroupKind is returned
+// if the object definition is invalid.
+func templateGroupKind(policyT *policiesv1.PolicyTemplate) schema.GroupKind {
+	tmpl := utils.TemplateObject(policyT)
+	if tmpl == nil {
+		return schema.GroupKind{}
+	}
+
+	return tmpl.GroupVersionKind().GroupKind()
+}
@@ -0,0 +1,107 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/events"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func TestMarkStaleTemplates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	configPolicyGK := schema.GroupKind{Group: "policy.open-cluster-management.io", Kind: "ConfigurationPolicy"}
+
+	makeTemplate := func(kind string) *policiesv1.PolicyTemplate {
+		return &policiesv1.PolicyTemplate{
+			ObjectDefinition: runtime.RawExtension{
+				Raw: []byte(`{"apiVersion":"policy.open-cluster-management.io/v1","kind":"` + kind + `"}`),
+			},
+		}
+	}
+
+	makeDetails := func(name string, age time.Duration) *policiesv1.DetailsPerTemplate {
+		return &policiesv1.DetailsPerTemplate{
+			TemplateMeta:    metav1.ObjectMeta{Name: name},
+			ComplianceState: policiesv1.Compliant,
+			History: []policiesv1.ComplianceHistory{{
+				LastTimestamp: metav1.NewTime(now.Add(-age)),
+				Message:       "Compliant; notification - no violations",
+			}},
+		}
+	}
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-stale", Namespace: "managed"},
+		Spec: policiesv1.PolicySpec{
+			PolicyTemplates: []*policiesv1.PolicyTemplate{
+				makeTemplate("ConfigurationPolicy"),
+				makeTemplate("ConfigurationPolicy"),
+				makeTemplate("CertificatePolicy"),
+			},
+		},
+		Status: policiesv1.PolicyStatus{
+			Details: []*policiesv1.DetailsPerTemplate{
+				makeDetails("config-stale", 2*time.Hour),
+				makeDetails("config-fresh", 20*time.Minute),
+				makeDetails("cert-old", 48*time.Hour),
+			},
+		},
+	}
+
+	recorder := events.NewFakeRecorder(10)
+	r := PolicyReconciler{
+		ManagedRecorder: recorder,
+		StaleThresholds: map[schema.GroupKind]time.Duration{configPolicyGK: time.Hour},
+	}
+
+	requeueAfter := r.markStaleTemplates(t.Context(), policy, policiesv1.PolicyStatus{}, now)
+
+	if requeueAfter != 40*time.Minute {
+		t.Errorf("markStaleTemplates() requeueAfter = %s, want 40m", requeueAfter)
+	}
+
+	// The stale template keeps its last compliance state since the Policy CRD has no state for it.
+	stale := policy.Status.Details[0]
+	if stale.ComplianceState != policiesv1.Compliant ||
+		stale.TemplateMeta.Annotations[ComplianceStaleAnnotation] != "true" {
+		t.Errorf("Expected config-stale to be marked as stale but got: %+v", stale)
+	}
+
+	if got := rollupCompliance(policy, logr.Discard()); got != ComplianceUnknown {
+		t.Errorf("Expected the policy with a stale template to have an unknown compliance but got %q", got)
+	}
+
+	for _, dpt := range policy.Status.Details[1:] {
+		if dpt.ComplianceState != policiesv1.Compliant || dpt.TemplateMeta.Annotations != nil {
+			t.Errorf("Expected %s to not be marked as stale but got: %+v", dpt.TemplateMeta.Name, dpt)
+		}
+	}
+
+	// The stale gauge is per policy namespace since a hub source can have a policy of the same name.
+	gauge := policyTemplateStaleGauge.WithLabelValues("managed", "policy-stale", "config-stale")
+	if got := testutil.ToFloat64(gauge); got != 1 {
+		t.Errorf("Expected the stale gauge of config-stale to be 1 but got %v", got)
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf("Expected 1 stale warning event but got %d", len(recorder.Events))
+	}
+
+	// A template that was already stale doesn't emit another event.
+	oldStatus := *policy.Status.DeepCopy()
+	_ = r.markStaleTemplates(t.Context(), policy, oldStatus, now)
+
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected no additional stale warning event but got %d events", len(recorder.Events))
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+// The annotations set in the templateMeta of the status details to describe the applied template object. The
+// namespace, UID, resourceVersion, and generation of the object are set in the templateMeta fields directly.
+const (
+	TemplateAPIVersionAnnotation = common.APIGroup + "/template-api-version"
+	TemplateKindAnnotation       = common.APIGroup + "/template-kind"
+	// AppliedPolicyGenerationAnnotation is the generation of the managed policy that the template object reflects.
+	AppliedPolicyGenerationAnnotation = common.APIGroup + "/applied-policy-generation"
+	// AppliedHubPolicyGenerationAnnotation is the generation of the hub policy that the template object reflects.
+	AppliedHubPolicyGenerationAnnotation = common.APIGroup + "/applied-hub-policy-generation"
+)
+
+// latestEventName returns the name of the most recent compliance event in the existing status details of the
+// template, or an empty string if there are none.
+func latestEventName(existingDPTs []*policiesv1.DetailsPerTemplate, tName string) string {
+	for _, dpt := range existingDPTs {
+		if dpt != nil && dpt.TemplateMeta.Name == tName && len(dpt.History) > 0 {
+			return dpt.History[0].EventName
+		}
+	}
+
+	return ""
+}
+
+// setAppliedObjectMeta sets the metadata of the applied template object in the templateMeta of the status details.
+// The resourceVersion changes on every status update of the template, so it's only refreshed when the compliance
+// changed or the object was recreated or its spec changed. This way, it's the resourceVersion of the object when its
+// current compliance was reported, and template status updates that don't affect compliance don't cause policy
+// status updates. A nil obj clears the metadata since the template object is not applied.
+func setAppliedObjectMeta(
+	dpt *policiesv1.DetailsPerTemplate, obj *unstructured.Unstructured, prevLatestEvent string,
+) {
+	if obj == nil {
+		dpt.TemplateMeta.Namespace = ""
+		dpt.TemplateMeta.UID = ""
+		dpt.TemplateMeta.ResourceVersion = ""
+		dpt.TemplateMeta.Generation = 0
+
+		for _, annotation := range []string{
+			TemplateAPIVersionAnnotation,
+			TemplateKindAnnotation,
+			AppliedPolicyGenerationAnnotation,
+			AppliedHubPolicyGenerationAnnotation,
+		} {
+			delete(dpt.TemplateMeta.Annotations, annotation)
+		}
+
+		if len(dpt.TemplateMeta.Annotations) == 0 {
+			dpt.TemplateMeta.Annotations = nil
+		}
+
+		return
+	}
+
+	latestEvent := ""
+	if len(dpt.History) > 0 {
+		latestEvent = dpt.History[0].EventName
+	}
+
+	refreshResourceVersion := dpt.TemplateMeta.ResourceVersion == "" ||
+		dpt.TemplateMeta.UID != obj.GetUID() ||
+		dpt.TemplateMeta.Generation != obj.GetGeneration() ||
+		latestEvent != prevLatestEvent
+
+	dpt.TemplateMeta.Namespace = obj.GetNamespace()
+	dpt.TemplateMeta.UID = obj.GetUID()
+	dpt.TemplateMeta.Generation = obj.GetGeneration()
+
+	if refreshResourceVersion {
+		dpt.TemplateMeta.ResourceVersion = obj.GetResourceVersion()
+	}
+
+	if dpt.TemplateMeta.Annotations == nil {
+		dpt.TemplateMeta.Annotations = map[string]string{}
+	}
+
+	dpt.TemplateMeta.Annotations[TemplateAPIVersionAnnotation] = obj.GetAPIVersion()
+	dpt.TemplateMeta.Annotations[TemplateKindAnnotation] = obj.GetKind()
+}
+
+// setAppliedPolicyGenerations records the managed and hub policy generations in the templateMeta of the applied
+// templates when the TemplatesAppliedGenerationAnnotation set by template-sync reports that it applied all of the
+// templates of the current policy generation. Otherwise, the previously recorded generations are kept, so a generation
+// lower than the policy's means the compliance reflects a previous policy spec. Both the annotation and the recorded
+// generations are stored on the policy, so they survive a restart of the addon.
+func (r *PolicyReconciler) setAppliedPolicyGenerations(instance, hubInstance *policiesv1.Policy) {
+	generation := strconv.FormatInt(instance.Generation, 10)
+	if instance.GetAnnotations()[utils.TemplatesAppliedGenerationAnnotation] != generation {
+		return
+	}
+
+	for _, dpt := range instance.Status.Details {
+		if dpt == nil || dpt.TemplateMeta.UID == "" {
+			continue
+		}
+
+		dpt.TemplateMeta.Annotations[AppliedPolicyGenerationAnnotation] = generation
+
+		if hubInstance != nil {
+			dpt.TemplateMeta.Annotations[AppliedHubPolicyGenerationAnnotation] = strconv.FormatInt(
+				hubInstance.Generation, 10,
+			)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package statussync
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+func TestSetAppliedObjectMeta(t *testing.T) {
+	t.Parallel()
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("policy.open-cluster-management.io/v1")
+	obj.SetKind("ConfigurationPolicy")
+	obj.SetNamespace("managed")
+	obj.SetName("config-a")
+	obj.SetUID("1234")
+	obj.SetResourceVersion("10")
+	obj.SetGeneration(2)
+
+	dpt := &policiesv1.DetailsPerTemplate{
+		TemplateMeta: metav1.ObjectMeta{Name: "config-a"},
+		History:      []policiesv1.ComplianceHistory{{EventName: "policy-a.1"}},
+	}
+
+	setAppliedObjectMeta(dpt, obj, "")
+
+	if dpt.TemplateMeta.UID != "1234" || dpt.TemplateMeta.ResourceVersion != "10" ||
+		dpt.TemplateMeta.Generation != 2 || dpt.TemplateMeta.Namespace != "managed" {
+		t.Fatalf("Unexpected templateMeta: %v", dpt.TemplateMeta)
+	}
+
+	if dpt.TemplateMeta.Annotations[TemplateKindAnnotation] != "ConfigurationPolicy" ||
+		dpt.TemplateMeta.Annotations[TemplateAPIVersionAnnotation] != "policy.open-cluster-management.io/v1" {
+		t.Fatalf("Unexpected templateMeta annotations: %v", dpt.TemplateMeta.Annotations)
+	}
+
+	// A status update of the template without a compliance change keeps the resourceVersion.
+	obj.SetResourceVersion("11")
+	setAppliedObjectMeta(dpt, obj, "policy-a.1")
+
+	if dpt.TemplateMeta.ResourceVersion != "10" {
+		t.Fatalf("Expected the resourceVersion to be kept but got %s", dpt.TemplateMeta.ResourceVersion)
+	}
+
+	// A new compliance event refreshes the resourceVersion.
+	dpt.History = append([]policiesv1.ComplianceHistory{{EventName: "policy-a.2"}}, dpt.History...)
+	setAppliedObjectMeta(dpt, obj, "policy-a.1")
+
+	if dpt.TemplateMeta.ResourceVersion != "11" {
+		t.Fatalf("Expected the resourceVersion to be refreshed but got %s", dpt.TemplateMeta.ResourceVersion)
+	}
+
+	setAppliedObjectMeta(dpt, nil, "policy-a.2")
+
+	if dpt.TemplateMeta.UID != "" || dpt.TemplateMeta.Annotations != nil || dpt.TemplateMeta.Name != "config-a" {
+		t.Fatalf("Expected the applied object metadata to be cleared but got: %v", dpt.TemplateMeta)
+	}
+}
+
+func TestSetAppliedPolicyGenerations(t *testing.T) {
+	t.Parallel()
+
+	// A new reconciler, such as after a restart of the addon, only has the data stored on the policy.
+	r := &PolicyReconciler{}
+
+	policy := &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "policy-a",
+			Namespace:  "managed",
+			Generation: 3,
+			Annotations: map[string]string{
+				utils.TemplatesAppliedGenerationAnnotation: "3",
+			},
+		},
+		Status: policiesv1.PolicyStatus{
+			Details: []*policiesv1.DetailsPerTemplate{
+				{TemplateMeta: metav1.ObjectMeta{
+					Name: "config-a", UID: "1234", Annotations: map[string]string{TemplateKindAnnotation: "Kind"},
+				}},
+				{TemplateMeta: metav1.ObjectMeta{Name: "config-b"}},
+			},
+		},
+	}
+	hubPolicy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy-a", Generation: 7}}
+
+	r.setAppliedPolicyGenerations(policy, hubPolicy)
+
+	applied := policy.Status.Details[0].TemplateMeta.Annotations
+	if applied[AppliedPolicyGenerationAnnotation] != "3" || applied[AppliedHubPolicyGenerationAnnotation] != "7" {
+		t.Fatalf("Expected the applied generations to be recorded but got: %v", applied)
+	}
+
+	if policy.Status.Details[1].TemplateMeta.Annotations != nil {
+		t.Fatalf("Expected no applied generations on a template that isn't applied")
+	}
+
+	// The policy changed and template-sync hasn't applied it yet, so the recorded generations are kept.
+	policy.Generation = 4
+	hubPolicy.Generation = 8
+
+	r.setAppliedPolicyGenerations(policy, hubPolicy)
+
+	if applied[AppliedPolicyGenerationAnnotation] != "3" || applied[AppliedHubPolicyGenerationAnnotation] != "7" {
+		t.Fatalf("Expected the previously applied generations to be kept but got: %v", applied)
+	}
+}
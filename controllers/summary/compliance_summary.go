@@ -0,0 +1,260 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+const (
+	ControllerName = "policy-compliance-summary"
+	// ConfigMapName is the name of the ConfigMap in the cluster namespace that holds the compliance summary.
+	ConfigMapName = "governance-policy-compliance-summary"
+	// SummaryKey is the ConfigMap data key that holds the JSON compliance summary.
+	SummaryKey = "summary.json"
+	// LastUpdateTimeKey is the ConfigMap data key that holds the RFC 3339 time the summary last changed.
+	LastUpdateTimeKey = "lastUpdateTime"
+)
+
+// ComplianceCounts is the number of policies or policy templates in each compliance state. Policies and policy
+// templates without a compliance state are counted as unknown.
+type ComplianceCounts struct {
+	Compliant    int `json:"compliant"`
+	NonCompliant int `json:"noncompliant"`
+	Pending      int `json:"pending"`
+	Unknown      int `json:"unknown"`
+}
+
+func (c *ComplianceCounts) add(state policiesv1.ComplianceState) {
+	switch state {
+	case policiesv1.Compliant:
+		c.Compliant++
+	case policiesv1.NonCompliant:
+		c.NonCompliant++
+	case policiesv1.Pending:
+		c.Pending++
+	default:
+		c.Unknown++
+	}
+}
+
+// PolicySummary is the compliance of a single policy and the compliance counts of its policy templates.
+type PolicySummary struct {
+	ComplianceState policiesv1.ComplianceState `json:"compliant,omitempty"`
+	Templates       ComplianceCounts           `json:"templates"`
+}
+
+// Summary aggregates the compliance of the policies in the cluster namespace. Policy templates are counted per kind,
+// and policies are counted per value of the standards and categories annotations.
+type Summary struct {
+	Policies        ComplianceCounts            `json:"policies"`
+	PerPolicy       map[string]PolicySummary    `json:"perPolicy"`
+	PerTemplateKind map[string]ComplianceCounts `json:"perTemplateKind"`
+	PerStandard     map[string]ComplianceCounts `json:"perStandard"`
+	PerCategory     map[string]ComplianceCounts `json:"perCategory"`
+}
+
+// SetupWithManager sets up the controller with the Manager. The Policy events of a cluster namespace are mapped to a
+// single request for the summary ConfigMap in that namespace, so bursts of compliance updates are coalesced by the work
+// queue.
+func (r *ComplianceSummaryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	summaryRequest := func(_ context.Context, obj client.Object) []reconcile.Request {
+		if !slices.Contains(r.ClusterNamespaces, obj.GetNamespace()) {
+			return nil
+		}
+
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{
+			Namespace: obj.GetNamespace(),
+			Name:      ConfigMapName,
+		}}}
+	}
+
+	isSummary := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == ConfigMapName
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		WithLogConstructor(func(req *reconcile.Request) logr.Logger {
+			return utils.LogConstructor(ControllerName, "ConfigMap", req)
+		}).
+		Watches(&policiesv1.Policy{}, handler.EnqueueRequestsFromMapFunc(summaryRequest)).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(summaryRequest),
+			builder.WithPredicates(isSummary),
+		).
+		Complete(r)
+}
+
+// blank assignment to verify that ComplianceSummaryReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ComplianceSummaryReconciler{}
+
+// ComplianceSummaryReconciler maintains a ConfigMap in each cluster namespace that summarizes the compliance of the
+// policies in that namespace.
+type ComplianceSummaryReconciler struct {
+	client.Client
+	// ClusterNamespaces are the namespaces of the replicated policies of the hub and of the hub sources.
+	ClusterNamespaces []string
+}
+
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=create
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,resourceNames=governance-policy-compliance-summary,verbs=update
+
+// Reconcile computes the compliance summary from the Policies in the cluster namespace of the request and creates or
+// updates the summary ConfigMap when the summary changed.
+func (r *ComplianceSummaryReconciler) Reconcile(
+	ctx context.Context, request reconcile.Request,
+) (reconcile.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	policies := &policiesv1.PolicyList{}
+
+	if err := r.List(ctx, policies, client.InNamespace(request.Namespace)); err != nil {
+		log.Error(err, "Failed to list the policies")
+
+		return reconcile.Result{}, err
+	}
+
+	summaryJSON, err := json.Marshal(computeSummary(policies.Items))
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	configMap := &corev1.ConfigMap{}
+
+	err = r.Get(ctx, types.NamespacedName{Namespace: request.Namespace, Name: ConfigMapName}, configMap)
+	if k8serrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ConfigMapName,
+				Namespace: request.Namespace,
+			},
+			Data: map[string]string{SummaryKey: string(summaryJSON), LastUpdateTimeKey: now},
+		}
+
+		log.Info("Creating the compliance summary ConfigMap")
+
+		if err := r.Create(ctx, configMap); err != nil {
+			log.Error(err, "Failed to create the compliance summary ConfigMap")
+
+			return reconcile.Result{}, err
+		}
+
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get the compliance summary ConfigMap")
+
+		return reconcile.Result{}, err
+	}
+
+	if configMap.Data[SummaryKey] == string(summaryJSON) && configMap.Data[LastUpdateTimeKey] != "" {
+		return reconcile.Result{}, nil
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+
+	configMap.Data[SummaryKey] = string(summaryJSON)
+	configMap.Data[LastUpdateTimeKey] = now
+
+	log.V(1).Info("Updating the compliance summary ConfigMap")
+
+	if err := r.Update(ctx, configMap); err != nil {
+		log.Error(err, "Failed to update the compliance summary ConfigMap")
+
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// computeSummary aggregates the compliance of the input policies. Policies being deleted are skipped.
+func computeSummary(policies []policiesv1.Policy) *Summary {
+	summary := &Summary{
+		PerPolicy:       map[string]PolicySummary{},
+		PerTemplateKind: map[string]ComplianceCounts{},
+		PerStandard:     map[string]ComplianceCounts{},
+		PerCategory:     map[string]ComplianceCounts{},
+	}
+
+	for i := range policies {
+		policy := &policies[i]
+
+		if policy.DeletionTimestamp != nil {
+			continue
+		}
+
+		policySummary := PolicySummary{ComplianceState: policy.Status.ComplianceState}
+
+		for _, policyT := range policy.Spec.PolicyTemplates {
+			tmpl := utils.TemplateObject(policyT)
+			if tmpl == nil {
+				continue
+			}
+
+			state := templateCompliance(policy, tmpl.GetName())
+			policySummary.Templates.add(state)
+
+			kind := tmpl.GroupVersionKind().GroupKind().String()
+			counts := summary.PerTemplateKind[kind]
+			counts.add(state)
+			summary.PerTemplateKind[kind] = counts
+		}
+
+		summary.PerPolicy[policy.Name] = policySummary
+		summary.Policies.add(policy.Status.ComplianceState)
+
+		annotations := policy.GetAnnotations()
+
+		addPerValue(summary.PerStandard, annotations[common.APIGroup+"/standards"], policy.Status.ComplianceState)
+		addPerValue(summary.PerCategory, annotations[common.APIGroup+"/categories"], policy.Status.ComplianceState)
+	}
+
+	return summary
+}
+
+// templateCompliance returns the compliance state of the named policy template in the policy status.
+func templateCompliance(policy *policiesv1.Policy, tName string) policiesv1.ComplianceState {
+	for _, dpt := range policy.Status.Details {
+		if dpt != nil && dpt.TemplateMeta.Name == tName {
+			return dpt.ComplianceState
+		}
+	}
+
+	return ""
+}
+
+// addPerValue counts the compliance state once for each value of the comma-separated annotation value.
+func addPerValue(perValue map[string]ComplianceCounts, annotation string, state policiesv1.ComplianceState) {
+	for _, value := range utils.SplitAnnotation(annotation) {
+		counts := perValue[value]
+		counts.add(state)
+		perValue[value] = counts
+	}
+}
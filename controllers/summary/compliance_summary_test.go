@@ -0,0 +1,184 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package summary
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	testutils "open-cluster-management.io/governance-policy-framework-addon/test/utils"
+)
+
+const clusterName = "managed"
+
+// standardsMeta returns the metadata of a policy in the cluster namespace with the standards annotation.
+func standardsMeta(name string, standards string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   clusterName,
+		Annotations: map[string]string{"policy.open-cluster-management.io/standards": standards},
+	}
+}
+
+func TestComputeSummary(t *testing.T) {
+	t.Parallel()
+
+	policies := []policiesv1.Policy{
+		testutils.NewPolicy(standardsMeta("p1", "NIST SP 800-53, PCI"), policiesv1.NonCompliant,
+			testutils.PolicyTemplate{Name: "cfg1", ComplianceState: policiesv1.Compliant},
+			testutils.PolicyTemplate{
+				APIVersion:      "constraints.gatekeeper.sh/v1beta1",
+				Kind:            "K8sRequiredLabels",
+				Name:            "gk",
+				ComplianceState: policiesv1.NonCompliant,
+			},
+		),
+		testutils.NewPolicy(standardsMeta("p2", "NIST SP 800-53"), policiesv1.Pending,
+			testutils.PolicyTemplate{Name: "cfg2", ComplianceState: policiesv1.Pending},
+			testutils.PolicyTemplate{Name: "cfg3"},
+		),
+	}
+
+	deleting := testutils.NewPolicy(standardsMeta("p3", "PCI"), policiesv1.Compliant)
+	deleting.DeletionTimestamp = &metav1.Time{}
+	policies = append(policies, deleting)
+
+	summary := computeSummary(policies)
+
+	if summary.Policies != (ComplianceCounts{NonCompliant: 1, Pending: 1}) {
+		t.Fatalf("unexpected policy counts: %+v", summary.Policies)
+	}
+
+	if got := summary.PerPolicy["p2"]; got.ComplianceState != policiesv1.Pending ||
+		got.Templates != (ComplianceCounts{Pending: 1, Unknown: 1}) {
+		t.Fatalf("unexpected summary of p2: %+v", got)
+	}
+
+	cfgKind := "ConfigurationPolicy.policy.open-cluster-management.io"
+	if got := summary.PerTemplateKind[cfgKind]; got != (ComplianceCounts{Compliant: 1, Pending: 1, Unknown: 1}) {
+		t.Fatalf("unexpected %s counts: %+v", cfgKind, got)
+	}
+
+	gkKind := "K8sRequiredLabels.constraints.gatekeeper.sh"
+	if got := summary.PerTemplateKind[gkKind]; got != (ComplianceCounts{NonCompliant: 1}) {
+		t.Fatalf("unexpected %s counts: %+v", gkKind, got)
+	}
+
+	if got := summary.PerStandard["NIST SP 800-53"]; got != (ComplianceCounts{NonCompliant: 1, Pending: 1}) {
+		t.Fatalf("unexpected NIST SP 800-53 counts: %+v", got)
+	}
+
+	if got := summary.PerStandard["PCI"]; got != (ComplianceCounts{NonCompliant: 1}) {
+		t.Fatalf("unexpected PCI counts: %+v", got)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := testutils.NewPolicy(standardsMeta("p1", ""), policiesv1.Compliant,
+		testutils.PolicyTemplate{Name: "cfg1", ComplianceState: policiesv1.Compliant},
+	)
+
+	// A policy of a hub source is in its own cluster namespace, which has its own summary.
+	sourcePolicy := testutils.NewPolicy(standardsMeta("p2", ""), policiesv1.NonCompliant)
+	sourcePolicy.Namespace = "managed-hub2"
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&policy, &sourcePolicy).Build()
+	r := &ComplianceSummaryReconciler{Client: fakeClient, ClusterNamespaces: []string{clusterName, "managed-hub2"}}
+	key := types.NamespacedName{Namespace: clusterName, Name: ConfigMapName}
+
+	if _, err := r.Reconcile(t.Context(), reconcile.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := fakeClient.Get(t.Context(), key, configMap); err != nil {
+		t.Fatal(err)
+	}
+
+	if configMap.Data[LastUpdateTimeKey] == "" {
+		t.Fatal("expected the last update time to be set")
+	}
+
+	summary := &Summary{}
+	if err := json.Unmarshal([]byte(configMap.Data[SummaryKey]), summary); err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Policies != (ComplianceCounts{Compliant: 1}) {
+		t.Fatalf("unexpected policy counts: %+v", summary.Policies)
+	}
+
+	// An unchanged summary doesn't update the ConfigMap.
+	if _, err := r.Reconcile(t.Context(), reconcile.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	unchanged := &corev1.ConfigMap{}
+	if err := fakeClient.Get(t.Context(), key, unchanged); err != nil {
+		t.Fatal(err)
+	}
+
+	if unchanged.ResourceVersion != configMap.ResourceVersion {
+		t.Fatal("expected the ConfigMap not to be updated")
+	}
+
+	policy.Status.ComplianceState = policiesv1.NonCompliant
+	if err := fakeClient.Update(t.Context(), &policy); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(t.Context(), reconcile.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fakeClient.Get(t.Context(), key, configMap); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(configMap.Data[SummaryKey]), summary); err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Policies != (ComplianceCounts{NonCompliant: 1}) {
+		t.Fatalf("unexpected policy counts after the update: %+v", summary.Policies)
+	}
+
+	sourceKey := types.NamespacedName{Namespace: "managed-hub2", Name: ConfigMapName}
+
+	if _, err := r.Reconcile(t.Context(), reconcile.Request{NamespacedName: sourceKey}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fakeClient.Get(t.Context(), sourceKey, configMap); err != nil {
+		t.Fatal(err)
+	}
+
+	sourceSummary := &Summary{}
+	if err := json.Unmarshal([]byte(configMap.Data[SummaryKey]), sourceSummary); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := sourceSummary.PerPolicy["p2"]; !ok || len(sourceSummary.PerPolicy) != 1 {
+		t.Fatalf("expected the summary of the hub source namespace to only have its policy: %+v", sourceSummary)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package templatesync
+
+import (
+	"context"
+	"strconv"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+)
+
+type templateResultsKey struct{}
+
+// templateResults collects the names of the policy templates that failed or are pending during a single reconcile.
+// It's stored in the reconcile context so that emitTemplateError and emitTemplatePending can record the results
+// without changing all of their call sites.
+type templateResults struct {
+	errors  []string
+	pending []string
+}
+
+func withTemplateResults(ctx context.Context) (context.Context, *templateResults) {
+	results := &templateResults{}
+
+	return context.WithValue(ctx, templateResultsKey{}, results), results
+}
+
+func recordTemplateError(ctx context.Context, tName string) {
+	if results, ok := ctx.Value(templateResultsKey{}).(*templateResults); ok {
+		results.errors = append(results.errors, tName)
+	}
+}
+
+func recordTemplatePending(ctx context.Context, tName string) {
+	if results, ok := ctx.Value(templateResultsKey{}).(*templateResults); ok {
+		results.pending = append(results.pending, tName)
+	}
+}
+
+// recordAppliedGeneration sets the TemplatesAppliedGenerationAnnotation on the policy to its generation when all of
+// its policy templates were applied, without template errors, pending dependencies, or other reconcile errors. A
+// failure to set the annotation is only logged since it doesn't affect the policy templates.
+func (r *PolicyReconciler) recordAppliedGeneration(
+	ctx context.Context, instance *policiesv1.Policy, results *templateResults, reconcileErr error,
+) {
+	if len(results.errors) != 0 || len(results.pending) != 0 || reconcileErr != nil {
+		return
+	}
+
+	generation := strconv.FormatInt(instance.Generation, 10)
+	if instance.GetAnnotations()[utils.TemplatesAppliedGenerationAnnotation] == generation {
+		return
+	}
+
+	patch := client.MergeFrom(instance.DeepCopy())
+
+	annotations := instance.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[utils.TemplatesAppliedGenerationAnnotation] = generation
+	instance.SetAnnotations(annotations)
+
+	err := r.Patch(ctx, instance, patch, client.FieldOwner(utils.TemplatesAppliedFieldManager))
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to record the applied generation on the policy")
+	}
+}
@@ -9,6 +9,8 @@ import (
 	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
 )
 
 // templatePredicates filters out changes to policies that don't need to be
@@ -24,6 +26,12 @@ func templatePredicates() predicate.Funcs {
 				return true
 			}
 
+			pauseAnnotation := utils.PauseSyncAnnotation
+			if oldPolicy.Annotations[pauseAnnotation] != updatedPolicy.Annotations[pauseAnnotation] {
+				// The sync was paused or resumed.
+				return true
+			}
+
 			if hasAnyDependencies(updatedPolicy) {
 				// if it has dependencies, and it's not currently Pending, then
 				// it needs to re-calculate if it *should* be Pending.
@@ -92,6 +92,8 @@ type PolicyReconciler struct {
 	DisableGkSync        bool
 	createdGkConstraint  *bool
 	ConcurrentReconciles int
+	// MaxMessageBytes is the byte budget of a compliance message in the policy status. A value of 0 means no limit.
+	MaxMessageBytes int
 }
 
 // Reconcile reads that state of the cluster for a Policy object and makes changes based on the state read
@@ -141,6 +143,22 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, err
 	}
 
+	// Leave the templates alone while a local administrator paused the sync of the policy, unless it's being deleted
+	if instance.DeletionTimestamp == nil {
+		paused, until, err := utils.SyncPausedUntil(instance, time.Now())
+		if err != nil {
+			reqLogger.Error(err, "Ignoring the invalid pause-sync annotation on the policy")
+		} else if paused {
+			reqLogger.Info("Skipping the template sync because the policy is paused")
+
+			if until.IsZero() {
+				return reconcile.Result{}, nil
+			}
+
+			return reconcile.Result{RequeueAfter: time.Until(until)}, nil
+		}
+	}
+
 	var discoveryClient discovery.DiscoveryInterface
 	var dClient dynamic.Interface
 
@@ -174,6 +192,9 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, nil
 	}
 
+	// Collect the template errors and pending templates to record whether the policy templates were applied
+	ctx, results := withTemplateResults(ctx)
+
 	// Check duplicate names in configuration-policies
 	dupName := getDupName(ctx, instance)
 	if dupName != "" {
@@ -184,6 +205,8 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			_ = r.emitTemplateError(ctx, instance, tIndex, dupName, false, msg)
 		}
 
+		r.recordAppliedGeneration(ctx, instance, results, nil)
+
 		return reconcile.Result{}, nil
 	}
 
@@ -239,7 +262,7 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 			Group:     dep.GroupVersionKind().Group,
 			Version:   dep.GroupVersionKind().Version,
 			Kind:      dep.GroupVersionKind().Kind,
-			Namespace: getDepNamespace(r.ClusterNamespace, dep),
+			Namespace: getDepNamespace(instance.Namespace, dep),
 			Name:      dep.Name,
 		}
 
@@ -313,7 +336,7 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 				Group:     dep.GroupVersionKind().Group,
 				Version:   dep.GroupVersionKind().Version,
 				Kind:      dep.GroupVersionKind().Kind,
-				Namespace: getDepNamespace(r.ClusterNamespace, dep),
+				Namespace: getDepNamespace(instance.Namespace, dep),
 				Name:      dep.Name,
 			}
 
@@ -969,6 +992,8 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request reconcile.Requ
 		}
 	}
 
+	r.recordAppliedGeneration(ctx, instance, results, resultError)
+
 	reqLogger.V(2).Info("Completed the reconciliation")
 
 	return reconcile.Result{}, resultError
@@ -1093,8 +1118,8 @@ func (r *PolicyReconciler) setDefaultTemplateLabels(instance *policiesv1.Policy,
 		utils.ParentPolicyLabel:      instance.GetName(),
 		"cluster-name":               instance.GetLabels()[common.ClusterNameLabel],
 		common.ClusterNameLabel:      instance.GetLabels()[common.ClusterNameLabel],
-		"cluster-namespace":          r.ClusterNamespace,
-		common.ClusterNamespaceLabel: r.ClusterNamespace,
+		"cluster-namespace":          instance.Namespace,
+		common.ClusterNamespaceLabel: instance.Namespace,
 	}
 
 	for key, label := range desiredLabels {
@@ -1264,7 +1289,7 @@ func (r *PolicyReconciler) cleanUpExcessTemplates(
 		// Instantiate a dynamic client for the GVR
 		resourceNs := ""
 		if gvrScoped.namespaced {
-			resourceNs = r.ClusterNamespace
+			resourceNs = instance.Namespace
 		}
 
 		resClient := dClient.Resource(gvrScoped.gvr).Namespace(resourceNs)
@@ -1505,6 +1530,8 @@ func (r *PolicyReconciler) emitTemplateError(
 ) error {
 	log := ctrl.LoggerFrom(ctx)
 
+	recordTemplateError(ctx, tName)
+
 	err := r.emitTemplateEvent(ctx, pol, tIndex, tName, clusterScoped,
 		"Warning", policiesv1.NonCompliant, "template-error; "+errMsg)
 	if err != nil {
@@ -1523,6 +1550,8 @@ func (r *PolicyReconciler) emitTemplatePending(
 ) error {
 	log := ctrl.LoggerFrom(ctx)
 
+	recordTemplatePending(ctx, tName)
+
 	compliance := policiesv1.Pending
 	eventType := "Warning"
 
@@ -1555,8 +1584,10 @@ func (r *PolicyReconciler) emitTemplateEvent(
 		return err
 	}
 
-	// check if the event is already present in the policy status - if so, return early
-	if strings.Contains(getLatestStatusMessage(refreshed, tIndex), string(compliance)+"; "+msg) {
+	// check if the event is already present in the policy status - if so, return early. The status has the message
+	// truncated to the byte budget.
+	statusMsg := utils.TruncateMessage(string(compliance)+"; "+msg, r.MaxMessageBytes)
+	if strings.Contains(getLatestStatusMessage(refreshed, tIndex), statusMsg) {
 		return nil
 	}
 
@@ -1576,6 +1607,7 @@ func (r *PolicyReconciler) emitTemplateEvent(
 		InstanceName:     r.InstanceName,
 		ClientSet:        r.Clientset,
 		ControllerName:   ControllerName,
+		MaxMessageBytes:  r.MaxMessageBytes,
 	}
 
 	ownerref := metav1.OwnerReference{
@@ -3,6 +3,8 @@
 package templatesync
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	gktemplatesv1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1"
@@ -14,6 +16,10 @@ import (
 	"k8s.io/client-go/tools/events"
 	configpoliciesv1 "open-cluster-management.io/config-policy-controller/api/v1"
 	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
 )
 
 func TestHandleSyncSuccessNoDoubleRemoveStatus(t *testing.T) {
@@ -442,3 +448,57 @@ func TestGetDepNamespace(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordAppliedGeneration(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		errors       []string
+		pending      []string
+		reconcileErr error
+		expected     string
+	}{
+		"all applied":      {expected: "3"},
+		"template error":   {errors: []string{"a"}, pending: []string{"b"}},
+		"template pending": {pending: []string{"b"}},
+		"reconcile error":  {reconcileErr: errors.New("oops")},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policiesv1.Policy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Namespace: "managed", Generation: 3},
+			}
+			r := &PolicyReconciler{Client: clientfake.NewClientBuilder().WithScheme(scheme).WithObjects(policy).Build()}
+
+			ctx, results := withTemplateResults(context.TODO())
+
+			for _, tName := range test.errors {
+				recordTemplateError(ctx, tName)
+			}
+
+			for _, tName := range test.pending {
+				recordTemplatePending(ctx, tName)
+			}
+
+			r.recordAppliedGeneration(ctx, policy, results, test.reconcileErr)
+
+			stored := &policiesv1.Policy{}
+			if err := r.Get(ctx, client.ObjectKeyFromObject(policy), stored); err != nil {
+				t.Fatal(err)
+			}
+
+			applied := stored.GetAnnotations()[utils.TemplatesAppliedGenerationAnnotation]
+			if applied != test.expected {
+				t.Fatalf("Expected the applied generation %q but got %q", test.expected, applied)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ package utils
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -20,10 +22,14 @@ type ComplianceEventSender struct {
 	InstanceName     string
 	ClientSet        *kubernetes.Clientset
 	ControllerName   string
+	// MaxMessageBytes is the byte budget of a compliance message in the policy status. A value of 0 means no limit.
+	MaxMessageBytes int
 }
 
 // SendEvent will send a policy template status message update synchronously as opposed to EventRecorder
-// sending events in the background asynchronously.
+// sending events in the background asynchronously. The full message is always kept in the Event. When it exceeds
+// MaxMessageBytes, the truncated message that the policy status will have is also set in the truncated-message
+// annotation.
 func (c *ComplianceEventSender) SendEvent(
 	ctx context.Context,
 	instance client.Object,
@@ -63,6 +69,10 @@ func (c *ComplianceEventSender) SendEvent(
 		ReportingInstance:   c.InstanceName,
 	}
 
+	if truncated := TruncateMessage(msg, c.MaxMessageBytes); truncated != msg {
+		event.Annotations = map[string]string{TruncatedMessageAnnotation: truncated}
+	}
+
 	if instance != nil {
 		gvk := instance.GetObjectKind().GroupVersionKind()
 
@@ -87,6 +97,86 @@ func (c *ComplianceEventSender) SendEvent(
 	return err
 }
 
+// TruncateMessage truncates a compliance message to at most maxBytes bytes. The message is split into violations on
+// "; " and as many leading violations as possible are kept, followed by a "(N more violations truncated)" suffix that
+// counts the violations that were fully dropped. A leading compliance state, such as in "NonCompliant; violation", is
+// always kept in full. If the first violation alone doesn't fit, it's cut at a UTF-8 character boundary and marked
+// with "...". When maxBytes can't fit the compliance state and the suffix, only those are returned, so the result may
+// exceed maxBytes. The result is deterministic and a message that fits is returned unchanged, so truncating a
+// truncated message is a no-op. A maxBytes of 0 means no limit.
+func TruncateMessage(msg string, maxBytes int) string {
+	if maxBytes <= 0 || len(msg) <= maxBytes {
+		return msg
+	}
+
+	violations := strings.Split(msg, "; ")
+	prefix := ""
+
+	if len(violations) > 1 && hasComplianceState(violations[0]) {
+		prefix = violations[0] + "; "
+		violations = violations[1:]
+	}
+
+	for kept := len(violations) - 1; kept > 0; kept-- {
+		truncated := prefix + strings.Join(violations[:kept], "; ") + truncatedSuffix(len(violations)-kept)
+		if len(truncated) <= maxBytes {
+			return truncated
+		}
+	}
+
+	// The first violation is cut, so it's not counted as truncated.
+	suffix := "..." + truncatedSuffix(len(violations)-1)
+
+	budget := maxBytes - len(prefix) - len(suffix)
+	if budget > 0 {
+		if cut := cutUTF8(violations[0], budget); cut != "" {
+			return prefix + cut + suffix
+		}
+	}
+
+	if prefix == "" {
+		return cutUTF8(msg, maxBytes)
+	}
+
+	return strings.TrimSuffix(prefix, "; ") + truncatedSuffix(len(violations))
+}
+
+// hasComplianceState returns whether the first segment of a compliance message is, or ends with, a compliance state,
+// such as in "(combined from similar events): NonCompliant".
+func hasComplianceState(segment string) bool {
+	for _, state := range []policyv1.ComplianceState{policyv1.Compliant, policyv1.NonCompliant, policyv1.Pending} {
+		if strings.HasSuffix(segment, string(state)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func truncatedSuffix(count int) string {
+	switch count {
+	case 0:
+		return ""
+	case 1:
+		return " (1 more violation truncated)"
+	}
+
+	return fmt.Sprintf(" (%d more violations truncated)", count)
+}
+
+// cutUTF8 returns the longest prefix of s of at most maxBytes bytes that doesn't split a UTF-8 character.
+func cutUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+
+	return s[:maxBytes]
+}
+
 func EventReason(ns, name string) string {
 	if ns == "" {
 		return fmt.Sprintf(PolicyClusterScopedFmtStr, name)
@@ -0,0 +1,75 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		msg      string
+		maxBytes int
+		expected string
+	}{
+		"fits": {
+			msg:      "NonCompliant; violation - a; violation - b",
+			maxBytes: 64,
+			expected: "NonCompliant; violation - a; violation - b",
+		},
+		"no limit": {
+			msg:      "NonCompliant; " + strings.Repeat("x", 100),
+			maxBytes: 0,
+			expected: "NonCompliant; " + strings.Repeat("x", 100),
+		},
+		"drops trailing violations": {
+			msg:      "NonCompliant; violation - a; violation - b; violation - c; violation - d",
+			maxBytes: 60,
+			expected: "NonCompliant; violation - a (3 more violations truncated)",
+		},
+		"cut violation isn't counted": {
+			msg:      "NonCompliant; " + strings.Repeat("x", 60) + "; violation - b",
+			maxBytes: 64,
+			expected: "NonCompliant; " + strings.Repeat("x", 18) + "... (1 more violation truncated)",
+		},
+		"cut only violation": {
+			msg:      "NonCompliant; " + strings.Repeat("x", 100),
+			maxBytes: 30,
+			expected: "NonCompliant; " + strings.Repeat("x", 13) + "...",
+		},
+		"keeps the compliance state": {
+			msg:      "Compliant; violation - a; violation - b; violation - c; violation - d",
+			maxBytes: 20,
+			expected: "Compliant (4 more violations truncated)",
+		},
+		"keeps a combined compliance state": {
+			msg:      "(combined from similar events): NonCompliant; violation - a; violation - b",
+			maxBytes: 64,
+			expected: "(combined from similar events): NonCompliant (2 more violations truncated)",
+		},
+		"cuts a message without a compliance state": {
+			msg:      "héllo; world",
+			maxBytes: 2,
+			expected: "h",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			truncated := TruncateMessage(test.msg, test.maxBytes)
+			if truncated != test.expected {
+				t.Fatalf("Expected %q but got %q", test.expected, truncated)
+			}
+
+			again := TruncateMessage(truncated, test.maxBytes)
+			if len(truncated) <= test.maxBytes && again != truncated {
+				t.Fatalf("Expected truncating the truncated message to be a no-op but got %q", again)
+			}
+		})
+	}
+}
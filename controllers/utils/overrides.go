@@ -0,0 +1,184 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// LocalOverridesConfigMapName is the name of the ConfigMap in the cluster namespace on the managed cluster that
+	// holds the local overrides of the replicated policies.
+	LocalOverridesConfigMapName = "governance-policy-local-overrides"
+	// LocalOverridesKey is the key of the local overrides in the ConfigMap data.
+	LocalOverridesKey = "overrides.yaml"
+	// LocalOverridesAnnotation is set on a replicated policy on the managed cluster to the JSON of the local overrides
+	// that were applied to it by the spec-sync controller.
+	LocalOverridesAnnotation = common.APIGroup + "/local-overrides"
+)
+
+// PolicyOverride is the local override of a replicated policy.
+type PolicyOverride struct {
+	// RemediationAction replaces the remediationAction of the policy.
+	RemediationAction policiesv1.RemediationAction `json:"remediationAction,omitempty"`
+	// Disabled replaces the disabled field of the policy.
+	Disabled *bool `json:"disabled,omitempty"`
+	// DisabledTemplates are the names of the policy templates that are removed from the policy.
+	DisabledTemplates []string `json:"disabledTemplates,omitempty"`
+}
+
+// LocalOverrides are the local overrides of the replicated policies, keyed by the replicated policy name.
+type LocalOverrides struct {
+	Policies map[string]*PolicyOverride `json:"policies,omitempty"`
+	// Invalid maps the replicated policy names whose local overrides are invalid to the reason. They aren't in
+	// Policies.
+	Invalid map[string]string `json:"-"`
+}
+
+// ParseLocalOverrides parses and validates the local overrides in the format of the LocalOverridesKey ConfigMap data.
+// The local overrides of each policy are validated separately, so that an invalid entry is recorded in Invalid
+// without affecting the other policies. An error is only returned when the data as a whole can't be parsed.
+func ParseLocalOverrides(data string) (*LocalOverrides, error) {
+	rawOverrides := struct {
+		Policies map[string]json.RawMessage `json:"policies,omitempty"`
+	}{}
+
+	if err := yaml.UnmarshalStrict([]byte(data), &rawOverrides); err != nil {
+		return nil, fmt.Errorf("the local overrides are invalid: %w", err)
+	}
+
+	overrides := &LocalOverrides{}
+
+	for name, raw := range rawOverrides.Policies {
+		override := &PolicyOverride{}
+
+		if err := yaml.UnmarshalStrict(raw, override); err != nil {
+			overrides.invalidate(name, err.Error())
+
+			continue
+		}
+
+		switch strings.ToLower(string(override.RemediationAction)) {
+		case "":
+		case "inform", "enforce":
+			override.RemediationAction = policiesv1.RemediationAction(
+				strings.ToLower(string(override.RemediationAction)),
+			)
+		default:
+			overrides.invalidate(name, "the remediationAction must be inform or enforce")
+
+			continue
+		}
+
+		// A null entry has no overrides.
+		if string(raw) == "null" {
+			continue
+		}
+
+		if overrides.Policies == nil {
+			overrides.Policies = map[string]*PolicyOverride{}
+		}
+
+		overrides.Policies[name] = override
+	}
+
+	return overrides, nil
+}
+
+func (o *LocalOverrides) invalidate(name string, reason string) {
+	if o.Invalid == nil {
+		o.Invalid = map[string]string{}
+	}
+
+	o.Invalid[name] = reason
+}
+
+// String returns a human readable description of the override.
+func (o *PolicyOverride) String() string {
+	descriptions := []string{}
+
+	if o.RemediationAction != "" {
+		descriptions = append(descriptions, "the remediationAction is "+string(o.RemediationAction))
+	}
+
+	if o.Disabled != nil {
+		descriptions = append(descriptions, fmt.Sprintf("disabled is %t", *o.Disabled))
+	}
+
+	if len(o.DisabledTemplates) != 0 {
+		descriptions = append(
+			descriptions, "the policy templates "+strings.Join(o.DisabledTemplates, ", ")+" are disabled",
+		)
+	}
+
+	return strings.Join(descriptions, "; ")
+}
+
+// ApplyPolicyOverride returns a copy of the policy with the override applied to its spec and recorded in the
+// LocalOverridesAnnotation. A nil override returns an unmodified copy.
+func ApplyPolicyOverride(plc *policiesv1.Policy, override *PolicyOverride) *policiesv1.Policy {
+	plc = plc.DeepCopy()
+
+	if override == nil {
+		return plc
+	}
+
+	if override.RemediationAction != "" {
+		plc.Spec.RemediationAction = override.RemediationAction
+	}
+
+	if override.Disabled != nil {
+		plc.Spec.Disabled = *override.Disabled
+	}
+
+	if len(override.DisabledTemplates) != 0 {
+		templates := make([]*policiesv1.PolicyTemplate, 0, len(plc.Spec.PolicyTemplates))
+
+		for _, policyT := range plc.Spec.PolicyTemplates {
+			tmpl := TemplateObject(policyT)
+			if tmpl != nil && slices.Contains(override.DisabledTemplates, tmpl.GetName()) {
+				continue
+			}
+
+			templates = append(templates, policyT)
+		}
+
+		plc.Spec.PolicyTemplates = templates
+	}
+
+	// Marshaling the struct can't fail and produces the same value for equal overrides.
+	overrideJSON, _ := json.Marshal(override)
+
+	annotations := plc.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[LocalOverridesAnnotation] = string(overrideJSON)
+	plc.SetAnnotations(annotations)
+
+	return plc
+}
+
+// RecordedOverride returns the override recorded in the LocalOverridesAnnotation of the policy, or nil if it has none.
+func RecordedOverride(plc *policiesv1.Policy) (*PolicyOverride, error) {
+	value := plc.GetAnnotations()[LocalOverridesAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	override := &PolicyOverride{}
+
+	if err := json.Unmarshal([]byte(value), override); err != nil {
+		return nil, err
+	}
+
+	return override, nil
+}
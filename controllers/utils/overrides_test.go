@@ -0,0 +1,124 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+func overridesTestPolicy() *policiesv1.Policy {
+	template := func(name string) *policiesv1.PolicyTemplate {
+		return &policiesv1.PolicyTemplate{ObjectDefinition: runtime.RawExtension{Raw: []byte(
+			`{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+				`"metadata":{"name":"` + name + `"}}`,
+		)}}
+	}
+
+	return &policiesv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "policies.my-policy",
+			Annotations: map[string]string{"policy.open-cluster-management.io/standards": "NIST SP 800-53"},
+		},
+		Spec: policiesv1.PolicySpec{
+			RemediationAction: policiesv1.Enforce,
+			PolicyTemplates:   []*policiesv1.PolicyTemplate{template("config-1"), template("config-2")},
+		},
+	}
+}
+
+func TestParseLocalOverrides(t *testing.T) {
+	t.Parallel()
+
+	overrides, err := ParseLocalOverrides(`
+policies:
+  policies.my-policy:
+    remediationAction: Inform
+    disabledTemplates: [config-2]
+`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	override := overrides.Policies["policies.my-policy"]
+	if override == nil || override.RemediationAction != "inform" || len(override.DisabledTemplates) != 1 {
+		t.Fatalf("unexpected override: %+v", override)
+	}
+
+	// An invalid entry doesn't affect the other policies.
+	overrides, err = ParseLocalOverrides(`
+policies:
+  policies.valid:
+    remediationAction: enforce
+  policies.bad-action:
+    remediationAction: delete
+  policies.unknown-field:
+    unknown: true
+  policies.empty:
+`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(overrides.Policies) != 1 || overrides.Policies["policies.valid"] == nil {
+		t.Fatalf("expected only the valid override, got %+v", overrides.Policies)
+	}
+
+	if len(overrides.Invalid) != 2 || overrides.Invalid["policies.bad-action"] == "" ||
+		overrides.Invalid["policies.unknown-field"] == "" {
+		t.Fatalf("expected the two invalid overrides, got %+v", overrides.Invalid)
+	}
+
+	for _, invalid := range []string{
+		"policies: [policies.my-policy]\n",
+		"unknown: true\n",
+	} {
+		if _, err := ParseLocalOverrides(invalid); err == nil {
+			t.Fatalf("expected an error for the invalid overrides %q", invalid)
+		}
+	}
+}
+
+func TestApplyPolicyOverride(t *testing.T) {
+	t.Parallel()
+
+	hubPlc := overridesTestPolicy()
+	disabled := true
+
+	override := &PolicyOverride{
+		RemediationAction: "inform", Disabled: &disabled, DisabledTemplates: []string{"config-2"},
+	}
+
+	managedPlc := ApplyPolicyOverride(hubPlc, override)
+
+	if managedPlc.Spec.RemediationAction != "inform" || !managedPlc.Spec.Disabled {
+		t.Fatalf("the override was not applied to the spec: %+v", managedPlc.Spec)
+	}
+
+	if len(managedPlc.Spec.PolicyTemplates) != 1 {
+		t.Fatalf("expected 1 policy template, got %d", len(managedPlc.Spec.PolicyTemplates))
+	}
+
+	expected := `{"remediationAction":"inform","disabled":true,"disabledTemplates":["config-2"]}`
+	if managedPlc.Annotations[LocalOverridesAnnotation] != expected {
+		t.Fatalf("unexpected annotation: %s", managedPlc.Annotations[LocalOverridesAnnotation])
+	}
+
+	if hubPlc.Spec.RemediationAction != policiesv1.Enforce || len(hubPlc.Spec.PolicyTemplates) != 2 {
+		t.Fatal("the input policy was modified")
+	}
+
+	// The hub policy matches the overridden replicated policy so that the status sync doesn't loop.
+	if !EquivalentReplicatedPolicies(managedPlc, hubPlc) || !EquivalentReplicatedPolicies(hubPlc, managedPlc) {
+		t.Fatal("expected the hub policy to be equivalent to the overridden replicated policy")
+	}
+
+	hubPlc.Spec.PolicyTemplates = hubPlc.Spec.PolicyTemplates[1:]
+
+	if EquivalentReplicatedPolicies(managedPlc, hubPlc) {
+		t.Fatal("expected a change to the hub policy to not be equivalent")
+	}
+}
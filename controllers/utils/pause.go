@@ -0,0 +1,43 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"open-cluster-management.io/governance-policy-propagator/controllers/common"
+)
+
+// PauseSyncAnnotation is set by a local administrator on a replicated policy on the managed cluster to pause the spec
+// sync and template sync of the policy. The value is "true" to pause until the annotation is removed, or an RFC 3339
+// time to pause until that time.
+const PauseSyncAnnotation = common.APIGroup + "/pause-sync"
+
+// SyncPausedUntil returns whether the sync of the object is paused by the PauseSyncAnnotation at the input time, and
+// the expiry of the pause, which is zero when the pause doesn't expire. An error is returned for an invalid annotation
+// value, in which case the sync isn't paused.
+func SyncPausedUntil(obj metav1.Object, now time.Time) (paused bool, until time.Time, err error) {
+	value, ok := obj.GetAnnotations()[PauseSyncAnnotation]
+	if !ok {
+		return false, time.Time{}, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true":
+		return true, time.Time{}, nil
+	case "false", "":
+		return false, time.Time{}, nil
+	}
+
+	until, err = time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf(
+			"the %s annotation must be true or an RFC 3339 time, got %q", PauseSyncAnnotation, value,
+		)
+	}
+
+	return now.Before(until), until, nil
+}
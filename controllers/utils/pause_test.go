@@ -0,0 +1,54 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSyncPausedUntil(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		value  *string
+		paused bool
+		until  time.Time
+		err    bool
+	}{
+		"no annotation": {},
+		"true":          {value: ptr("True"), paused: true},
+		"false":         {value: ptr("false")},
+		"future":        {value: ptr("2026-03-01T13:00:00Z"), paused: true, until: now.Add(time.Hour)},
+		"expired":       {value: ptr("2026-03-01T11:00:00Z"), until: now.Add(-time.Hour)},
+		"invalid":       {value: ptr("1h"), err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			obj := &metav1.ObjectMeta{}
+			if test.value != nil {
+				obj.Annotations = map[string]string{PauseSyncAnnotation: *test.value}
+			}
+
+			paused, until, err := SyncPausedUntil(obj, now)
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if paused != test.paused || !until.Equal(test.until) {
+				t.Fatalf("expected paused=%t until=%s, got paused=%t until=%s", test.paused, test.until, paused, until)
+			}
+		})
+	}
+}
+
+func ptr(value string) *string {
+	return &value
+}
@@ -0,0 +1,288 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReloadableClient is a client whose underlying client can be replaced, such as when the hub credentials are
+// reloaded, so that the controllers holding it keep working without being rebuilt. It can also be suspended until the
+// replacement is ready, such as while the cache of a rebuilt hub manager syncs.
+type ReloadableClient struct {
+	current atomic.Pointer[clientHolder]
+}
+
+type clientHolder struct {
+	client.Client
+	// replaced is closed when a suspended client is replaced. It's nil when the client isn't suspended.
+	replaced chan struct{}
+}
+
+// blank assignment to verify that ReloadableClient implements client.Client
+var _ client.Client = &ReloadableClient{}
+
+// NewReloadableClient returns a ReloadableClient that forwards to the client until it's replaced.
+func NewReloadableClient(c client.Client) *ReloadableClient {
+	reloadable := &ReloadableClient{}
+	reloadable.Replace(c)
+
+	return reloadable
+}
+
+// Replace forwards the following calls to the client, including the calls waiting on a suspended client. Calls in
+// progress complete with the previous client.
+func (r *ReloadableClient) Replace(c client.Client) {
+	previous := r.current.Swap(&clientHolder{Client: c})
+	if previous != nil && previous.replaced != nil {
+		close(previous.replaced)
+	}
+}
+
+// Suspend makes the following calls that read from or write to the API server wait until the client is replaced or
+// their context is canceled. Calls in progress complete with the current client.
+func (r *ReloadableClient) Suspend() {
+	for {
+		current := r.current.Load()
+		if current.replaced != nil {
+			return
+		}
+
+		suspended := &clientHolder{Client: current.Client, replaced: make(chan struct{})}
+		if r.current.CompareAndSwap(current, suspended) {
+			return
+		}
+	}
+}
+
+// get returns the current client, and waits until it's replaced when it's suspended.
+func (r *ReloadableClient) get(ctx context.Context) (client.Client, error) {
+	for {
+		current := r.current.Load()
+		if current.replaced == nil {
+			return current.Client, nil
+		}
+
+		select {
+		case <-current.replaced:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (r *ReloadableClient) Get(
+	ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption,
+) error {
+	c, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Get(ctx, key, obj, opts...)
+}
+
+func (r *ReloadableClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.List(ctx, list, opts...)
+}
+
+func (r *ReloadableClient) Apply(
+	ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.ApplyOption,
+) error {
+	c, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Apply(ctx, obj, opts...)
+}
+
+func (r *ReloadableClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Create(ctx, obj, opts...)
+}
+
+func (r *ReloadableClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Delete(ctx, obj, opts...)
+}
+
+func (r *ReloadableClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Update(ctx, obj, opts...)
+}
+
+func (r *ReloadableClient) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption,
+) error {
+	c, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Patch(ctx, obj, patch, opts...)
+}
+
+func (r *ReloadableClient) DeleteAllOf(
+	ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption,
+) error {
+	c, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (r *ReloadableClient) Status() client.SubResourceWriter {
+	return &reloadableSubResourceClient{reloadable: r, subResource: "status"}
+}
+
+func (r *ReloadableClient) SubResource(subResource string) client.SubResourceClient {
+	return &reloadableSubResourceClient{reloadable: r, subResource: subResource}
+}
+
+// The following don't call the API server, so they use the current client even when it's suspended.
+
+func (r *ReloadableClient) Scheme() *runtime.Scheme {
+	return r.current.Load().Scheme()
+}
+
+func (r *ReloadableClient) RESTMapper() meta.RESTMapper {
+	return r.current.Load().RESTMapper()
+}
+
+func (r *ReloadableClient) GroupVersionKindFor(obj runtime.Object) (schema.GroupVersionKind, error) {
+	return r.current.Load().GroupVersionKindFor(obj)
+}
+
+func (r *ReloadableClient) IsObjectNamespaced(obj runtime.Object) (bool, error) {
+	return r.current.Load().IsObjectNamespaced(obj)
+}
+
+// reloadableSubResourceClient forwards each call to the subresource client of the current client of the
+// ReloadableClient, so that it waits on a suspended client like the ReloadableClient does.
+type reloadableSubResourceClient struct {
+	reloadable  *ReloadableClient
+	subResource string
+}
+
+func (s *reloadableSubResourceClient) get(ctx context.Context) (client.SubResourceClient, error) {
+	c, err := s.reloadable.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.SubResource(s.subResource), nil
+}
+
+func (s *reloadableSubResourceClient) Get(
+	ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption,
+) error {
+	c, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Get(ctx, obj, subResource, opts...)
+}
+
+func (s *reloadableSubResourceClient) Create(
+	ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption,
+) error {
+	c, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Create(ctx, obj, subResource, opts...)
+}
+
+func (s *reloadableSubResourceClient) Update(
+	ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption,
+) error {
+	c, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Update(ctx, obj, opts...)
+}
+
+func (s *reloadableSubResourceClient) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption,
+) error {
+	c, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Patch(ctx, obj, patch, opts...)
+}
+
+func (s *reloadableSubResourceClient) Apply(
+	ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.SubResourceApplyOption,
+) error {
+	c, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.Apply(ctx, obj, opts...)
+}
+
+// ReloadableRecorder is an event recorder whose underlying recorder can be replaced, such as when the event
+// broadcaster to the hub is rebuilt after the hub credentials are reloaded.
+type ReloadableRecorder struct {
+	current atomic.Pointer[recorderHolder]
+}
+
+type recorderHolder struct {
+	events.EventRecorder
+}
+
+// blank assignment to verify that ReloadableRecorder implements events.EventRecorder
+var _ events.EventRecorder = &ReloadableRecorder{}
+
+// NewReloadableRecorder returns a ReloadableRecorder that forwards to the recorder until it's replaced.
+func NewReloadableRecorder(recorder events.EventRecorder) *ReloadableRecorder {
+	reloadable := &ReloadableRecorder{}
+	reloadable.Replace(recorder)
+
+	return reloadable
+}
+
+// Replace forwards the following events to the recorder.
+func (r *ReloadableRecorder) Replace(recorder events.EventRecorder) {
+	r.current.Store(&recorderHolder{recorder})
+}
+
+func (r *ReloadableRecorder) Eventf(
+	regarding runtime.Object, related runtime.Object, eventtype, reason, action, note string, args ...interface{},
+) {
+	r.current.Load().Eventf(regarding, related, eventtype, reason, action, note, args...)
+}
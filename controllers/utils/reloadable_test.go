@@ -0,0 +1,124 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReloadableClient(t *testing.T) {
+	t.Parallel()
+
+	secret := func(namespace string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: namespace}}
+	}
+
+	reloadable := NewReloadableClient(fake.NewClientBuilder().WithObjects(secret("old")).Build())
+	key := types.NamespacedName{Namespace: "new", Name: "secret"}
+
+	if err := reloadable.Get(context.TODO(), key, &corev1.Secret{}); err == nil {
+		t.Fatal("Expected the secret to not be found with the previous client")
+	}
+
+	reloadable.Replace(fake.NewClientBuilder().WithObjects(secret("new")).Build())
+
+	if err := reloadable.Get(context.TODO(), key, &corev1.Secret{}); err != nil {
+		t.Fatalf("Expected the secret to be found with the new client but got: %v", err)
+	}
+
+	if err := reloadable.Create(context.TODO(), secret("other")); err != nil {
+		t.Fatalf("Failed to create the secret: %v", err)
+	}
+
+	secrets := &corev1.SecretList{}
+
+	if err := reloadable.List(context.TODO(), secrets); err != nil {
+		t.Fatalf("Failed to list the secrets: %v", err)
+	}
+
+	if len(secrets.Items) != 2 {
+		t.Fatalf("Expected 2 secrets with the new client but got %d", len(secrets.Items))
+	}
+}
+
+func TestReloadableClientSuspend(t *testing.T) {
+	t.Parallel()
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"}}
+
+	oldClient := fake.NewClientBuilder().WithObjects(secret.DeepCopy()).Build()
+	newClient := fake.NewClientBuilder().WithObjects(secret.DeepCopy()).Build()
+
+	reloadable := NewReloadableClient(oldClient)
+	reloadable.Suspend()
+	reloadable.Suspend()
+
+	timeoutCtx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	err := reloadable.Status().Update(timeoutCtx, secret.DeepCopy())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the update to wait on the suspended client but got: %v", err)
+	}
+
+	if reloadable.Scheme() != oldClient.Scheme() {
+		t.Fatal("Expected the scheme of the suspended client")
+	}
+
+	updated := make(chan error, 1)
+
+	go func() {
+		updated <- reloadable.Update(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default", Labels: map[string]string{"new": ""}},
+		})
+	}()
+
+	select {
+	case err := <-updated:
+		t.Fatalf("Expected the update to wait on the suspended client but got: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	reloadable.Replace(newClient)
+
+	if err := <-updated; err != nil {
+		t.Fatalf("Expected the update to complete with the new client but got: %v", err)
+	}
+
+	for c, labeled := range map[*ReloadableClient]bool{NewReloadableClient(oldClient): false, reloadable: true} {
+		got := &corev1.Secret{}
+
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "secret"}, got); err != nil {
+			t.Fatalf("Failed to get the secret: %v", err)
+		}
+
+		if _, ok := got.Labels["new"]; ok != labeled {
+			t.Fatalf("Expected the update to only be made with the new client but got the labels %v", got.Labels)
+		}
+	}
+}
+
+func TestReloadableRecorder(t *testing.T) {
+	t.Parallel()
+
+	oldRecorder := events.NewFakeRecorder(1)
+	newRecorder := events.NewFakeRecorder(1)
+
+	reloadable := NewReloadableRecorder(oldRecorder)
+	reloadable.Replace(newRecorder)
+
+	reloadable.Eventf(&corev1.Secret{}, nil, corev1.EventTypeNormal, "Reason", "Action", "note")
+
+	if len(oldRecorder.Events) != 0 || len(newRecorder.Events) != 1 {
+		t.Fatalf("Expected the event to be recorded by the new recorder only")
+	}
+}
@@ -1,14 +1,20 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
@@ -31,6 +37,8 @@ var (
 		{Group: GConstraint},
 	}
 	ErrNoVersionedResource = errors.New("the resource version was not found")
+	// ErrHubOffline is returned by the hub client while the hub is unreachable and the hub snapshot is used instead.
+	ErrHubOffline = errors.New("the hub is offline")
 )
 
 const (
@@ -40,14 +48,160 @@ const (
 	ClusterwideFinalizer      = common.APIGroup + "/cleanup-cluster-scoped-policies"
 	ParentPolicyLabel         = common.APIGroup + "/policy"
 	PolicyTypeLabel           = common.APIGroup + "/policy-type"
+	// ComplianceEventsLabel set to "disabled" on a policy template CRD indicates that the compliance of its
+	// templates is only reported in the template's status.history field and not with Kubernetes Events.
+	ComplianceEventsLabel = common.APIGroup + "/compliance-events"
+	// TruncatedMessageAnnotation holds the compliance message truncated to the configured byte budget on compliance
+	// Events whose message exceeds it. The Event message itself is never truncated.
+	TruncatedMessageAnnotation = common.APIGroup + "/truncated-message"
+	// LastSyncedHashAnnotation is set by the spec sync on a replicated policy on the managed cluster to the
+	// SyncedHash of the policy that it last synced, so that a local change is detected as a drift across restarts.
+	LastSyncedHashAnnotation = common.APIGroup + "/last-synced-hash"
+	// DriftedHashAnnotation is set by the spec sync on a replicated policy on the managed cluster to its SyncedHash
+	// when its local change was reported as a drift. It's removed when the policy is synced again.
+	DriftedHashAnnotation = common.APIGroup + "/drifted-hash"
+	// RejectedHashAnnotation is set by the spec sync on a replicated policy on the managed cluster to the SyncedHash of
+	// the hub policy whose signature was rejected, while the replicated policy is kept as is. It's removed when the
+	// policy is synced again.
+	RejectedHashAnnotation = common.APIGroup + "/rejected-hash"
+	// DeletionHeldAnnotation is set by the spec sync on a replicated policy on the managed cluster to the time that its
+	// deletion was held by the mass-deletion guard, so that the deletion stays held across restarts. It's removed when
+	// the deletion is no longer held.
+	DeletionHeldAnnotation = common.APIGroup + "/deletion-held"
+	// TemplatesAppliedGenerationAnnotation is set by the template sync on a replicated policy on the managed cluster to
+	// the generation of the policy whose policy templates were all applied, so that the status sync can tell whether
+	// the compliance of the templates reflects the current policy spec.
+	TemplatesAppliedGenerationAnnotation = common.APIGroup + "/templates-applied-generation"
+	// TemplatesAppliedFieldManager is the field manager of the TemplatesAppliedGenerationAnnotation.
+	TemplatesAppliedFieldManager = "governance-policy-framework-addon-templates-applied"
 )
 
+// managedOnlyAnnotations are maintained by the addon on the replicated policies on the managed cluster. They aren't
+// synced from the hub and are ignored when comparing replicated policies.
+var managedOnlyAnnotations = []string{
+	LastSyncedHashAnnotation, DriftedHashAnnotation, RejectedHashAnnotation, TemplatesAppliedGenerationAnnotation,
+	DeletionHeldAnnotation,
+}
+
+// SyncedHash returns a hash of the synced annotations and spec of the policy, which are the fields set by the spec
+// sync.
+func SyncedHash(plc *policiesv1.Policy) string {
+	// Marshaling the annotations and spec can't fail.
+	synced, _ := json.Marshal(struct {
+		Annotations map[string]string     `json:"annotations"`
+		Spec        policiesv1.PolicySpec `json:"spec"`
+	}{SyncedAnnotations(plc), plc.Spec})
+
+	sum := sha256.Sum256(synced)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// DriftRecorded returns whether the replicated policy has a local change that the spec sync reported as a drift and
+// left as is, and it wasn't changed since.
+func DriftRecorded(plc *policiesv1.Policy) bool {
+	drifted := plc.GetAnnotations()[DriftedHashAnnotation]
+
+	return drifted != "" && drifted == SyncedHash(plc)
+}
+
+// OnlyRecordedDrift returns whether the replicated policy only differs from the hub policy by a local change that the
+// spec sync reported as a drift and left as is. This is the case when the drift is recorded and the hub policy, with
+// the recorded local overrides applied, didn't change since the last sync.
+func OnlyRecordedDrift(managedPlc *policiesv1.Policy, hubPlc *policiesv1.Policy) bool {
+	if !DriftRecorded(managedPlc) {
+		return false
+	}
+
+	override, err := RecordedOverride(managedPlc)
+	if err != nil {
+		return false
+	}
+
+	return managedPlc.GetAnnotations()[LastSyncedHashAnnotation] == SyncedHash(ApplyPolicyOverride(hubPlc, override))
+}
+
+// OnlyRejectedSignature returns whether the replicated policy only differs from the hub policy because the spec sync
+// rejected the signature of the hub policy and kept the replicated policy as is. This is the case when the rejection is
+// recorded for the current hub policy.
+func OnlyRejectedSignature(managedPlc *policiesv1.Policy, hubPlc *policiesv1.Policy) bool {
+	rejected := managedPlc.GetAnnotations()[RejectedHashAnnotation]
+
+	return rejected != "" && rejected == SyncedHash(hubPlc)
+}
+
+// SyncedAnnotations returns the annotations of the policy without the annotations that are only maintained on the
+// managed cluster, which are the annotations that are synced from the hub.
+func SyncedAnnotations(plc metav1.Object) map[string]string {
+	annotations := plc.GetAnnotations()
+
+	managedOnly := slices.ContainsFunc(managedOnlyAnnotations, func(annotation string) bool {
+		_, ok := annotations[annotation]
+
+		return ok
+	})
+	if !managedOnly {
+		return annotations
+	}
+
+	synced := make(map[string]string, len(annotations))
+
+	for key, value := range annotations {
+		if !slices.Contains(managedOnlyAnnotations, key) {
+			synced[key] = value
+		}
+	}
+
+	if len(synced) == 0 {
+		return nil
+	}
+
+	return synced
+}
+
+// WithManagedOnlyAnnotations returns the annotations with the managed-only annotations of the existing replicated
+// policy added, so that a sync from the hub doesn't remove them.
+func WithManagedOnlyAnnotations(annotations map[string]string, existing metav1.Object) map[string]string {
+	for _, annotation := range managedOnlyAnnotations {
+		value, ok := existing.GetAnnotations()[annotation]
+		if !ok {
+			continue
+		}
+
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations[annotation] = value
+	}
+
+	return annotations
+}
+
 // EquivalentReplicatedPolicies compares replicated policies. Returns true if they match. (Comparing
 // labels is skipped here in part because in hosted mode the cluster-namespace label likely will not
-// match.)
+// match.) When only one of the policies has local overrides recorded in the LocalOverridesAnnotation, the overrides
+// are applied to the other policy before comparing, so that a hub policy matches its overridden replicated policy.
+// The annotations only maintained on the managed cluster are ignored.
 func EquivalentReplicatedPolicies(plc1 *policiesv1.Policy, plc2 *policiesv1.Policy) bool {
+	_, overridden1 := plc1.GetAnnotations()[LocalOverridesAnnotation]
+	_, overridden2 := plc2.GetAnnotations()[LocalOverridesAnnotation]
+
+	if overridden1 != overridden2 {
+		if overridden2 {
+			plc1, plc2 = plc2, plc1
+		}
+
+		override, err := RecordedOverride(plc1)
+		if err != nil || override == nil {
+			return false
+		}
+
+		plc2 = ApplyPolicyOverride(plc2, override)
+	}
+
 	// Compare annotations
-	if !equality.Semantic.DeepEqual(plc1.GetAnnotations(), plc2.GetAnnotations()) {
+	if !equality.Semantic.DeepEqual(SyncedAnnotations(plc1), SyncedAnnotations(plc2)) {
 		return false
 	}
 
@@ -55,6 +209,28 @@ func EquivalentReplicatedPolicies(plc1 *policiesv1.Policy, plc2 *policiesv1.Poli
 	return equality.Semantic.DeepEqual(plc1.Spec, plc2.Spec)
 }
 
+// MatchesPolicySelector returns whether the labels of the policy match the policy label selector of the addon instance.
+// A nil selector matches all policies.
+func MatchesPolicySelector(selector labels.Selector, plc metav1.Object) bool {
+	return selector == nil || selector.Matches(labels.Set(plc.GetLabels()))
+}
+
+// TemplateObject returns the object definition of the policy template, such as to look up its kind and name. nil is
+// returned if the policy template is nil or its object definition is invalid.
+func TemplateObject(policyT *policiesv1.PolicyTemplate) *unstructured.Unstructured {
+	if policyT == nil {
+		return nil
+	}
+
+	tmpl := &unstructured.Unstructured{}
+
+	if err := tmpl.UnmarshalJSON(policyT.ObjectDefinition.Raw); err != nil {
+		return nil
+	}
+
+	return tmpl
+}
+
 // ApplyObjectDefaults marshals an object to JSON using its scheme in order to fill in default
 // fields that would be added on applying the object to the cluster.
 func ApplyObjectDefaults(scheme runtime.Scheme, object *unstructured.Unstructured) error {
@@ -168,3 +344,30 @@ func LogConstructor(controllerName string, kind string, req *reconcile.Request)
 
 	return log
 }
+
+// HubSourceControllerName returns the name of a controller for a hub source. Controller names must be unique, so the
+// name of an additional hub source is appended to the controller name. The primary hub source has no name.
+func HubSourceControllerName(controllerName string, hubSourceName string) string {
+	if hubSourceName == "" {
+		return controllerName
+	}
+
+	return controllerName + "-" + hubSourceName
+}
+
+// SplitAnnotation splits a comma-separated annotation value, ignoring empty values.
+func SplitAnnotation(value string) []string {
+	values := []string{}
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values
+}
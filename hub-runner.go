@@ -0,0 +1,434 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/events"
+	addonutils "open-cluster-management.io/addon-framework/pkg/utils"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/complianceapi"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/hubfailover"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/hubsnapshot"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+	"open-cluster-management.io/governance-policy-framework-addon/tool"
+)
+
+// hubConfigCheckInterval is the interval at which the hub kubeconfig and client certificate are checked for changes.
+const hubConfigCheckInterval = 10 * time.Second
+
+// hubRunner runs the hub manager of a hub source. When the hub kubeconfig or its client certificate changes, the hub
+// manager is stopped, which drains the in-flight reconciles of its controllers, and it's rebuilt with the new
+// credentials along with the event broadcaster to the hub. The reconcilers and their request channels are kept, and the
+// controllers on the managed cluster use a client and event recorders to the hub that are replaced in place. The calls
+// of the client to the hub wait from when the hub manager is stopped until the cache of the new one is synced.
+type hubRunner struct {
+	source     tool.HubSource
+	options    manager.Options
+	healthAddr string
+	// buildManager returns a hub manager for the hub config with the options.
+	buildManager func(options manager.Options, hubCfg *rest.Config) manager.Manager
+	// configCheckInterval is the interval at which the hub credentials are checked for changes.
+	configCheckInterval time.Duration
+	// client forwards to the client of the current hub manager once its cache is synced.
+	client    *utils.ReloadableClient
+	recorders map[string]*utils.ReloadableRecorder
+	// withHub runs with the current hub config until the hub credentials change or the hub changes. It's optional.
+	withHub func(ctx context.Context, hubCfg *rest.Config)
+	// failover selects the hub among the hub and its standby hubs. When it selects another hub, the hub manager is
+	// rebuilt in place for it. It's optional.
+	failover *hubfailover.Monitor
+	// offline is set to run in the hub offline mode from the hub snapshot when the hub is unreachable, so it requires
+	// the hub snapshot to be written.
+	offline bool
+	// policySelector is the policy selector that the hub cache is limited to, whose hub snapshot is loaded.
+	policySelector labels.Selector
+	// probe returns an error when the hub is unreachable.
+	probe hubfailover.ProbeFunc
+	// The following are set to requeue the policies to the status sync once the hub is back from the hub offline mode
+	// or the hub changed, and to run in the hub offline mode, where the spec sync requests are drained. They're
+	// optional.
+	managedClient      client.Client
+	managedReader      client.Reader
+	specSyncRequests   <-chan event.GenericEvent
+	statusSyncRequests chan<- event.GenericEvent
+	// replay is set when the hub is back from the hub offline mode or the hub changed so that the statuses are synced.
+	replay bool
+	// complianceReporter sends the compliance events to the compliance history API on the hub at complianceAPIURL
+	// with the hub credentials. It's pointed to the new hub when the hub changes. It's optional.
+	complianceReporter *complianceapi.Reporter
+	complianceAPIURL   string
+
+	cfg         *rest.Config
+	mgr         manager.Manager
+	broadcaster events.EventBroadcaster
+	setups      []func(hubMgr manager.Manager) error
+}
+
+// newHubRunner returns a hubRunner with a hub manager for the hub config. The event broadcaster to the hub records
+// events until the context is canceled.
+func newHubRunner(
+	ctx context.Context,
+	options manager.Options,
+	healthAddr string,
+	hubCfg *rest.Config,
+	managedCfg *rest.Config,
+	source tool.HubSource,
+) (*hubRunner, error) {
+	broadcaster, err := newHubBroadcaster(ctx, hubCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	runner := &hubRunner{
+		source:     source,
+		options:    options,
+		healthAddr: healthAddr,
+		buildManager: func(options manager.Options, hubCfg *rest.Config) manager.Manager {
+			return getHubManager(options, healthAddr, hubCfg, managedCfg, source)
+		},
+		configCheckInterval: hubConfigCheckInterval,
+		probe:               hubfailover.Probe,
+		recorders:           map[string]*utils.ReloadableRecorder{},
+		cfg:                 hubCfg,
+		broadcaster:         broadcaster,
+	}
+
+	runner.mgr = runner.buildManager(options, hubCfg)
+	runner.client = utils.NewReloadableClient(runner.mgr.GetClient())
+	// The calls to the hub wait until the cache of the hub manager is synced.
+	runner.client.Suspend()
+
+	return runner, nil
+}
+
+// newHubBroadcaster returns an event broadcaster to the hub that records events until the context is canceled or it's
+// shut down.
+func newHubBroadcaster(ctx context.Context, hubCfg *rest.Config) (events.EventBroadcaster, error) {
+	kubeClientHub, err := kubernetes.NewForConfig(hubCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	broadcaster := events.NewBroadcaster(&events.EventSinkImpl{Interface: kubeClientHub.EventsV1()})
+
+	if err := broadcaster.StartRecordingToSinkWithContext(ctx); err != nil {
+		return nil, fmt.Errorf("unable to start the event broadcaster to the hub cluster: %w", err)
+	}
+
+	return broadcaster, nil
+}
+
+// recorder returns an event recorder to the hub for the controller, which is kept across reloads.
+func (r *hubRunner) recorder(controllerName string) events.EventRecorder {
+	if recorder, ok := r.recorders[controllerName]; ok {
+		return recorder
+	}
+
+	recorder := utils.NewReloadableRecorder(r.broadcaster.NewRecorder(eventsScheme, controllerName))
+	r.recorders[controllerName] = recorder
+
+	return recorder
+}
+
+// setup registers controllers on the hub manager and on every hub manager rebuilt after a reload. The controllers of
+// the previous hub manager are stopped by then, so the same reconcilers can be registered again.
+func (r *hubRunner) setup(setup func(hubMgr manager.Manager) error) error {
+	r.setups = append(r.setups, setup)
+
+	return setup(r.mgr)
+}
+
+// Start runs the hub manager and rebuilds it when the hub credentials change or the failover monitor selects another
+// hub, until the context is canceled. When the hub is unreachable, it runs in the hub offline mode until it's back.
+func (r *hubRunner) Start(ctx context.Context) error {
+	for {
+		var changed bool
+		var err error
+
+		if !r.offline {
+			changed, err = r.run(ctx)
+		} else if probeErr := r.probe(ctx, r.cfg); probeErr != nil {
+			log.Info(
+				"The hub is unreachable. Running in the hub offline mode from the hub snapshot.",
+				"hubSource", r.source.Name, "error", probeErr.Error(),
+			)
+
+			changed, err = r.runOffline(ctx)
+			if err == nil && ctx.Err() == nil {
+				// The writes to the hub wait for the cache of the hub manager rather than fail with the offline
+				// client, and the statuses queued in the hub offline mode are synced once it's synced.
+				r.client.Suspend()
+				r.replay = true
+
+				if !changed {
+					log.Info("The hub is back. Leaving the hub offline mode.", "hubSource", r.source.Name)
+
+					continue
+				}
+			}
+		} else {
+			changed, err = r.run(ctx)
+		}
+
+		if err != nil || !changed {
+			return err
+		}
+
+		if !r.changeHub() {
+			log.Info("The hub credentials changed. Rebuilding the hub manager.", "hubSource", r.source.Name)
+		}
+
+		if err := r.reload(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// hubPending returns whether the failover monitor selected another hub.
+func (r *hubRunner) hubPending() bool {
+	if r.failover == nil {
+		return false
+	}
+
+	_, pending := r.failover.Pending()
+
+	return pending
+}
+
+// changeHub switches the hub kubeconfig and the compliance history API endpoint to the hub that the failover monitor
+// selected, if any, and returns whether it did. The status of every policy is then synced to the new hub.
+func (r *hubRunner) changeHub() bool {
+	if r.failover == nil {
+		return false
+	}
+
+	target, pending := r.failover.Pending()
+	if !pending {
+		return false
+	}
+
+	log.Info(
+		"Changing hubs. Rebuilding the hub manager.",
+		"from", r.source.HubConfigFilePathName, "to", r.failover.Hubs[target].Name,
+	)
+
+	r.source.HubConfigFilePathName = r.failover.Hubs[target].Name
+	r.complianceAPIURL = r.failover.Hubs[target].ComplianceAPIURL
+	r.failover.Activate(target)
+	r.replay = r.managedClient != nil
+
+	return true
+}
+
+// newConfigChecker returns a check that fails once when the hub kubeconfig or its client certificate changes.
+func (r *hubRunner) newConfigChecker() (healthz.Checker, error) {
+	configFiles := []string{r.source.HubConfigFilePathName}
+
+	if r.cfg.CertFile != "" {
+		configFiles = append(configFiles, r.cfg.CertFile)
+	}
+
+	configChecker, err := addonutils.NewConfigChecker(
+		utils.HubSourceControllerName("governance-policy-framework-addon2", r.source.Name), configFiles...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to setup a configChecker: %w", err)
+	}
+
+	configChecker.SetReload(true)
+
+	return configChecker.Check, nil
+}
+
+// run runs the current hub manager until the context is canceled, or the hub credentials change or the failover
+// monitor selects another hub, in which case it returns true once the hub manager is stopped.
+func (r *hubRunner) run(ctx context.Context) (bool, error) {
+	configChecker, err := r.newConfigChecker()
+	if err != nil {
+		return false, err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	mgrErr := make(chan error, 1)
+
+	go func() {
+		mgrErr <- r.mgr.Start(runCtx)
+	}()
+
+	replay := r.replay
+	r.replay = false
+
+	// The controllers on the managed cluster switch to the new hub client once it can read from its cache.
+	go func() {
+		if !r.mgr.GetCache().WaitForCacheSync(runCtx) {
+			return
+		}
+
+		r.client.Replace(r.mgr.GetClient())
+
+		if replay {
+			r.replayStatuses(runCtx)
+		}
+	}()
+
+	if r.withHub != nil {
+		go r.withHub(runCtx, r.cfg)
+	}
+
+	ticker := time.NewTicker(r.configCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-mgrErr:
+			return false, err
+		case <-ticker.C:
+			if configChecker(nil) == nil && !r.hubPending() {
+				continue
+			}
+
+			// The calls to the hub from the controllers on the managed cluster wait for the cache of the rebuilt hub
+			// manager rather than go through the client of this one while it stops. Stopping the hub manager waits for
+			// the in-flight reconciles to finish.
+			r.client.Suspend()
+			cancel()
+
+			return true, <-mgrErr
+		}
+	}
+}
+
+// runOffline serves the hub snapshot to the controllers on the managed cluster with a read-only hub client until the
+// context is canceled, the hub credentials change or the failover monitor selects another hub, in which case it returns
+// true, or the hub is reachable again. The template sync keeps enforcing the replicated policies, and the status
+// updates to the hub are queued.
+func (r *hubRunner) runOffline(ctx context.Context) (bool, error) {
+	configChecker, err := r.newConfigChecker()
+	if err != nil {
+		return false, err
+	}
+
+	snapshot, err := hubsnapshot.Load(ctx, r.managedReader, r.source.ClusterNamespace, r.policySelector)
+	if err != nil {
+		log.Error(err, "Failed to load the hub snapshot. Running in the hub offline mode without it.")
+	} else if snapshot == nil {
+		log.Info("There is no hub snapshot. Running in the hub offline mode without it.")
+	}
+
+	r.client.Replace(hubsnapshot.NewOfflineClient(snapshot, r.source.ClusterNamespaceOnHub, r.mgr.GetScheme()))
+
+	if err := hubsnapshot.RestoreEncryptionKey(ctx, r.managedClient, r.source.ClusterNamespace, snapshot); err != nil {
+		log.Error(err, "Failed to restore the replicated policy encryption key from the hub snapshot")
+	}
+
+	// The hub manager isn't running, so its health endpoint is left out of the health of the pod.
+	healthAddressesLock.Lock()
+	delete(healthAddresses, r.healthAddr)
+	healthAddressesLock.Unlock()
+
+	defer func() {
+		healthAddressesLock.Lock()
+		healthAddresses[r.healthAddr] = true
+		healthAddressesLock.Unlock()
+	}()
+
+	ticker := time.NewTicker(r.configCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case <-r.specSyncRequests:
+			// The spec sync reconciles every policy once the hub is back.
+		case <-ticker.C:
+			if configChecker(nil) != nil || r.hubPending() {
+				return true, nil
+			}
+
+			if r.probe(ctx, r.cfg) == nil {
+				return false, nil
+			}
+		}
+	}
+}
+
+// replayStatuses requeues the policies in the cluster namespace to the status sync so that the statuses queued in the
+// hub offline mode are synced to the hub.
+func (r *hubRunner) replayStatuses(ctx context.Context) {
+	policies := &policiesv1.PolicyList{}
+
+	if err := r.managedClient.List(ctx, policies, client.InNamespace(r.source.ClusterNamespace)); err != nil {
+		log.Error(err, "Failed to list the policies to sync their statuses to the hub")
+
+		return
+	}
+
+	log.Info("Syncing the policy statuses queued in the hub offline mode", "count", len(policies.Items))
+
+	for i := range policies.Items {
+		select {
+		case <-ctx.Done():
+			return
+		case r.statusSyncRequests <- event.GenericEvent{Object: &policies.Items[i]}:
+		}
+	}
+}
+
+// reload rebuilds the hub manager, the event broadcaster to the hub, and the client of the compliance history API
+// reporter with the current hub credentials, and registers the controllers on the new hub manager.
+func (r *hubRunner) reload(ctx context.Context) error {
+	hubCfg, err := clientcmd.BuildConfigFromFlags("", r.source.HubConfigFilePathName)
+	if err != nil {
+		return fmt.Errorf("failed to build the hub cluster config: %w", err)
+	}
+
+	broadcaster, err := newHubBroadcaster(ctx, hubCfg)
+	if err != nil {
+		return err
+	}
+
+	for controllerName, recorder := range r.recorders {
+		recorder.Replace(broadcaster.NewRecorder(eventsScheme, controllerName))
+	}
+
+	r.broadcaster.Shutdown()
+
+	// The controller names of the previous hub manager are still registered, so the names are not validated again.
+	skipNameValidation := true
+	options := r.options
+	options.Controller.SkipNameValidation = &skipNameValidation
+
+	if r.complianceReporter != nil {
+		if err := r.complianceReporter.SetHub(r.complianceAPIURL, hubCfg); err != nil {
+			return fmt.Errorf("failed to point the compliance history API reporter to the hub: %w", err)
+		}
+	}
+
+	r.cfg = hubCfg
+	r.mgr = r.buildManager(options, hubCfg)
+	r.broadcaster = broadcaster
+
+	for _, setup := range r.setups {
+		if err := setup(r.mgr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
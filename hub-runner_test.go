@@ -0,0 +1,661 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/events"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/complianceapi"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/hubfailover"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/hubsnapshot"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/secretsync"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+	"open-cluster-management.io/governance-policy-framework-addon/tool"
+)
+
+// syncingCache is a fake cache that is synced once its synced channel is closed.
+type syncingCache struct {
+	*informertest.FakeInformers
+	synced <-chan struct{}
+}
+
+func (c *syncingCache) WaitForCacheSync(ctx context.Context) bool {
+	select {
+	case <-c.synced:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// testHub is a fake hub with a client that holds the objects written to it and a cache that is synced once synced is
+// closed.
+type testHub struct {
+	client client.Client
+	synced chan struct{}
+}
+
+func newTestHub() *testHub {
+	return &testHub{
+		client: fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&policiesv1.Policy{}).Build(),
+		synced: make(chan struct{}),
+	}
+}
+
+// writeHubKubeconfig writes a kubeconfig for the hub server to the path.
+func writeHubKubeconfig(t *testing.T, path string, server string) {
+	t.Helper()
+
+	writeHubKubeconfigWithToken(t, path, server, "token")
+}
+
+// writeHubKubeconfigWithToken writes a kubeconfig for the hub server with the token to the path.
+func writeHubKubeconfigWithToken(t *testing.T, path string, server string, token string) {
+	t.Helper()
+
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters["hub"] = &clientcmdapi.Cluster{Server: server}
+	kubeconfig.AuthInfos["hub"] = &clientcmdapi.AuthInfo{Token: token}
+	kubeconfig.Contexts["hub"] = &clientcmdapi.Context{Cluster: "hub", AuthInfo: "hub"}
+	kubeconfig.CurrentContext = "hub"
+
+	if err := clientcmd.WriteToFile(*kubeconfig, path); err != nil {
+		t.Fatalf("Failed to write the hub kubeconfig: %v", err)
+	}
+}
+
+// newTestHubRunner returns a hubRunner for the hub kubeconfig whose hub managers use the fake hubs by server. The hub
+// servers that the hub managers are built for are sent to the built channel.
+func newTestHubRunner(
+	t *testing.T, kubeconfig string, hubs map[string]*testHub, built chan<- string,
+) *hubRunner {
+	t.Helper()
+
+	hubCfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		t.Fatalf("Failed to build the hub config: %v", err)
+	}
+
+	runner := &hubRunner{
+		source: tool.HubSource{HubConfigFilePathName: kubeconfig, ClusterNamespaceOnHub: "managed-hub"},
+		buildManager: func(_ manager.Options, hubCfg *rest.Config) manager.Manager {
+			hub := hubs[hubCfg.Host]
+
+			mgr, err := ctrl.NewManager(hubCfg, manager.Options{
+				Scheme:                 scheme,
+				Metrics:                metricsserver.Options{BindAddress: "0"},
+				HealthProbeBindAddress: "0",
+				MapperProvider: func(*rest.Config, *http.Client) (meta.RESTMapper, error) {
+					return hub.client.RESTMapper(), nil
+				},
+				NewCache: func(*rest.Config, cache.Options) (cache.Cache, error) {
+					return &syncingCache{FakeInformers: &informertest.FakeInformers{}, synced: hub.synced}, nil
+				},
+				NewClient: func(*rest.Config, client.Options) (client.Client, error) {
+					return hub.client, nil
+				},
+			})
+			if err != nil {
+				t.Errorf("Failed to build the hub manager: %v", err)
+			}
+
+			built <- hubCfg.Host
+
+			return mgr
+		},
+		configCheckInterval: 10 * time.Millisecond,
+		recorders:           map[string]*utils.ReloadableRecorder{},
+		cfg:                 hubCfg,
+		broadcaster: events.NewBroadcaster(
+			&events.EventSinkImpl{Interface: kubefake.NewClientset().EventsV1()},
+		),
+	}
+
+	runner.mgr = runner.buildManager(runner.options, hubCfg)
+	runner.client = utils.NewReloadableClient(runner.mgr.GetClient())
+	runner.client.Suspend()
+
+	return runner
+}
+
+func TestHubRunnerReload(t *testing.T) {
+	t.Parallel()
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	writeHubKubeconfig(t, kubeconfig, "https://hub1.example.com")
+
+	hub1 := newTestHub()
+	hub2 := newTestHub()
+	built := make(chan string, 2)
+
+	runner := newTestHubRunner(
+		t, kubeconfig, map[string]*testHub{"https://hub1.example.com": hub1, "https://hub2.example.com": hub2}, built,
+	)
+
+	if host := <-built; host != "https://hub1.example.com" {
+		t.Fatalf("Expected the hub manager of the first hub but got %s", host)
+	}
+
+	setupMgrs := make(chan manager.Manager, 2)
+
+	err := runner.setup(func(hubMgr manager.Manager) error {
+		setupMgrs <- hubMgr
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up the hub manager: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	started := make(chan error, 1)
+
+	go func() {
+		started <- runner.Start(ctx)
+	}()
+
+	close(hub1.synced)
+
+	create := func(ctx context.Context, name string) error {
+		return runner.client.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}})
+	}
+
+	if err := create(ctx, "before-reload"); err != nil {
+		t.Fatalf("Failed to write to the first hub: %v", err)
+	}
+
+	writeHubKubeconfig(t, kubeconfig, "https://hub2.example.com")
+
+	select {
+	case host := <-built:
+		if host != "https://hub2.example.com" {
+			t.Fatalf("Expected the hub manager of the second hub but got %s", host)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the hub manager to be rebuilt after the hub kubeconfig changed")
+	}
+
+	// The client is suspended before the previous hub manager is stopped, so the writes wait for the new cache.
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer timeoutCancel()
+
+	if err := create(timeoutCtx, "suspended"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the write to wait for the cache of the rebuilt hub manager but got: %v", err)
+	}
+
+	written := make(chan error, 1)
+
+	go func() {
+		written <- create(ctx, "after-reload")
+	}()
+
+	close(hub2.synced)
+
+	if err := <-written; err != nil {
+		t.Fatalf("Failed to write to the second hub: %v", err)
+	}
+
+	for hub, names := range map[*testHub][]string{hub1: {"before-reload"}, hub2: {"after-reload"}} {
+		configMaps := &corev1.ConfigMapList{}
+
+		if err := hub.client.List(ctx, configMaps); err != nil {
+			t.Fatalf("Failed to list the config maps: %v", err)
+		}
+
+		if len(configMaps.Items) != len(names) || configMaps.Items[0].Name != names[0] {
+			t.Fatalf("Expected the config maps %v on the hub but got %v", names, configMaps.Items)
+		}
+	}
+
+	cancel()
+
+	if err := <-started; err != nil {
+		t.Fatalf("Expected the hub runner to stop without an error but got: %v", err)
+	}
+
+	if len(setupMgrs) != 2 {
+		t.Fatalf("Expected the controllers to be set up on both hub managers but got %d", len(setupMgrs))
+	}
+
+	<-setupMgrs
+
+	if hubMgr := <-setupMgrs; hubMgr != runner.mgr {
+		t.Fatal("Expected the controllers to be set up on the rebuilt hub manager")
+	}
+
+	if runner.cfg.Host != "https://hub2.example.com" {
+		t.Fatalf("Expected the hub config of the second hub but got %s", runner.cfg.Host)
+	}
+
+	// The rebuilt hub manager has the client of the second hub.
+	key := types.NamespacedName{Namespace: "ns", Name: "after-reload"}
+
+	if err := runner.mgr.GetClient().Get(context.TODO(), key, &corev1.ConfigMap{}); err != nil {
+		t.Fatalf("Expected the client of the rebuilt hub manager to be the second hub's but got: %v", err)
+	}
+}
+
+func TestHubRunnerReloadComplianceReporter(t *testing.T) {
+	t.Parallel()
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	writeHubKubeconfigWithToken(t, kubeconfig, "https://hub1.example.com", "token1")
+
+	hub := newTestHub()
+	close(hub.synced)
+
+	built := make(chan string, 2)
+	runner := newTestHubRunner(t, kubeconfig, map[string]*testHub{"https://hub1.example.com": hub}, built)
+
+	<-built
+
+	authorizations := make(chan string, 2)
+
+	complianceAPI := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		authorizations <- r.Header.Get("Authorization")
+	}))
+	defer complianceAPI.Close()
+
+	reporter, err := complianceapi.NewReporter(complianceAPI.URL, runner.cfg, 2)
+	if err != nil {
+		t.Fatalf("Failed to create the compliance history API reporter: %v", err)
+	}
+
+	runner.complianceReporter = reporter
+	runner.complianceAPIURL = complianceAPI.URL
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	started := make(chan error, 1)
+
+	go func() {
+		started <- runner.Start(ctx)
+	}()
+
+	go func() {
+		_ = reporter.Start(ctx)
+	}()
+
+	expectAuthorization := func(expected string) {
+		t.Helper()
+
+		reporter.Report(complianceapi.ComplianceEvent{Event: complianceapi.Event{Compliance: "Compliant"}})
+
+		select {
+		case authorization := <-authorizations:
+			if authorization != expected {
+				t.Fatalf("Expected the compliance event to be sent with %s but got %s", expected, authorization)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("Expected the compliance event to be sent")
+		}
+	}
+
+	expectAuthorization("Bearer token1")
+
+	writeHubKubeconfigWithToken(t, kubeconfig, "https://hub1.example.com", "token2")
+
+	select {
+	case <-built:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the hub manager to be rebuilt after the hub credentials changed")
+	}
+
+	expectAuthorization("Bearer token2")
+
+	cancel()
+
+	if err := <-started; err != nil {
+		t.Fatalf("Expected the hub runner to stop without an error but got: %v", err)
+	}
+}
+
+func TestHubRunnerFailover(t *testing.T) {
+	t.Parallel()
+
+	hubs := map[string]*testHub{"https://hub1.example.com": newTestHub(), "https://hub2.example.com": newTestHub()}
+	failoverHubs := make([]hubfailover.Hub, 0, 2)
+	// The compliance events received by the compliance history API of each hub.
+	complianceEvents := make(chan string, 3)
+
+	for i, server := range []string{"https://hub1.example.com", "https://hub2.example.com"} {
+		kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+		writeHubKubeconfig(t, kubeconfig, server)
+
+		complianceAPI := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			complianceEvents <- server
+		}))
+		defer complianceAPI.Close()
+
+		failoverHubs = append(failoverHubs, hubfailover.Hub{
+			Name: kubeconfig, Config: &rest.Config{Host: server}, ComplianceAPIURL: complianceAPI.URL,
+		})
+
+		// The second hub's cache syncs once the hub manager is rebuilt for it.
+		if i == 0 {
+			close(hubs[server].synced)
+		}
+	}
+
+	var hub1Down atomic.Bool
+
+	monitor := hubfailover.NewMonitor(failoverHubs, 0, 0)
+	monitor.Interval = 10 * time.Millisecond
+	monitor.Probe = func(_ context.Context, cfg *rest.Config) error {
+		if cfg.Host == "https://hub1.example.com" && hub1Down.Load() {
+			return errors.New("connection refused")
+		}
+
+		return nil
+	}
+
+	built := make(chan string, 3)
+	leases := make(chan string, 3)
+
+	reporter, err := complianceapi.NewReporter(failoverHubs[0].ComplianceAPIURL, failoverHubs[0].Config, 3)
+	if err != nil {
+		t.Fatalf("Failed to create the compliance history API reporter: %v", err)
+	}
+
+	runner := newTestHubRunner(t, failoverHubs[0].Name, hubs, built)
+	runner.failover = monitor
+	runner.complianceReporter = reporter
+	runner.complianceAPIURL = failoverHubs[0].ComplianceAPIURL
+	runner.withHub = func(_ context.Context, hubCfg *rest.Config) {
+		leases <- hubCfg.Host
+	}
+
+	<-built
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	started := make(chan error, 1)
+
+	go func() {
+		started <- runner.Start(ctx)
+	}()
+
+	go func() {
+		_ = monitor.Start(ctx)
+	}()
+
+	go func() {
+		_ = reporter.Start(ctx)
+	}()
+
+	expectHub := func(received <-chan string, host string, what string) {
+		t.Helper()
+
+		select {
+		case got := <-received:
+			if got != host {
+				t.Fatalf("Expected the %s of %s but got %s", what, host, got)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Expected the %s of %s", what, host)
+		}
+	}
+
+	create := func(name string) {
+		t.Helper()
+
+		err := runner.client.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}})
+		if err != nil {
+			t.Fatalf("Failed to write to the hub: %v", err)
+		}
+	}
+
+	report := func() {
+		reporter.Report(complianceapi.ComplianceEvent{Event: complianceapi.Event{Compliance: "Compliant"}})
+	}
+
+	expectHub(leases, "https://hub1.example.com", "lease")
+	create("before-failover")
+	report()
+	expectHub(complianceEvents, "https://hub1.example.com", "compliance event")
+
+	hub1Down.Store(true)
+
+	expectHub(built, "https://hub2.example.com", "hub manager")
+	expectHub(leases, "https://hub2.example.com", "lease")
+
+	close(hubs["https://hub2.example.com"].synced)
+	create("after-failover")
+	report()
+	expectHub(complianceEvents, "https://hub2.example.com", "compliance event")
+
+	hub1Down.Store(false)
+
+	expectHub(built, "https://hub1.example.com", "hub manager")
+	expectHub(leases, "https://hub1.example.com", "lease")
+	create("after-failback")
+	report()
+	expectHub(complianceEvents, "https://hub1.example.com", "compliance event")
+
+	cancel()
+
+	if err := <-started; err != nil {
+		t.Fatalf("Expected the hub runner to stop without an error but got: %v", err)
+	}
+
+	if runner.source.HubConfigFilePathName != failoverHubs[0].Name {
+		t.Fatalf("Expected the kubeconfig of the first hub but got %s", runner.source.HubConfigFilePathName)
+	}
+
+	for host, names := range map[string][]string{
+		"https://hub1.example.com": {"after-failback", "before-failover"},
+		"https://hub2.example.com": {"after-failover"},
+	} {
+		configMaps := &corev1.ConfigMapList{}
+
+		if err := hubs[host].client.List(context.TODO(), configMaps); err != nil {
+			t.Fatalf("Failed to list the config maps: %v", err)
+		}
+
+		got := make([]string, 0, len(configMaps.Items))
+
+		for _, configMap := range configMaps.Items {
+			got = append(got, configMap.Name)
+		}
+
+		if !slices.Equal(got, names) {
+			t.Fatalf("Expected the config maps %v on %s but got %v", names, host, got)
+		}
+	}
+}
+
+func TestHubRunnerOffline(t *testing.T) {
+	t.Parallel()
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	writeHubKubeconfig(t, kubeconfig, "https://hub1.example.com")
+
+	hub := newTestHub()
+
+	hubPolicy := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policies.a", Namespace: "managed-hub"}}
+	encryptionKey := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretsync.SecretName, Namespace: "managed-hub"},
+		Data:       map[string][]byte{"key": []byte("secret")},
+	}
+
+	for _, obj := range []client.Object{hubPolicy, encryptionKey} {
+		if err := hub.client.Create(context.TODO(), obj); err != nil {
+			t.Fatalf("Failed to create the hub object: %v", err)
+		}
+	}
+
+	managedClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policies.a", Namespace: "managed"}},
+		&policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policies.b", Namespace: "managed"}},
+	).Build()
+
+	// Write the hub snapshot that the hub offline mode is served from.
+	writerCtx, writerCancel := context.WithCancel(context.TODO())
+
+	go func() {
+		_ = (&hubsnapshot.Writer{
+			HubClient:             hub.client,
+			ManagedClient:         managedClient,
+			ManagedReader:         managedClient,
+			ClusterNamespaceOnHub: "managed-hub",
+			TargetNamespace:       "managed",
+			Interval:              time.Hour,
+		}).Start(writerCtx)
+	}()
+
+	snapshotKey := types.NamespacedName{Namespace: "managed", Name: hubsnapshot.SecretName}
+
+	for managedClient.Get(context.TODO(), snapshotKey, &corev1.Secret{}) != nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writerCancel()
+
+	// The encryption key is restored from the hub snapshot.
+	err := managedClient.Delete(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretsync.SecretName, Namespace: "managed"},
+	})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		t.Fatalf("Failed to delete the replicated encryption key: %v", err)
+	}
+
+	var hubDown atomic.Bool
+
+	hubDown.Store(true)
+
+	specSyncRequests := make(chan event.GenericEvent)
+	statusSyncRequests := make(chan event.GenericEvent, 10)
+
+	runner := newTestHubRunner(
+		t, kubeconfig, map[string]*testHub{"https://hub1.example.com": hub}, make(chan string, 1),
+	)
+	runner.source.ClusterNamespace = "managed"
+	runner.offline = true
+	runner.probe = func(context.Context, *rest.Config) error {
+		if hubDown.Load() {
+			return errors.New("connection refused")
+		}
+
+		return nil
+	}
+	runner.managedClient = managedClient
+	runner.managedReader = managedClient
+	runner.specSyncRequests = specSyncRequests
+	runner.statusSyncRequests = statusSyncRequests
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	started := make(chan error, 1)
+
+	go func() {
+		started <- runner.Start(ctx)
+	}()
+
+	// The hub policy is served from the hub snapshot while the hub is unreachable.
+	plc := &policiesv1.Policy{}
+
+	if err := runner.client.Get(ctx, client.ObjectKeyFromObject(hubPolicy), plc); err != nil {
+		t.Fatalf("Expected the hub policy from the hub snapshot but got: %v", err)
+	}
+
+	if err := runner.client.Status().Update(ctx, plc); !errors.Is(err, utils.ErrHubOffline) {
+		t.Fatalf("Expected the status update to be rejected in the hub offline mode but got: %v", err)
+	}
+
+	err = managedClient.Get(
+		ctx, types.NamespacedName{Namespace: "managed", Name: secretsync.SecretName}, &corev1.Secret{},
+	)
+	if err != nil {
+		t.Fatalf("Expected the encryption key to be restored from the hub snapshot but got: %v", err)
+	}
+
+	select {
+	case specSyncRequests <- event.GenericEvent{Object: plc}:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the spec sync requests to be drained in the hub offline mode")
+	}
+
+	hubDown.Store(false)
+
+	// Once the hub is back, the writes to the hub wait for the cache of the hub manager.
+	for {
+		timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		err := runner.client.Status().Update(timeoutCtx, plc)
+
+		timeoutCancel()
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+
+		if !errors.Is(err, utils.ErrHubOffline) {
+			t.Fatalf("Expected the status update to wait for the hub but got: %v", err)
+		}
+	}
+
+	if len(statusSyncRequests) != 0 {
+		t.Fatal("Expected the statuses to be replayed once the cache of the hub manager is synced")
+	}
+
+	close(hub.synced)
+
+	replayed := []string{}
+
+	for range 2 {
+		select {
+		case request := <-statusSyncRequests:
+			replayed = append(replayed, request.Object.GetName())
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Expected the statuses of the policies to be replayed but got %v", replayed)
+		}
+	}
+
+	slices.Sort(replayed)
+
+	if !slices.Equal(replayed, []string{"policies.a", "policies.b"}) {
+		t.Fatalf("Expected the statuses of the policies to be replayed but got %v", replayed)
+	}
+
+	if err := runner.client.Status().Update(ctx, plc); err != nil {
+		t.Fatalf("Expected the status update to go to the hub but got: %v", err)
+	}
+
+	cancel()
+
+	if err := <-started; err != nil {
+		t.Fatalf("Expected the hub runner to stop without an error but got: %v", err)
+	}
+}
@@ -5,6 +5,8 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
@@ -13,6 +15,7 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -45,6 +48,7 @@ import (
 	"k8s.io/klog/v2"
 	"open-cluster-management.io/addon-framework/pkg/lease"
 	addonutils "open-cluster-management.io/addon-framework/pkg/utils"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -53,14 +57,23 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/complianceapi"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/compliancemetrics"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/gatekeepersync"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/hubfailover"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/hubsnapshot"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/notifications"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/oscal"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/policyreport"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/secretsync"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/specsync"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/statussync"
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/summary"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/templatesync"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/uninstall"
 	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
@@ -97,6 +110,15 @@ func main() {
 		return
 	}
 
+	if len(os.Args) >= 2 && os.Args[1] == "oscal-export" {
+		if err := oscal.Export(os.Args[2:]); err != nil {
+			log.Error(err, "Failed to export the OSCAL assessment results")
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	zflags := zaputil.FlagConfig{
 		LevelName:   "log-level",
 		EncoderName: "log-encoder",
@@ -128,12 +150,34 @@ func main() {
 
 	printVersion()
 
-	hubCfg, err := clientcmd.BuildConfigFromFlags("", tool.Options.HubConfigFilePathName)
-	if err != nil {
-		log.Error(err, "Failed to build hub cluster config")
-		os.Exit(1)
+	// The hub and its standby hubs are the same logical hub. The first reachable one is used.
+	hubConfigFiles := tool.HubConfigFiles()
+	hubComplianceAPIURLs := tool.HubComplianceAPIURLs()
+	hubs := make([]hubfailover.Hub, 0, len(hubConfigFiles))
+
+	for i, hubConfigFile := range hubConfigFiles {
+		hubCfg, err := clientcmd.BuildConfigFromFlags("", hubConfigFile)
+		if err != nil {
+			log.Error(err, "Failed to build hub cluster config", "kubeconfig", hubConfigFile)
+			os.Exit(1)
+		}
+
+		hubs = append(hubs, hubfailover.Hub{
+			Name: hubConfigFile, Config: hubCfg, ComplianceAPIURL: hubComplianceAPIURLs[i],
+		})
 	}
 
+	activeHub := 0
+
+	if len(hubs) > 1 {
+		activeHub = hubfailover.SelectHub(context.TODO(), hubs, hubfailover.Probe)
+
+		log.Info("Using the hub cluster config", "kubeconfig", hubs[activeHub].Name)
+	}
+
+	hubCfg := hubs[activeHub].Config
+	hubConfigFile := hubs[activeHub].Name
+
 	// Get managedconfig to talk to managed apiserver
 	var managedCfg *rest.Config
 
@@ -201,9 +245,9 @@ func main() {
 	// This lease is not related to leader election. This is to report the status of the controller
 	// to the addon framework. This can be seen in the "status" section of the ManagedClusterAddOn
 	// resource objects.
-	if tool.Options.EnableLease {
-		ctx := context.Background()
+	var startLease func(ctx context.Context, hubCfg *rest.Config)
 
+	if tool.Options.EnableLease {
 		operatorNs, err := tool.GetOperatorNamespace()
 		if err != nil {
 			if errors.Is(err, tool.ErrNoNamespace) || errors.Is(err, tool.ErrRunLocal) {
@@ -216,11 +260,13 @@ func main() {
 			log.Info("Starting lease controller to report status")
 
 			generatedClient := kubernetes.NewForConfigOrDie(managedCfg)
-			leaseUpdater := lease.NewLeaseUpdater(
-				generatedClient, "governance-policy-framework", operatorNs,
-			).WithHubLeaseConfig(hubCfg, tool.Options.ClusterNamespaceOnHub)
 
-			go leaseUpdater.Start(ctx)
+			// The lease on the hub is restarted with the new hub config when the hub credentials change.
+			startLease = func(ctx context.Context, hubCfg *rest.Config) {
+				lease.NewLeaseUpdater(
+					generatedClient, "governance-policy-framework", operatorNs,
+				).WithHubLeaseConfig(hubCfg, tool.Options.ClusterNamespaceOnHub).Start(ctx)
+			}
 		}
 	} else {
 		log.Info("Status reporting is not enabled")
@@ -238,9 +284,40 @@ func main() {
 	mainCtx := ctrl.SetupSignalHandler()
 	mgrCtx, mgrCtxCancel := context.WithCancel(mainCtx)
 
-	mgr := getManager(mgrOptionsBase, mgrHealthAddr, hubCfg, managedCfg)
+	mgr := getManager(mgrOptionsBase, mgrHealthAddr, hubCfg, managedCfg, leaderElectionID(managedLeaderElectionID))
 
-	var hubMgr manager.Manager
+	// The controllers on the managed cluster that aren't scoped by the policy selector run in a manager with the leader
+	// election ID of an addon instance without a policy selector, so that only one of the addon instances runs them.
+	sharedMgr := mgr
+
+	if tool.Options.PolicyLabelSelector != nil {
+		sharedMgrHealthAddr, err := getFreeLocalAddr()
+		if err != nil {
+			log.Error(err, "Failed to get a free port for the health endpoint")
+			os.Exit(1)
+		}
+
+		healthAddresses[sharedMgrHealthAddr] = true
+
+		sharedMgrOptions := mgrOptionsBase
+		// The metrics of this manager are exposed by the other manager since they use the global metrics registry.
+		sharedMgrOptions.Metrics.BindAddress = "0"
+
+		sharedMgr = getManager(sharedMgrOptions, sharedMgrHealthAddr, hubCfg, managedCfg, managedLeaderElectionID)
+	}
+
+	var failover *hubfailover.Monitor
+
+	if len(hubs) > 1 {
+		failover = hubfailover.NewMonitor(hubs, activeHub, tool.Options.HubFailoverPeriod)
+
+		if err := mgr.Add(failover); err != nil {
+			log.Error(err, "Unable to add the hub failover monitor to the manager")
+			os.Exit(1)
+		}
+	}
+
+	var primaryHubRunner *hubRunner
 
 	if !tool.Options.OnMulticlusterhub {
 		hubMgrHealthAddr, err := getFreeLocalAddr()
@@ -251,7 +328,51 @@ func main() {
 
 		healthAddresses[hubMgrHealthAddr] = true
 
-		hubMgr = getHubManager(mgrOptionsBase, hubMgrHealthAddr, hubCfg, managedCfg)
+		primarySource := tool.HubSource{
+			HubConfigFilePathName: hubConfigFile,
+			ClusterNamespaceOnHub: tool.Options.ClusterNamespaceOnHub,
+			ClusterNamespace:      tool.Options.ClusterNamespace,
+		}
+
+		primaryHubRunner, err = newHubRunner(
+			mgrCtx, mgrOptionsBase, hubMgrHealthAddr, hubCfg, managedCfg, primarySource,
+		)
+		if err != nil {
+			log.Error(err, "Failed to set up the hub manager")
+			os.Exit(1)
+		}
+
+		primaryHubRunner.withHub = startLease
+		primaryHubRunner.failover = failover
+		primaryHubRunner.complianceAPIURL = hubs[activeHub].ComplianceAPIURL
+	} else if startLease != nil {
+		go startLease(context.Background(), hubCfg)
+	}
+
+	hubSourceRunners := make([]*hubRunner, 0, len(tool.Options.HubSources))
+
+	for _, source := range tool.Options.HubSources {
+		sourceCfg, err := clientcmd.BuildConfigFromFlags("", source.HubConfigFilePathName)
+		if err != nil {
+			log.Error(err, "Failed to build hub cluster config", "hubSource", source.Name)
+			os.Exit(1)
+		}
+
+		sourceHealthAddr, err := getFreeLocalAddr()
+		if err != nil {
+			log.Error(err, "Failed to get a free port for the health endpoint")
+			os.Exit(1)
+		}
+
+		healthAddresses[sourceHealthAddr] = true
+
+		sourceRunner, err := newHubRunner(mgrCtx, mgrOptionsBase, sourceHealthAddr, sourceCfg, managedCfg, source)
+		if err != nil {
+			log.Error(err, "Failed to set up the hub manager", "hubSource", source.Name)
+			os.Exit(1)
+		}
+
+		hubSourceRunners = append(hubSourceRunners, sourceRunner)
 	}
 
 	healthAddressesLock.Unlock()
@@ -273,7 +394,17 @@ func main() {
 		if semver.Compare(serverVersion.GitVersion, "v1.16.0") >= 0 {
 			dynamicClient := dynamic.NewForConfigOrDie(managedCfg)
 
-			go manageGatekeeperSyncManager(mgrCtx, &wg, managedCfg, dynamicClient, mgrOptionsBase)
+			go manageCRDGatedManager(
+				mgrCtx,
+				&wg,
+				dynamicClient,
+				"constrainttemplates."+utils.GvkConstraintTemplate.Group,
+				"Gatekeeper",
+				gatekeepersync.ControllerName,
+				func(ctx context.Context) error {
+					return runGatekeeperSyncManager(ctx, managedCfg, mgrOptionsBase)
+				},
+			)
 		} else {
 			log.Info("The Gatekeeper integration is disabled due to the Kubernetes version being less than 1.16.0")
 		}
@@ -281,9 +412,25 @@ func main() {
 		log.Info("The Gatekeeper integration is set to disabled")
 	}
 
+	if tool.Options.EnablePolicyReports {
+		dynamicClient := dynamic.NewForConfigOrDie(managedCfg)
+
+		go manageCRDGatedManager(
+			mgrCtx,
+			&wg,
+			dynamicClient,
+			policyreport.CRDName,
+			"PolicyReport",
+			policyreport.ControllerName,
+			func(ctx context.Context) error {
+				return runPolicyReportManager(ctx, managedCfg, mgrOptionsBase)
+			},
+		)
+	}
+
 	log.Info("Adding controllers to managers")
 
-	addControllers(mgrCtx, hubCfg, hubMgr, mgr)
+	addControllers(mgrCtx, hubCfg, primaryHubRunner, mgr, sharedMgr, hubSourceRunners)
 
 	log.Info("Starting the controller managers")
 
@@ -312,6 +459,19 @@ func main() {
 		}
 	})
 
+	if sharedMgr != mgr {
+		wg.Go(func() {
+			if err := sharedMgr.Start(mgrCtx); err != nil {
+				log.Error(err, "problem running the shared manager")
+
+				// On errors, the parent context (mainCtx) may not have closed, so cancel the child context.
+				mgrCtxCancel()
+
+				errorExit = true
+			}
+		})
+	}
+
 	operatorNs, err := tool.GetOperatorNamespace()
 
 	if errors.Is(err, tool.ErrRunLocal) {
@@ -339,7 +499,7 @@ func main() {
 
 	if !tool.Options.OnMulticlusterhub {
 		wg.Go(func() {
-			if err := hubMgr.Start(mgrCtx); err != nil {
+			if err := primaryHubRunner.Start(mgrCtx); err != nil {
 				log.Error(err, "problem running hub manager")
 
 				// On errors, the parent context (mainCtx) may not have closed, so cancel the child context.
@@ -350,6 +510,19 @@ func main() {
 		})
 	}
 
+	for _, sourceRunner := range hubSourceRunners {
+		wg.Go(func() {
+			if err := sourceRunner.Start(mgrCtx); err != nil {
+				log.Error(err, "problem running hub manager", "hubSource", sourceRunner.source.Name)
+
+				// On errors, the parent context (mainCtx) may not have closed, so cancel the child context.
+				mgrCtxCancel()
+
+				errorExit = true
+			}
+		})
+	}
+
 	wg.Wait()
 
 	if errorExit {
@@ -359,11 +532,11 @@ func main() {
 
 // getManager return a controller Manager object that watches on the managed cluster and has the controllers registered.
 func getManager(
-	options manager.Options, healthAddr string, hubCfg *rest.Config, managedCfg *rest.Config,
+	options manager.Options, healthAddr string, hubCfg *rest.Config, managedCfg *rest.Config, electionID string,
 ) manager.Manager {
 	crdLabelSelector := labels.SelectorFromSet(map[string]string{utils.PolicyTypeLabel: "template"})
 
-	options.LeaderElectionID = "governance-policy-framework-addon.open-cluster-management.io"
+	options.LeaderElectionID = electionID
 	options.HealthProbeBindAddress = healthAddr
 	options.Client = client.Options{
 		Cache: &client.CacheOptions{
@@ -373,50 +546,45 @@ func getManager(
 			},
 		},
 	}
+	// The policies of each hub source are synced to their own namespace on the managed cluster.
+	eventNamespaces := map[string]cache.Config{}
+	secretNamespaces := map[string]cache.Config{}
+	defaultNamespaces := map[string]cache.Config{}
+
+	for _, namespace := range tool.ManagedNamespaces() {
+		eventNamespaces[namespace] = cache.Config{
+			// Filter out events not related to policy compliance
+			FieldSelector: fields.ParseSelectorOrDie(`involvedObject.kind=Policy,` +
+				`reason!="PolicySpecSync",` +
+				`reason!="PolicyTemplateSync",` +
+				`reason!="PolicyStatusSync"`,
+			),
+			// Only cache fields that are utilized by the controllers.
+			Transform: statussync.TransformEvent,
+		}
+		secretNamespaces[namespace] = cache.Config{
+			FieldSelector: fields.SelectorFromSet(fields.Set{"metadata.name": secretsync.SecretName}),
+		}
+		defaultNamespaces[namespace] = cache.Config{}
+	}
+
 	options.Cache = cache.Options{
 		ByObject: map[client.Object]cache.ByObject{
 			&extensionsv1.CustomResourceDefinition{}: {
 				Label: crdLabelSelector,
 			},
 			&v1.Event{}: {
-				Namespaces: map[string]cache.Config{
-					tool.Options.ClusterNamespace: {
-						// Filter out events not related to policy compliance
-						FieldSelector: fields.ParseSelectorOrDie(`involvedObject.kind=Policy,` +
-							`reason!="PolicySpecSync",` +
-							`reason!="PolicyTemplateSync",` +
-							`reason!="PolicyStatusSync"`,
-						),
-						Transform: func(obj interface{}) (interface{}, error) {
-							event := obj.(*v1.Event)
-							// Only cache fields that are utilized by the controllers.
-							return &v1.Event{
-								InvolvedObject: event.InvolvedObject,
-								TypeMeta:       event.TypeMeta,
-								ObjectMeta: metav1.ObjectMeta{
-									Name:      event.Name,
-									Namespace: event.Namespace,
-									UID:       event.UID,
-								},
-								LastTimestamp: event.LastTimestamp,
-								Message:       event.Message,
-								Reason:        event.Reason,
-							}, nil
-						},
-					},
-				},
+				Namespaces: eventNamespaces,
 			},
 			&v1.Secret{}: {
-				Namespaces: map[string]cache.Config{
-					tool.Options.ClusterNamespace: {
-						FieldSelector: fields.SelectorFromSet(fields.Set{"metadata.name": secretsync.SecretName}),
-					},
-				},
+				Namespaces: secretNamespaces,
+			},
+			// The compliance summary and local overrides ConfigMaps are in the cluster namespaces.
+			&v1.ConfigMap{}: {
+				Namespaces: defaultNamespaces,
 			},
 		},
-		DefaultNamespaces: map[string]cache.Config{
-			tool.Options.ClusterNamespace: {},
-		},
+		DefaultNamespaces: defaultNamespaces,
 	}
 
 	mgr, err := ctrl.NewManager(managedCfg, options)
@@ -425,21 +593,29 @@ func getManager(
 		os.Exit(1)
 	}
 
-	configFiles := []string{tool.Options.HubConfigFilePathName}
+	var healthzCheck healthz.Checker = healthz.Ping
 
-	if hubCfg.CertFile != "" {
-		configFiles = append(configFiles, hubCfg.CertFile)
-	}
+	// The hub managers are rebuilt when the hub credentials change, except when running on the hub, where the hub
+	// client is bound to the hub config for the lifetime of the process. A restart is then needed.
+	if tool.Options.OnMulticlusterhub {
+		configFiles := []string{tool.Options.HubConfigFilePathName}
 
-	// use config check
-	configChecker, err := addonutils.NewConfigChecker("governance-policy-framework-addon", configFiles...)
-	if err != nil {
-		log.Error(err, "unable to setup a configChecker")
-		os.Exit(1)
+		if hubCfg.CertFile != "" {
+			configFiles = append(configFiles, hubCfg.CertFile)
+		}
+
+		// use config check
+		configChecker, err := addonutils.NewConfigChecker("governance-policy-framework-addon", configFiles...)
+		if err != nil {
+			log.Error(err, "unable to setup a configChecker")
+			os.Exit(1)
+		}
+
+		healthzCheck = configChecker.Check
 	}
 
 	//+kubebuilder:scaffold:builder
-	if err := mgr.AddHealthzCheck("healthz", configChecker.Check); err != nil {
+	if err := mgr.AddHealthzCheck("healthz", healthzCheck); err != nil {
 		log.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
@@ -452,28 +628,45 @@ func getManager(
 	return mgr
 }
 
-// getHubManager return a controller Manager object that watches on the Hub and has the controllers registered.
+// getHubManager return a controller Manager object that watches on the Hub of the hub source and has the controllers
+// registered. The primary hub source has no name.
 func getHubManager(
-	options manager.Options, healthAddr string, hubCfg *rest.Config, managedCfg *rest.Config,
+	options manager.Options, healthAddr string, hubCfg *rest.Config, managedCfg *rest.Config, source tool.HubSource,
 ) manager.Manager {
 	// Set the manager options
 	options.HealthProbeBindAddress = healthAddr
-	options.LeaderElectionID = "governance-policy-framework-addon2.open-cluster-management.io"
+	options.LeaderElectionID = leaderElectionID(hubLeaderElectionID)
 	options.LeaderElectionConfig = managedCfg
+	// Release the lease when the manager is stopped so that the manager rebuilt after a reload of the hub credentials
+	// doesn't wait for it to expire.
+	options.LeaderElectionReleaseOnCancel = true
+
+	if source.Name != "" {
+		options.LeaderElectionID = source.Name + "." + options.LeaderElectionID
+	}
+
 	// Set a field selector so that a watch on secrets will be limited to just the secret with the policy template
-	// encryption key.
+	// encryption key, and a label selector so that a watch on policies will be limited to the policies synced by this
+	// addon instance.
 	options.Cache = cache.Options{
 		ByObject: map[client.Object]cache.ByObject{
 			&v1.Secret{}: {
 				Namespaces: map[string]cache.Config{
-					tool.Options.ClusterNamespaceOnHub: {
+					source.ClusterNamespaceOnHub: {
 						FieldSelector: fields.SelectorFromSet(fields.Set{"metadata.name": secretsync.SecretName}),
 					},
 				},
 			},
+			&policiesv1.Policy{}: {
+				Namespaces: map[string]cache.Config{
+					source.ClusterNamespaceOnHub: {
+						LabelSelector: tool.Options.PolicyLabelSelector,
+					},
+				},
+			},
 		},
 		DefaultNamespaces: map[string]cache.Config{
-			tool.Options.ClusterNamespaceOnHub: {},
+			source.ClusterNamespaceOnHub: {},
 		},
 	}
 
@@ -488,21 +681,9 @@ func getHubManager(
 		os.Exit(1)
 	}
 
-	configFiles := []string{tool.Options.HubConfigFilePathName}
-
-	if hubCfg.CertFile != "" {
-		configFiles = append(configFiles, hubCfg.CertFile)
-	}
-
-	// use config check
-	configChecker, err := addonutils.NewConfigChecker("governance-policy-framework-addon2", configFiles...)
-	if err != nil {
-		log.Error(err, "unable to setup a configChecker")
-		os.Exit(1)
-	}
-
+	// The hub credentials are checked for changes by the hubRunner, which rebuilds this manager.
 	//+kubebuilder:scaffold:builder
-	if err := mgr.AddHealthzCheck("healthz", configChecker.Check); err != nil {
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		log.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
@@ -629,21 +810,45 @@ func getFreeLocalAddr() (string, error) {
 	return fmt.Sprintf("127.0.0.1:%d", l.Addr().(*net.TCPAddr).Port), nil
 }
 
+// The leader election IDs of the managers.
+const (
+	managedLeaderElectionID      = "governance-policy-framework-addon.open-cluster-management.io"
+	hubLeaderElectionID          = "governance-policy-framework-addon2.open-cluster-management.io"
+	gatekeeperLeaderElectionID   = "governance-policy-framework-addon3.open-cluster-management.io"
+	policyReportLeaderElectionID = "governance-policy-framework-addon4.open-cluster-management.io"
+)
+
+// leaderElectionID returns the leader election ID of a manager of the controllers scoped by --policy-label-selector,
+// which are the spec sync and status sync. Addon instances that sync a subset of the policies have their own leader
+// election IDs for these managers so that they can run side by side, while the other controllers keep the leader
+// election IDs of an addon instance without a policy selector so that only one of the addon instances runs them.
+func leaderElectionID(id string) string {
+	if tool.Options.PolicyLabelSelector == nil {
+		return id
+	}
+
+	sum := sha256.Sum256([]byte(tool.Options.PolicyLabelSelector.String()))
+
+	return hex.EncodeToString(sum[:5]) + "." + id
+}
+
 // addControllers sets up all controllers with their respective managers
 func addControllers(
 	ctx context.Context,
 	hubCfg *rest.Config,
-	hubMgr manager.Manager,
+	primaryHubRunner *hubRunner,
 	managedMgr manager.Manager,
+	sharedMgr manager.Manager,
+	hubSourceRunners []*hubRunner,
 ) {
 	// Set up all controllers for manager on managed cluster
 	var hubClient client.Client
+	var hubRecorder events.EventRecorder
 	var specSyncRequests chan event.GenericEvent
-	var specSyncRequestsSource source.Source
 	var statusSyncRequests chan event.GenericEvent
 	var statusSyncRequestsSource source.Source
 
-	if hubMgr == nil {
+	if primaryHubRunner == nil {
 		hubCache, err := cache.New(hubCfg,
 			cache.Options{
 				ByObject: map[client.Object]cache.ByObject{
@@ -683,30 +888,26 @@ func addControllers(
 			log.Error(err, "Failed to generate a client to the hub cluster")
 			os.Exit(1)
 		}
+
+		eventBroadcasterHub, err := newHubBroadcaster(ctx, hubCfg)
+		if err != nil {
+			log.Error(err, "Unable to start event broadcaster to the hub cluster")
+			os.Exit(1)
+		}
+
+		hubRecorder = eventBroadcasterHub.NewRecorder(eventsScheme, statussync.ControllerName)
 	} else {
 		bufferSize := 100
 
 		specSyncRequests = make(chan event.GenericEvent, bufferSize)
-		specSource := source.Channel(specSyncRequests, &handler.EnqueueRequestForObject{})
-		specSyncRequestsSource = specSource
 
 		statusSyncRequests = make(chan event.GenericEvent, bufferSize)
 		statusSyncRequestsSource = source.Channel(statusSyncRequests, &handler.EnqueueRequestForObject{})
 
-		hubClient = hubMgr.GetClient()
-	}
-
-	var kubeClientHub kubernetes.Interface = kubernetes.NewForConfigOrDie(hubCfg)
-	eventBroadcasterHub := events.NewBroadcaster(&events.EventSinkImpl{Interface: kubeClientHub.EventsV1()})
-
-	err := eventBroadcasterHub.StartRecordingToSinkWithContext(ctx)
-	if err != nil {
-		log.Error(err, "Unable to start event broadcaster to the hub cluster")
-		os.Exit(1)
+		hubClient = primaryHubRunner.client
+		hubRecorder = primaryHubRunner.recorder(statussync.ControllerName)
 	}
 
-	hubRecorder := eventBroadcasterHub.NewRecorder(eventsScheme, statussync.ControllerName)
-
 	statusDepReconciler, statusDepEvents := depclient.NewControllerRuntimeSource()
 
 	statusDepWatcher, err := depclient.New(managedMgr.GetConfig(), statusDepReconciler, &depclient.Options{
@@ -718,17 +919,104 @@ func addControllers(
 		os.Exit(1)
 	}
 
+	var notifier *notifications.Dispatcher
+
+	if len(tool.Options.NotificationSinks) != 0 {
+		sinks := make([]notifications.Sink, 0, len(tool.Options.NotificationSinks))
+
+		for _, sinkValue := range tool.Options.NotificationSinks {
+			sink, err := notifications.ParseSink(
+				sinkValue, "open-cluster-management.io/clusters/"+tool.Options.ClusterNamespaceOnHub,
+			)
+			if err != nil {
+				log.Error(err, "Invalid notification sink")
+				os.Exit(1)
+			}
+
+			sinks = append(sinks, sink)
+		}
+
+		notifier = notifications.NewDispatcher(sinks...)
+
+		backoff := notifications.DefaultBackoff
+		backoff.Steps = int(tool.Options.NotificationRetries) + 1
+		notifier.Backoff = &backoff
+
+		if err := managedMgr.Add(notifier); err != nil {
+			log.Error(err, "Unable to add the notification dispatcher to the manager")
+			os.Exit(1)
+		}
+	}
+
+	var complianceReporter *complianceapi.Reporter
+
+	var clusterID string
+
+	if tool.Options.ComplianceAPIURL != "" {
+		complianceAPIURL := tool.Options.ComplianceAPIURL
+		if primaryHubRunner != nil {
+			complianceAPIURL = primaryHubRunner.complianceAPIURL
+		}
+
+		complianceReporter, err = complianceapi.NewReporter(
+			complianceAPIURL, hubCfg, int(tool.Options.ComplianceAPIBufferSize),
+		)
+		if err != nil {
+			log.Error(err, "Unable to create the compliance history API reporter")
+			os.Exit(1)
+		}
+
+		// The reporter is pointed to the new hub when the hub changes, and it gets the new hub credentials when they're
+		// reloaded.
+		if primaryHubRunner != nil {
+			primaryHubRunner.complianceReporter = complianceReporter
+		}
+
+		if err := managedMgr.Add(complianceReporter); err != nil {
+			log.Error(err, "Unable to add the compliance history API reporter to the manager")
+			os.Exit(1)
+		}
+	}
+
+	sendsComplianceEvents := slices.ContainsFunc(tool.Options.HubSources, func(source tool.HubSource) bool {
+		return source.ComplianceAPIURL != ""
+	})
+
+	if tool.Options.ComplianceAPIURL != "" || sendsComplianceEvents {
+		// The kube-system namespace UID is a stable unique identifier of the cluster.
+		kubeSystem, err := kubernetes.NewForConfigOrDie(managedMgr.GetConfig()).CoreV1().Namespaces().Get(
+			ctx, "kube-system", metav1.GetOptions{},
+		)
+		if err != nil {
+			log.Error(err, "Failed to get the cluster ID from the kube-system namespace. It won't be reported.")
+		} else {
+			clusterID = string(kubeSystem.UID)
+		}
+	}
+
 	statusReconciler := &statussync.PolicyReconciler{
-		ClusterNamespaceOnHub: tool.Options.ClusterNamespaceOnHub,
-		HubClient:             hubClient,
-		HubRecorder:           hubRecorder,
-		ManagedClient:         managedMgr.GetClient(),
-		ManagedRecorder:       managedMgr.GetEventRecorder(statussync.ControllerName),
-		DynamicWatcher:        statusDepWatcher,
-		Scheme:                managedMgr.GetScheme(),
-		ConcurrentReconciles:  int(tool.Options.EvaluationConcurrency),
-		SpecSyncRequests:      specSyncRequests,
-		OnMulticlusterhub:     tool.Options.OnMulticlusterhub,
+		ClusterNamespaceOnHub:      tool.Options.ClusterNamespaceOnHub,
+		HubClient:                  hubClient,
+		HubRecorder:                hubRecorder,
+		ManagedClient:              managedMgr.GetClient(),
+		ManagedRecorder:            managedMgr.GetEventRecorder(statussync.ControllerName),
+		DynamicWatcher:             statusDepWatcher,
+		Scheme:                     managedMgr.GetScheme(),
+		ConcurrentReconciles:       int(tool.Options.EvaluationConcurrency),
+		SpecSyncRequests:           specSyncRequests,
+		OnMulticlusterhub:          tool.Options.OnMulticlusterhub,
+		EventlessKinds:             tool.Options.EventlessTemplateKinds,
+		StaleThresholds:            tool.Options.StaleComplianceThresholds,
+		FlappingTransitions:        int(tool.Options.FlappingTransitions),
+		FlappingWindow:             tool.Options.FlappingWindow,
+		SuppressFlappingHubUpdates: tool.Options.SuppressFlappingHubUpdates,
+		MaxMessageBytes:            int(tool.Options.MaxMessageBytes),
+		MaxStatusBytes:             int(tool.Options.MaxStatusBytes),
+		Notifier:                   notifier,
+		ComplianceReporter:         complianceReporter,
+		ClusterID:                  clusterID,
+		PolicySelector:             tool.Options.PolicyLabelSelector,
+		ClusterNamespace:           tool.Options.ClusterNamespace,
 	}
 
 	go func() {
@@ -748,7 +1036,9 @@ func addControllers(
 
 	depReconciler, depEvents := depclient.NewControllerRuntimeSource()
 
-	watcher, err := depclient.New(managedMgr.GetConfig(), depReconciler, nil)
+	// The template sync and the controllers after it aren't scoped by the policy selector, so they run in the shared
+	// manager.
+	watcher, err := depclient.New(sharedMgr.GetConfig(), depReconciler, nil)
 	if err != nil {
 		log.Error(err, "Unable to create dependency watcher")
 		os.Exit(1)
@@ -757,16 +1047,17 @@ func addControllers(
 	instanceName, _ := os.Hostname() // on an error, instanceName will be empty, which is ok
 
 	templateReconciler := &templatesync.PolicyReconciler{
-		Client:               managedMgr.GetClient(),
+		Client:               sharedMgr.GetClient(),
 		DynamicWatcher:       watcher,
-		Scheme:               managedMgr.GetScheme(),
-		Config:               managedMgr.GetConfig(),
-		Recorder:             managedMgr.GetEventRecorder(templatesync.ControllerName),
+		Scheme:               sharedMgr.GetScheme(),
+		Config:               sharedMgr.GetConfig(),
+		Recorder:             sharedMgr.GetEventRecorder(templatesync.ControllerName),
 		ClusterNamespace:     tool.Options.ClusterNamespace,
-		Clientset:            kubernetes.NewForConfigOrDie(managedMgr.GetConfig()),
+		Clientset:            kubernetes.NewForConfigOrDie(sharedMgr.GetConfig()),
 		InstanceName:         instanceName,
 		DisableGkSync:        tool.Options.DisableGkSync,
 		ConcurrentReconciles: int(tool.Options.EvaluationConcurrency),
+		MaxMessageBytes:      int(tool.Options.MaxMessageBytes),
 	}
 
 	go func() {
@@ -779,11 +1070,54 @@ func addControllers(
 	// Wait until the dynamic watcher has started.
 	<-watcher.Started()
 
-	if err := templateReconciler.Setup(managedMgr, depEvents); err != nil {
+	if err := templateReconciler.Setup(sharedMgr, depEvents); err != nil {
 		log.Error(err, "Unable to create the controller", "controller", templatesync.ControllerName)
 		os.Exit(1)
 	}
 
+	if !tool.Options.DisableComplianceSummary {
+		if err := (&summary.ComplianceSummaryReconciler{
+			Client:            sharedMgr.GetClient(),
+			ClusterNamespaces: tool.ManagedNamespaces(),
+		}).SetupWithManager(sharedMgr); err != nil {
+			log.Error(err, "Unable to create the controller", "controller", summary.ControllerName)
+			os.Exit(1)
+		}
+	}
+
+	if tool.Options.EnableComplianceMetrics {
+		collector := compliancemetrics.NewCollector(
+			tool.Options.ComplianceMetricsLabels, int(tool.Options.ComplianceMetricsMaxSeries),
+		)
+
+		if err := metrics.Registry.Register(collector); err != nil {
+			log.Error(err, "Unable to register the compliance metrics")
+			os.Exit(1)
+		}
+
+		if err := (&compliancemetrics.ComplianceMetricsReconciler{
+			Client:            sharedMgr.GetClient(),
+			ClusterNamespaces: tool.ManagedNamespaces(),
+			Collector:         collector,
+		}).SetupWithManager(sharedMgr); err != nil {
+			log.Error(err, "Unable to create the controller", "controller", compliancemetrics.ControllerName)
+			os.Exit(1)
+		}
+	}
+
+	if tool.Options.OSCALOutputPath != "" {
+		if err := sharedMgr.Add(&oscal.Writer{
+			Client:            sharedMgr.GetClient(),
+			ClusterName:       tool.Options.ClusterNamespaceOnHub,
+			ClusterNamespaces: tool.ManagedNamespaces(),
+			Path:              tool.Options.OSCALOutputPath,
+			Interval:          tool.Options.OSCALInterval,
+		}); err != nil {
+			log.Error(err, "Unable to add the OSCAL assessment results writer to the manager")
+			os.Exit(1)
+		}
+	}
+
 	// When running on the hub, no more controllers are needed.
 	if tool.Options.OnMulticlusterhub {
 		return
@@ -800,42 +1134,228 @@ func addControllers(
 
 	managedRecorder := eventBroadcaster.NewRecorder(eventsScheme, specsync.ControllerName)
 
-	if err = (&specsync.PolicyReconciler{
-		HubClient:            hubClient,
+	addHubRunnerControllers(primaryHubRunner, managedMgr, managedRecorder, specSyncRequests, statusSyncRequests)
+
+	for _, sourceRunner := range hubSourceRunners {
+		addHubSourceControllers(ctx, sourceRunner, managedMgr, *statusReconciler, managedRecorder)
+	}
+}
+
+// addHubSourceControllers sets up the status sync controller of an additional hub source and its controllers on the
+// hub. The status sync is configured like the status sync of the primary hub, which is passed as statusReconciler,
+// except for the hub it syncs to and the namespace of its policies.
+func addHubSourceControllers(
+	ctx context.Context,
+	sourceRunner *hubRunner,
+	managedMgr manager.Manager,
+	statusReconciler statussync.PolicyReconciler,
+	managedRecorder events.EventRecorder,
+) {
+	hubSource := sourceRunner.source
+
+	bufferSize := 100
+
+	specSyncRequests := make(chan event.GenericEvent, bufferSize)
+
+	statusSyncRequests := make(chan event.GenericEvent, bufferSize)
+	statusSyncRequestsSource := source.Channel(statusSyncRequests, &handler.EnqueueRequestForObject{})
+
+	statusDepReconciler, statusDepEvents := depclient.NewControllerRuntimeSource()
+
+	statusDepWatcher, err := depclient.New(managedMgr.GetConfig(), statusDepReconciler, &depclient.Options{
+		EnableCache:             true,
+		DisableInitialReconcile: true,
+	})
+	if err != nil {
+		log.Error(err, "Unable to create dependency watcher", "hubSource", hubSource.Name)
+		os.Exit(1)
+	}
+
+	statusReconciler.HubClient = sourceRunner.client
+	statusReconciler.HubRecorder = sourceRunner.recorder(statussync.ControllerName)
+	statusReconciler.DynamicWatcher = statusDepWatcher
+	statusReconciler.ClusterNamespaceOnHub = hubSource.ClusterNamespaceOnHub
+	statusReconciler.ClusterNamespace = hubSource.ClusterNamespace
+	statusReconciler.SpecSyncRequests = specSyncRequests
+	statusReconciler.HubSourceName = hubSource.Name
+	// The compliance events are only sent to the compliance history API of the hub source, and not the primary hub.
+	statusReconciler.ComplianceReporter = nil
+
+	if hubSource.ComplianceAPIURL != "" {
+		complianceReporter, err := complianceapi.NewReporter(
+			hubSource.ComplianceAPIURL, sourceRunner.cfg, int(tool.Options.ComplianceAPIBufferSize),
+		)
+		if err != nil {
+			log.Error(err, "Unable to create the compliance history API reporter", "hubSource", hubSource.Name)
+			os.Exit(1)
+		}
+
+		if err := managedMgr.Add(complianceReporter); err != nil {
+			log.Error(
+				err, "Unable to add the compliance history API reporter to the manager", "hubSource", hubSource.Name,
+			)
+			os.Exit(1)
+		}
+
+		statusReconciler.ComplianceReporter = complianceReporter
+		// The reporter gets the new hub credentials when they're reloaded.
+		sourceRunner.complianceReporter = complianceReporter
+		sourceRunner.complianceAPIURL = hubSource.ComplianceAPIURL
+	}
+
+	go func() {
+		err := statusDepWatcher.Start(ctx)
+		if err != nil {
+			panic(err)
+		}
+	}()
+
+	// Wait until the dynamic watcher has started.
+	<-statusDepWatcher.Started()
+
+	if err := statusReconciler.SetupWithManager(managedMgr, statusSyncRequestsSource, statusDepEvents); err != nil {
+		log.Error(err, "unable to create controller", "controller", "Policy", "hubSource", hubSource.Name)
+		os.Exit(1)
+	}
+
+	addHubRunnerControllers(sourceRunner, managedMgr, managedRecorder, specSyncRequests, statusSyncRequests)
+}
+
+// addHubRunnerControllers sets up the spec sync and secret sync controllers on the hub manager of the hub runner, and
+// the local overrides controller that requests spec syncs of the hub source. The reconcilers are registered again on
+// each hub manager rebuilt after a reload of the hub credentials, with the client of that hub manager, so that their
+// state and request channels are kept.
+func addHubRunnerControllers(
+	runner *hubRunner,
+	managedMgr manager.Manager,
+	managedRecorder events.EventRecorder,
+	specSyncRequests chan event.GenericEvent,
+	statusSyncRequests chan event.GenericEvent,
+) {
+	hubSource := runner.source
+
+	specReconciler := &specsync.PolicyReconciler{
 		ManagedClient:        managedMgr.GetClient(),
 		ManagedRecorder:      managedRecorder,
-		Scheme:               hubMgr.GetScheme(),
-		TargetNamespace:      tool.Options.ClusterNamespace,
+		HubRecorder:          runner.recorder(specsync.ControllerName),
+		TargetNamespace:      hubSource.ClusterNamespace,
 		ConcurrentReconciles: int(tool.Options.EvaluationConcurrency),
 		StatusSyncRequests:   statusSyncRequests,
-	}).SetupWithManager(hubMgr, specSyncRequestsSource); err != nil {
-		log.Error(err, "Unable to create the controller", "controller", specsync.ControllerName)
+		HubSourceName:        hubSource.Name,
+		DriftMode:            tool.Options.SpecDriftMode,
+		PolicySelector:       tool.Options.PolicyLabelSelector,
+	}
+
+	if tool.Options.MassDeletionMaxPolicies != 0 || tool.Options.MassDeletionMaxPercent != 0 {
+		specReconciler.DeletionGuard = &specsync.DeletionGuard{
+			MaxPolicies: int(tool.Options.MassDeletionMaxPolicies),
+			MaxPercent:  int(tool.Options.MassDeletionMaxPercent),
+			Window:      tool.Options.MassDeletionWindow,
+		}
+	}
+
+	if tool.Options.PolicySignatureKeysNamespace != "" {
+		specReconciler.SignatureVerifier = &specsync.SignatureVerifier{
+			Reader:    managedMgr.GetAPIReader(),
+			Namespace: tool.Options.PolicySignatureKeysNamespace,
+		}
+	}
+
+	err := runner.setup(func(hubMgr manager.Manager) error {
+		specReconciler.HubClient = hubMgr.GetClient()
+		specReconciler.Scheme = hubMgr.GetScheme()
+
+		// The channel source of the previous hub manager stopped reading from the channel with its controller.
+		return specReconciler.SetupWithManager(
+			hubMgr, source.Channel(specSyncRequests, &handler.EnqueueRequestForObject{}),
+		)
+	})
+	if err != nil {
+		log.Error(
+			err, "Unable to create the controller", "controller", specsync.ControllerName,
+			"hubSource", hubSource.Name,
+		)
+		os.Exit(1)
+	}
+
+	if err = (&specsync.LocalOverridesReconciler{
+		ManagedClient:         managedMgr.GetClient(),
+		TargetNamespace:       hubSource.ClusterNamespace,
+		ClusterNamespaceOnHub: hubSource.ClusterNamespaceOnHub,
+		SpecSyncRequests:      specSyncRequests,
+		HubSourceName:         hubSource.Name,
+	}).SetupWithManager(managedMgr); err != nil {
+		log.Error(
+			err, "Unable to create the controller", "controller", specsync.OverridesControllerName,
+			"hubSource", hubSource.Name,
+		)
 		os.Exit(1)
 	}
 
-	if err = (&secretsync.SecretReconciler{
-		Client:               hubClient,
+	secretReconciler := &secretsync.SecretReconciler{
 		ManagedClient:        managedMgr.GetClient(),
-		Scheme:               hubMgr.GetScheme(),
-		TargetNamespace:      tool.Options.ClusterNamespace,
+		TargetNamespace:      hubSource.ClusterNamespace,
 		ConcurrentReconciles: int(tool.Options.EvaluationConcurrency),
-	}).SetupWithManager(hubMgr); err != nil {
-		log.Error(err, "Unable to create the controller", "controller", secretsync.ControllerName)
+		HubSourceName:        hubSource.Name,
+	}
+
+	err = runner.setup(func(hubMgr manager.Manager) error {
+		secretReconciler.Client = hubMgr.GetClient()
+		secretReconciler.Scheme = hubMgr.GetScheme()
+
+		return secretReconciler.SetupWithManager(hubMgr)
+	})
+	if err != nil {
+		log.Error(
+			err, "Unable to create the controller", "controller", secretsync.ControllerName,
+			"hubSource", hubSource.Name,
+		)
+		os.Exit(1)
+	}
+
+	runner.managedClient = managedMgr.GetClient()
+	runner.managedReader = managedMgr.GetAPIReader()
+	runner.specSyncRequests = specSyncRequests
+	runner.statusSyncRequests = statusSyncRequests
+
+	// The hub offline mode serves the hub snapshot, so it's only enabled when the hub snapshot is written.
+	if tool.Options.HubSnapshotInterval == 0 {
+		return
+	}
+
+	runner.offline = true
+	runner.policySelector = tool.Options.PolicyLabelSelector
+
+	err = runner.setup(func(hubMgr manager.Manager) error {
+		return hubMgr.Add(&hubsnapshot.Writer{
+			HubClient:             hubMgr.GetClient(),
+			PolicySelector:        tool.Options.PolicyLabelSelector,
+			ManagedClient:         managedMgr.GetClient(),
+			ManagedReader:         managedMgr.GetAPIReader(),
+			ClusterNamespaceOnHub: hubSource.ClusterNamespaceOnHub,
+			TargetNamespace:       hubSource.ClusterNamespace,
+			Interval:              tool.Options.HubSnapshotInterval,
+		})
+	})
+	if err != nil {
+		log.Error(err, "Unable to add the hub snapshot writer", "hubSource", hubSource.Name)
 		os.Exit(1)
 	}
 }
 
-// manageGatekeeperSyncManager ensures the gatekeeper-constraint-status-sync controller is running based on Gatekeeper's
-// installation status. The controller will be off when Gatekeeper is not installed. This is blocking until ctx
-// is closed and continuously retries to start the manager if the manager shuts down unexpectedly.
-func manageGatekeeperSyncManager(
+// manageCRDGatedManager ensures the manager started by run is running based on the presence of the crdName CRD. The
+// manager will be off when the CRD is not installed. The product and controllerName are only used for logging. This is
+// blocking until ctx is closed and continuously retries to start the manager if the manager shuts down unexpectedly.
+func manageCRDGatedManager(
 	ctx context.Context,
 	wg *sync.WaitGroup,
-	managedCfg *rest.Config,
 	dynamicClient dynamic.Interface,
-	mgrOptions manager.Options,
+	crdName string,
+	product string,
+	controllerName string,
+	run func(ctx context.Context) error,
 ) {
-	fieldSelector := "metadata.name=constrainttemplates." + utils.GvkConstraintTemplate.Group
+	fieldSelector := "metadata.name=" + crdName
 	timeout := int64(30)
 	crdGVR := schema.GroupVersionResource{
 		Group:    "apiextensions.k8s.io",
@@ -847,7 +1367,7 @@ func manageGatekeeperSyncManager(
 	var mgrCtxCancel context.CancelFunc
 	var watcher *watch.RetryWatcher
 	var mgrRunning bool
-	var gatekeeperInstalled bool
+	var installed bool
 
 	mgrCtx, mgrCtxCancel = context.WithCancel(ctx)
 
@@ -857,7 +1377,7 @@ func manageGatekeeperSyncManager(
 				ctx, metav1.ListOptions{FieldSelector: fieldSelector, TimeoutSeconds: &timeout},
 			)
 			if err != nil {
-				log.Error(err, "Failed to list the CRDs to check for the Gatekeeper installation. Will retry.")
+				log.Error(err, "Failed to list the CRDs to check for the "+product+" installation. Will retry.")
 
 				time.Sleep(time.Second)
 
@@ -876,22 +1396,22 @@ func manageGatekeeperSyncManager(
 				ctx, resourceVersion, &apiCache.ListWatch{WatchFunc: watchFunc},
 			)
 			if err != nil {
-				log.Error(err, "Failed to watch the CRDs to check for the Gatekeeper installation. Will retry.")
+				log.Error(err, "Failed to watch the CRDs to check for the "+product+" installation. Will retry.")
 
 				time.Sleep(time.Second)
 
 				continue
 			}
 
-			gatekeeperInstalled = len(listResult.Items) > 0
+			installed = len(listResult.Items) > 0
 		}
 
-		if gatekeeperInstalled && !mgrRunning {
+		if installed && !mgrRunning {
 			mgrRunning = true
 
 			wg.Add(1)
 
-			// Keep retrying to start the Gatekeeper sync manager until mgrCtx closes.
+			// Keep retrying to start the manager until mgrCtx closes.
 			go func(ctx context.Context) {
 				for {
 					select {
@@ -900,12 +1420,10 @@ func manageGatekeeperSyncManager(
 
 						return
 					default:
-						log.Info(
-							"Gatekeeper is installed. Starting the gatekeeper-constraint-status-sync controller.",
-						)
+						log.Info(product + " is installed. Starting the " + controllerName + " controller.")
 
-						err := runGatekeeperSyncManager(ctx, managedCfg, mgrOptions)
-						// The error is logged in runGatekeeperSyncManager since it has more context.
+						err := run(ctx)
+						// The error is logged in run since it has more context.
 						if err != nil {
 							time.Sleep(time.Second)
 						}
@@ -914,15 +1432,15 @@ func manageGatekeeperSyncManager(
 			}(mgrCtx)
 		}
 
-		if !gatekeeperInstalled && mgrRunning {
-			log.Info("Gatekeeper was uninstalled. Stopping the gatekeeper-constraint-status-sync controller.")
+		if !installed && mgrRunning {
+			log.Info(product + " was uninstalled. Stopping the " + controllerName + " controller.")
 
 			mgrRunning = false
 
 			mgrCtxCancel()
 
 			// Reset the context for later, otherwise the context is permanently cancelled,
-			// and the manager won't start if Gatekeeper is reinstalled.
+			// and the manager won't start if the CRD is reinstalled.
 			//nolint:fatcontext
 			mgrCtx, mgrCtxCancel = context.WithCancel(ctx)
 		}
@@ -945,13 +1463,13 @@ func manageGatekeeperSyncManager(
 			// on purpose.
 			watcher = nil
 		case result := <-watcher.ResultChan():
-			// If the CRD is added, then Gatekeeper is installed.
+			// If the CRD is added, then the product is installed.
 			//nolint:exhaustive
 			switch result.Type {
 			case apiWatch.Added:
-				gatekeeperInstalled = true
+				installed = true
 			case apiWatch.Deleted:
-				gatekeeperInstalled = false
+				installed = false
 			}
 		}
 	}
@@ -968,7 +1486,9 @@ func runGatekeeperSyncManager(ctx context.Context, managedCfg *rest.Config, mgrO
 	// Disable the metrics endpoint for this manager. Note that since they both use the global
 	// metrics registry, metrics for this manager are still exposed by the other manager.
 	mgrOptions.Metrics.BindAddress = "0"
-	mgrOptions.LeaderElectionID = "governance-policy-framework-addon3.open-cluster-management.io"
+	// The Gatekeeper constraints aren't scoped by the policy selector, so the leader election ID is shared by the addon
+	// instances.
+	mgrOptions.LeaderElectionID = gatekeeperLeaderElectionID
 	mgrOptions.Cache = cache.Options{
 		ByObject: map[client.Object]cache.ByObject{
 			&admissionregistration.ValidatingWebhookConfiguration{}: {
@@ -1042,6 +1562,7 @@ func runGatekeeperSyncManager(ctx context.Context, managedCfg *rest.Config, mgrO
 			ClientSet:        clientset,
 			ControllerName:   gatekeepersync.ControllerName,
 			InstanceName:     instanceName,
+			MaxMessageBytes:  int(tool.Options.MaxMessageBytes),
 		},
 		ConstraintsWatcher:   constraintsWatcher,
 		Scheme:               mgr.GetScheme(),
@@ -1081,3 +1602,83 @@ func runGatekeeperSyncManager(ctx context.Context, managedCfg *rest.Config, mgrO
 
 	return nil
 }
+
+// runPolicyReportManager runs a manager with the policy-report-sync controller. This blocks until the manager stops.
+func runPolicyReportManager(ctx context.Context, managedCfg *rest.Config, mgrOptions manager.Options) error {
+	healthAddress, err := getFreeLocalAddr()
+	if err != nil {
+		log.Error(err, "Unable to get a health address for the PolicyReport manager")
+
+		return err
+	}
+
+	// Disable the metrics endpoint for this manager. Note that since they all use the global
+	// metrics registry, metrics for this manager are still exposed by the main manager.
+	mgrOptions.Metrics.BindAddress = "0"
+	// The PolicyReports aren't scoped by the policy selector, so the leader election ID is shared by the addon
+	// instances.
+	mgrOptions.LeaderElectionID = policyReportLeaderElectionID
+	// The policies of each hub source are in their own namespace, which also has their PolicyReports.
+	defaultNamespaces := map[string]cache.Config{}
+
+	for _, namespace := range tool.ManagedNamespaces() {
+		defaultNamespaces[namespace] = cache.Config{}
+	}
+
+	mgrOptions.Cache = cache.Options{
+		DefaultNamespaces: defaultNamespaces,
+	}
+	mgrOptions.HealthProbeBindAddress = healthAddress
+
+	mgr, err := ctrl.NewManager(managedCfg, mgrOptions)
+	if err != nil {
+		log.Error(err, "Unable to start the PolicyReport manager")
+
+		return err
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		log.Error(err, "Unable to set up health check on the PolicyReport manager")
+
+		return err
+	}
+
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		log.Error(err, "Unable to set up ready check on the PolicyReport manager")
+
+		return err
+	}
+
+	if err = (&policyreport.PolicyReportReconciler{
+		Client:               mgr.GetClient(),
+		ClusterNamespaces:    tool.ManagedNamespaces(),
+		ConcurrentReconciles: int(tool.Options.EvaluationConcurrency),
+	}).SetupWithManager(mgr); err != nil {
+		log.Error(err, "Unable to create controller", "controller", policyreport.ControllerName)
+
+		return err
+	}
+
+	// Add the health bind address to be considered by the health proxy.
+	healthAddressesLock.Lock()
+
+	healthAddresses[healthAddress] = true
+
+	healthAddressesLock.Unlock()
+
+	// This blocks until the manager stops.
+	err = mgr.Start(ctx)
+
+	// Remove the health bind address after the manager stops.
+	healthAddressesLock.Lock()
+	delete(healthAddresses, healthAddress)
+	healthAddressesLock.Unlock()
+
+	if err != nil {
+		log.Error(err, "Unable to start the PolicyReport manager")
+
+		return err
+	}
+
+	return nil
+}
@@ -1,40 +1,82 @@
-//go:build e2e
-// +build e2e
-
-// Copyright (c) 2020 Red Hat, Inc.
 // Copyright Contributors to the Open Cluster Management project
 
 package main
 
 import (
-	"fmt"
-	"os"
 	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"open-cluster-management.io/governance-policy-framework-addon/controllers/utils"
+	"open-cluster-management.io/governance-policy-framework-addon/tool"
 )
 
-// TestRunMain wraps the main() function in order to build a test binary and collection coverage for
-// E2E/Integration tests. Controller CLI flags are also passed in here.
-func TestRunMain(t *testing.T) {
-	clusterNs := os.Getenv("E2E_CLUSTER_NAMESPACE")
-	if clusterNs == "" {
-		clusterNs = os.Getenv("MANAGED_CLUSTER_NAME")
+// TestPolicySelectorLeaderElection verifies that two addon instances with different policy selectors on the same
+// cluster namespace sync distinct policies and only compete for the leases of the controllers that aren't scoped.
+func TestPolicySelectorLeaderElection(t *testing.T) {
+	// This isn't parallel since the policy selector is a global option.
+	defer func(selector labels.Selector) { tool.Options.PolicyLabelSelector = selector }(
+		tool.Options.PolicyLabelSelector,
+	)
+
+	policies := []*policiesv1.Policy{
+		{ObjectMeta: metav1.ObjectMeta{
+			Name: "policies.a", Namespace: "managed", Labels: map[string]string{"team": "a"},
+		}},
+		{ObjectMeta: metav1.ObjectMeta{
+			Name: "policies.b", Namespace: "managed", Labels: map[string]string{"team": "b"},
+		}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "policies.c", Namespace: "managed"}},
 	}
 
-	clusterNsHub := os.Getenv("E2E_CLUSTER_NAMESPACE_ON_HUB")
-	if clusterNsHub == "" {
-		clusterNsHub = clusterNs
+	syncedBy := map[string]string{}
+	scopedLeases := map[string]string{}
+
+	for _, selector := range []string{"team=a", "team=b"} {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			t.Fatalf("Failed to parse the selector: %v", err)
+		}
+
+		tool.Options.PolicyLabelSelector = parsed
+
+		for _, plc := range policies {
+			if !utils.MatchesPolicySelector(tool.Options.PolicyLabelSelector, plc) {
+				continue
+			}
+
+			if other, ok := syncedBy[plc.Name]; ok {
+				t.Fatalf("Expected %s to only be synced by one instance but it's synced by %s and %s", plc.Name, other,
+					selector)
+			}
+
+			syncedBy[plc.Name] = selector
+		}
+
+		for _, id := range []string{managedLeaderElectionID, hubLeaderElectionID} {
+			scoped := leaderElectionID(id)
+
+			if other, ok := scopedLeases[scoped]; ok || scoped == id {
+				t.Fatalf("Expected the %s lease of %s to not be shared but it is by %q", scoped, selector, other)
+			}
+
+			scopedLeases[scoped] = selector
+		}
 	}
 
-	disableGkStr := os.Getenv("DISABLE_GK_SYNC")
-	disableGk := disableGkStr == "true"
+	if syncedBy["policies.a"] != "team=a" || syncedBy["policies.b"] != "team=b" || syncedBy["policies.c"] != "" {
+		t.Fatalf("Expected each policy to be synced by the instance that selects it but got: %v", syncedBy)
+	}
 
-	os.Args = append(
-		os.Args,
-		"--leader-elect=false",
-		fmt.Sprintf("--disable-gatekeeper-sync=%t", disableGk),
-		fmt.Sprintf("--cluster-namespace=%s", clusterNs),
-		fmt.Sprintf("--cluster-namespace-on-hub=%s", clusterNsHub),
-	)
+	// The controllers that aren't scoped use the leader election IDs of an instance without a policy selector, so that
+	// a single instance runs them whatever the selectors are.
+	tool.Options.PolicyLabelSelector = nil
 
-	main()
+	for _, id := range []string{managedLeaderElectionID, gatekeeperLeaderElectionID, policyReportLeaderElectionID} {
+		if _, ok := scopedLeases[id]; ok || leaderElectionID(id) != id {
+			t.Fatalf("Expected the %s lease to be shared by the instances", id)
+		}
+	}
 }
@@ -0,0 +1,58 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// PolicyTemplate is a policy template of a policy built by NewPolicy, along with its status details.
+type PolicyTemplate struct {
+	// APIVersion and Kind default to the ones of a ConfigurationPolicy.
+	APIVersion string
+	Kind       string
+	Name       string
+	// Spec is the JSON of the spec of the policy template, such as {"severity":"low"}. It's left out when empty.
+	Spec string
+	// ComplianceState and History are set in the status details of the policy template. The policy template has no
+	// status details when ComplianceState is empty.
+	ComplianceState policiesv1.ComplianceState
+	History         []policiesv1.ComplianceHistory
+}
+
+// NewPolicy returns a policy with the metadata, the compliance state, and the policy templates, which are in the status
+// details in the same order.
+func NewPolicy(
+	meta metav1.ObjectMeta, state policiesv1.ComplianceState, templates ...PolicyTemplate,
+) policiesv1.Policy {
+	policy := policiesv1.Policy{
+		ObjectMeta: meta,
+		Status:     policiesv1.PolicyStatus{ComplianceState: state},
+	}
+
+	for _, tmpl := range templates {
+		apiVersion, kind := tmpl.APIVersion, tmpl.Kind
+		if apiVersion == "" {
+			apiVersion, kind = "policy.open-cluster-management.io/v1", "ConfigurationPolicy"
+		}
+
+		raw := `{"apiVersion":"` + apiVersion + `","kind":"` + kind + `","metadata":{"name":"` + tmpl.Name + `"}`
+		if tmpl.Spec != "" {
+			raw += `,"spec":` + tmpl.Spec
+		}
+
+		policy.Spec.PolicyTemplates = append(policy.Spec.PolicyTemplates, &policiesv1.PolicyTemplate{
+			ObjectDefinition: runtime.RawExtension{Raw: []byte(raw + "}")},
+		})
+
+		if tmpl.ComplianceState != "" {
+			dpt := &policiesv1.DetailsPerTemplate{ComplianceState: tmpl.ComplianceState, History: tmpl.History}
+			dpt.TemplateMeta.Name = tmpl.Name
+			policy.Status.Details = append(policy.Status.Details, dpt)
+		}
+	}
+
+	return policy
+}
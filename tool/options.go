@@ -6,14 +6,32 @@ package tool
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 var log = ctrl.Log.WithName("cmd")
 
+// HubSource is an additional hub that policies are synced from, into its own namespace on the managed cluster.
+type HubSource struct {
+	// The name of the hub source, which is appended to the names of its controllers.
+	Name                  string
+	HubConfigFilePathName string
+	ClusterNamespaceOnHub string
+	// The namespace on the managed cluster that the policies of the hub source are synced to.
+	ClusterNamespace string
+	// The compliance events endpoint of the compliance history API on the hub source. It's optional.
+	ComplianceAPIURL string
+}
+
 // PolicySpecSyncOptions for command line flag parsing
 type SyncerOptions struct {
 	ClusterNamespaceOnHub     string
@@ -33,9 +51,75 @@ type SyncerOptions struct {
 	EvaluationConcurrency uint8
 	ClientQPS             float32
 	ClientBurst           uint32
+	// The policy template kinds whose compliance is only read from the template's status.history field instead of
+	// from Kubernetes Events.
+	EventlessTemplateKinds []schema.GroupKind
+	// The maximum age of the most recent compliance history entry of a policy template, per template kind, before
+	// its compliance is considered stale.
+	StaleComplianceThresholds map[schema.GroupKind]time.Duration
+	// The number of compliance transitions of a policy template within FlappingWindow at which its compliance is
+	// considered flapping. A value of 0 disables flapping detection.
+	FlappingTransitions        uint
+	FlappingWindow             time.Duration
+	SuppressFlappingHubUpdates bool
+	// The byte budgets of each compliance message and of the status details of a policy. A value of 0 means no limit.
+	MaxMessageBytes uint
+	MaxStatusBytes  uint
+	// The type=destination values of the sinks that are notified of compliance state changes.
+	NotificationSinks   []string
+	NotificationRetries uint
+	// The compliance events endpoint of the compliance history API on the hub. Compliance events are only sent to it
+	// when it's set.
+	ComplianceAPIURL        string
+	ComplianceAPIBufferSize uint
+	// Disables the compliance summary ConfigMap in the cluster namespace.
+	DisableComplianceSummary bool
+	// Enables generating wg-policy PolicyReports from the policy statuses when the PolicyReport CRD is installed.
+	EnablePolicyReports bool
+	// The file that the OSCAL assessment results are periodically written to. They are only written when it's set.
+	OSCALOutputPath string
+	OSCALInterval   time.Duration
+	// Enables the per-policy, per-template and per-annotation compliance metrics. The Policy labels in
+	// ComplianceMetricsLabels are added as metric labels, and ComplianceMetricsMaxSeries limits the number of
+	// per-policy and per-template series, where 0 means no limit.
+	EnableComplianceMetrics    bool
+	ComplianceMetricsLabels    []string
+	ComplianceMetricsMaxSeries uint
+	// Whether local changes to the replicated policies are reverted (strict) or only reported (report).
+	SpecDriftMode string
+	// The mass-deletion guard holds the deletions of replicated policies when more than MassDeletionMaxPolicies
+	// policies, or more than MassDeletionMaxPercent percent of them, would be deleted within MassDeletionWindow. A zero
+	// threshold is disabled.
+	MassDeletionMaxPolicies uint
+	MassDeletionMaxPercent  uint
+	MassDeletionWindow      time.Duration
+	// The namespace of the Secret with the public keys that the hub policy signatures are verified against. The
+	// signatures aren't verified when it's empty.
+	PolicySignatureKeysNamespace string
+	// The label selector of the hub policies that are synced by this addon instance. It's nil when all policies are
+	// synced.
+	PolicyLabelSelector labels.Selector
+	// The hubs that policies are synced from in addition to the hub of HubConfigFilePathName.
+	HubSources []HubSource
+	// The kubeconfigs of the standby hubs of HubConfigFilePathName, in the order of preference.
+	HubFailoverConfigFiles []string
+	// The compliance events endpoints of the compliance history API on the standby hubs, in the order of
+	// HubFailoverConfigFiles. When it's empty, ComplianceAPIURL is used for every hub.
+	HubFailoverComplianceAPIURLs []string
+	// The period that the active hub must be unreachable before failing over, and that a preferred hub must be
+	// reachable before failing back.
+	HubFailoverPeriod time.Duration
+	// The interval at which the snapshot of the hub is written to the managed cluster. 0 disables the snapshot.
+	HubSnapshotInterval time.Duration
 }
 
-var disableSpecSync bool
+var (
+	disableSpecSync           bool
+	eventlessTemplateKinds    []string
+	policyLabelSelector       string
+	hubSources                []string
+	staleComplianceThresholds map[string]string
+)
 
 // Options default value
 var Options = SyncerOptions{}
@@ -65,6 +149,42 @@ func ProcessFlags() {
 		"Configuration file pathname to hub kubernetes cluster",
 	)
 
+	flag.StringArrayVar(
+		&Options.HubFailoverConfigFiles,
+		"hub-failover-configfile",
+		[]string{},
+		"Configuration file pathname to a standby of the hub kubernetes cluster, which is used when the hub is "+
+			"unreachable. This flag can be repeated, in which case the standby hubs are used in the given order.",
+	)
+
+	flag.StringArrayVar(
+		&Options.HubFailoverComplianceAPIURLs,
+		"hub-failover-compliance-api-url",
+		[]string{},
+		"The compliance events endpoint of the compliance history API on a standby hub, which the compliance events "+
+			"are sent to when the addon is connected to it. This flag must be repeated once for each "+
+			"--hub-failover-configfile flag, in the same order. When it's not set, the --compliance-api-url flag is "+
+			"used for every hub.",
+	)
+
+	flag.DurationVar(
+		&Options.HubFailoverPeriod,
+		"hub-failover-period",
+		5*time.Minute,
+		"The period that the active hub must be unreachable before failing over to a standby hub, and that a "+
+			"preferred hub must be reachable before failing back to it.",
+	)
+
+	flag.DurationVar(
+		&Options.HubSnapshotInterval,
+		"hub-snapshot-interval",
+		0,
+		"The interval at which a snapshot of the replicated policies and the policy encryption key on the hub is "+
+			"written to the cluster namespace on the managed cluster, such as 5m. The snapshot is used to start in a "+
+			"hub offline mode when the hub is unreachable. The snapshot and the hub offline mode are disabled if not "+
+			"set.",
+	)
+
 	flag.StringVar(
 		&Options.ManagedConfigFilePathName,
 		"managed-cluster-configfile",
@@ -101,6 +221,122 @@ func ProcessFlags() {
 		"If enabled, Gatekeeper object syncing will be entirely disabled.",
 	)
 
+	flag.BoolVar(
+		&Options.DisableComplianceSummary,
+		"disable-compliance-summary",
+		false,
+		"If enabled, the compliance summary ConfigMap in the cluster namespace will not be maintained.",
+	)
+
+	flag.BoolVar(
+		&Options.EnablePolicyReports,
+		"enable-policy-reports",
+		false,
+		"If enabled, wg-policy PolicyReports are generated from the policy statuses when the PolicyReport CRD is "+
+			"installed.",
+	)
+
+	flag.StringVar(
+		&Options.OSCALOutputPath,
+		"oscal-output-path",
+		"",
+		"The file that the OSCAL assessment results of the policies are periodically written to. If not set, they "+
+			"are not written.",
+	)
+
+	flag.DurationVar(
+		&Options.OSCALInterval,
+		"oscal-interval",
+		time.Hour,
+		"The interval at which the OSCAL assessment results are written to the --oscal-output-path.",
+	)
+
+	flag.BoolVar(
+		&Options.EnableComplianceMetrics,
+		"enable-compliance-metrics",
+		false,
+		"If enabled, the compliance of each policy and policy template, and the compliance counts per value of the "+
+			"standards, categories and controls annotations, are exposed as metrics.",
+	)
+
+	flag.StringSliceVar(
+		&Options.ComplianceMetricsLabels,
+		"compliance-metrics-label-allowlist",
+		[]string{},
+		"A comma-separated list of Policy label keys that are added as label_<key> labels to the per-policy and "+
+			"per-template compliance metrics.",
+	)
+
+	flag.UintVar(
+		&Options.ComplianceMetricsMaxSeries,
+		"compliance-metrics-max-series",
+		10000,
+		"The maximum number of per-policy and per-template compliance metric series. Additional series are dropped. "+
+			"A value of 0 means no limit.",
+	)
+
+	flag.StringVar(
+		&Options.SpecDriftMode,
+		"spec-drift-mode",
+		"strict",
+		"How changes to replicated policies on the managed cluster without a change on the hub are handled. They "+
+			"are always reported, and with strict they are reverted while with report they are left until the "+
+			"policy changes on the hub.",
+	)
+
+	flag.UintVar(
+		&Options.MassDeletionMaxPolicies,
+		"mass-deletion-max-policies",
+		0,
+		"The maximum number of replicated policies that are deleted within the --mass-deletion-window when their "+
+			"hub policies are missing. Further deletions are held until they are acknowledged. A value of 0 means "+
+			"no limit.",
+	)
+
+	flag.UintVar(
+		&Options.MassDeletionMaxPercent,
+		"mass-deletion-max-percent",
+		0,
+		"The maximum percentage of the replicated policies that are deleted within the --mass-deletion-window when "+
+			"their hub policies are missing. Further deletions are held until they are acknowledged. A value of 0 "+
+			"means no limit.",
+	)
+
+	flag.DurationVar(
+		&Options.MassDeletionWindow,
+		"mass-deletion-window",
+		10*time.Minute,
+		"The window of the --mass-deletion-max-policies and --mass-deletion-max-percent limits.",
+	)
+
+	flag.StringVar(
+		&Options.PolicySignatureKeysNamespace,
+		"policy-signature-keys-namespace",
+		"",
+		"The namespace on the managed cluster of the governance-policy-signature-keys Secret, whose PEM encoded "+
+			"public keys the signatures of the hub policies are verified against. Unsigned policies and policies "+
+			"with an invalid signature are not synced. The signatures are not verified when this is empty.",
+	)
+
+	flag.StringVar(
+		&policyLabelSelector,
+		"policy-label-selector",
+		"",
+		"A label selector of the hub policies that the spec sync and status sync of this addon instance handle, so "+
+			"that several addon instances can each sync a subset of the policies. All policies are synced if not set.",
+	)
+
+	flag.StringArrayVar(
+		&hubSources,
+		"hub-source",
+		[]string{},
+		"An additional hub that policies are synced from, in the name=<name>,kubeconfig=<path>,"+
+			"cluster-namespace-on-hub=<namespace>,cluster-namespace=<namespace>[,compliance-api-url=<url>] format. "+
+			"The cluster-namespace is the namespace on the managed cluster that its policies are synced to, which "+
+			"must differ from the other hubs. The optional compliance-api-url is the compliance events endpoint of "+
+			"the compliance history API on that hub. This flag can be repeated.",
+	)
+
 	flag.BoolVar(
 		&Options.EnableLeaderElection,
 		"leader-elect",
@@ -153,6 +389,99 @@ func ProcessFlags() {
 		"The maximum burst before client requests will be throttled. "+
 			"Will scale with concurrency, if not explicitly set.",
 	)
+
+	flag.StringSliceVar(
+		&eventlessTemplateKinds,
+		"eventless-template-kinds",
+		[]string{},
+		"A comma-separated list of policy template kinds in the Kind.group format (e.g. "+
+			"ConfigurationPolicy.policy.open-cluster-management.io) whose compliance is only read from the "+
+			"template's status.history field instead of from Kubernetes Events. A kind can also opt in with the "+
+			"'policy.open-cluster-management.io/compliance-events=disabled' label on its CRD.",
+	)
+
+	flag.StringToStringVar(
+		&staleComplianceThresholds,
+		"stale-compliance-thresholds",
+		map[string]string{},
+		"A comma-separated list of Kind.group=duration pairs (e.g. "+
+			"ConfigurationPolicy.policy.open-cluster-management.io=1h). When the most recent compliance history entry "+
+			"of a policy template of that kind is older than the duration, its compliance is reported as stale.",
+	)
+
+	flag.UintVar(
+		&Options.FlappingTransitions,
+		"flapping-transitions",
+		0,
+		"The number of compliance transitions of a policy template within the --flapping-window at which its "+
+			"compliance is considered flapping. The maximum is 9 since the compliance history has 10 entries. "+
+			"A value of 0 disables flapping detection.",
+	)
+
+	flag.DurationVar(
+		&Options.FlappingWindow,
+		"flapping-window",
+		10*time.Minute,
+		"The window in which the compliance transitions of a policy template are counted to detect flapping.",
+	)
+
+	flag.BoolVar(
+		&Options.SuppressFlappingHubUpdates,
+		"suppress-flapping-hub-updates",
+		false,
+		"If enabled, the hub status of flapping policy templates is not updated until they stabilize. The hub "+
+			"policy still gets the compliance-flapping annotation of the templates.",
+	)
+
+	flag.UintVar(
+		&Options.MaxMessageBytes,
+		"max-compliance-message-bytes",
+		0,
+		"The byte budget of each compliance message in the policy status. Longer messages are truncated with a "+
+			"'(N more violations truncated)' suffix, and the full message is only kept in the compliance event. "+
+			"The minimum is 256 and a value of 0 means no limit.",
+	)
+
+	flag.UintVar(
+		&Options.MaxStatusBytes,
+		"max-policy-status-bytes",
+		0,
+		"The byte budget of the status details of a policy. The oldest compliance history entries are removed first "+
+			"and then the most recent messages are truncated. The minimum is 1024 and a value of 0 means no limit.",
+	)
+
+	flag.StringSliceVar(
+		&Options.NotificationSinks,
+		"notification-sinks",
+		[]string{},
+		"A comma-separated list of sinks that are notified when the compliance state of a policy or policy template "+
+			"changes, in the type=destination format. The types are webhook (a URL that receives the notification as "+
+			"JSON), cloudevents (a URL that receives a CloudEvents v1.0 HTTP binary mode event), and file (a file "+
+			"that the notification is appended to as a JSON line, or - for stdout).",
+	)
+
+	flag.UintVar(
+		&Options.NotificationRetries,
+		"notification-retries",
+		5,
+		"The number of times a notification is retried with an exponential backoff when a sink fails.",
+	)
+
+	flag.StringVar(
+		&Options.ComplianceAPIURL,
+		"compliance-api-url",
+		"",
+		"The compliance events endpoint of the compliance history API on the hub (e.g. "+
+			"https://compliance-history.example.com/api/v1/compliance-events). When set, every compliance event is "+
+			"sent to it, authenticated with the credentials of the hub kubeconfig.",
+	)
+
+	flag.UintVar(
+		&Options.ComplianceAPIBufferSize,
+		"compliance-api-buffer-size",
+		1000,
+		"The number of compliance events buffered while the compliance history API is unavailable.",
+	)
 }
 
 func ProcessAndParse(flagset *flag.FlagSet) error {
@@ -202,6 +531,117 @@ func ProcessAndParse(flagset *flag.FlagSet) error {
 		Options.ClusterNamespaceOnHub = Options.ClusterNamespace
 	}
 
+	for _, kind := range eventlessTemplateKinds {
+		gk := schema.ParseGroupKind(kind)
+		if gk.Group == "" {
+			return fmt.Errorf("the --eventless-template-kinds value %s must be in the Kind.group format", kind)
+		}
+
+		Options.EventlessTemplateKinds = append(Options.EventlessTemplateKinds, gk)
+	}
+
+	Options.StaleComplianceThresholds = make(map[schema.GroupKind]time.Duration, len(staleComplianceThresholds))
+
+	for kind, thresholdStr := range staleComplianceThresholds {
+		gk := schema.ParseGroupKind(kind)
+		if gk.Group == "" {
+			return fmt.Errorf("the --stale-compliance-thresholds kind %s must be in the Kind.group format", kind)
+		}
+
+		threshold, err := time.ParseDuration(thresholdStr)
+		if err != nil || threshold <= 0 {
+			return fmt.Errorf(
+				"the --stale-compliance-thresholds value for %s must be a positive duration: %s", kind, thresholdStr,
+			)
+		}
+
+		Options.StaleComplianceThresholds[gk] = threshold
+	}
+
+	if Options.FlappingTransitions > 9 {
+		return errors.New("the --flapping-transitions flag must be at most 9")
+	}
+
+	if Options.HubFailoverPeriod <= 0 {
+		return errors.New("the --hub-failover-period flag must be a positive duration")
+	}
+
+	if Options.HubSnapshotInterval < 0 {
+		return errors.New("the --hub-snapshot-interval flag must not be negative")
+	}
+
+	if len(Options.HubFailoverConfigFiles) != 0 && Options.OnMulticlusterhub {
+		return errors.New("the --hub-failover-configfile flag can't be used with --on-multicluster-hub")
+	}
+
+	if len(Options.HubFailoverComplianceAPIURLs) != 0 {
+		if Options.ComplianceAPIURL == "" {
+			return errors.New("the --hub-failover-compliance-api-url flag requires the --compliance-api-url flag")
+		}
+
+		if len(Options.HubFailoverComplianceAPIURLs) != len(Options.HubFailoverConfigFiles) {
+			return errors.New(
+				"the --hub-failover-compliance-api-url flag must be set once for each --hub-failover-configfile flag",
+			)
+		}
+	}
+
+	if Options.FlappingWindow <= 0 {
+		return errors.New("the --flapping-window flag must be a positive duration")
+	}
+
+	if Options.OSCALInterval <= 0 {
+		return errors.New("the --oscal-interval flag must be a positive duration")
+	}
+
+	if policyLabelSelector != "" {
+		selector, err := labels.Parse(policyLabelSelector)
+		if err != nil {
+			return fmt.Errorf("the --policy-label-selector flag is invalid: %w", err)
+		}
+
+		Options.PolicyLabelSelector = selector
+	}
+
+	Options.HubSources = nil
+
+	for _, value := range hubSources {
+		source, err := parseHubSource(value)
+		if err != nil {
+			return err
+		}
+
+		Options.HubSources = append(Options.HubSources, source)
+	}
+
+	if err := validateHubSources(); err != nil {
+		return err
+	}
+
+	if Options.SpecDriftMode != "strict" && Options.SpecDriftMode != "report" {
+		return errors.New("the --spec-drift-mode flag must be strict or report")
+	}
+
+	if Options.MassDeletionMaxPercent > 100 {
+		return errors.New("the --mass-deletion-max-percent flag must be at most 100")
+	}
+
+	if Options.PolicySignatureKeysNamespace != "" && Options.OnMulticlusterhub {
+		return errors.New("the --policy-signature-keys-namespace flag can't be used with --on-multicluster-hub")
+	}
+
+	if Options.MassDeletionWindow <= 0 {
+		return errors.New("the --mass-deletion-window flag must be a positive duration")
+	}
+
+	if Options.MaxMessageBytes != 0 && Options.MaxMessageBytes < 256 {
+		return errors.New("the --max-compliance-message-bytes flag must be at least 256")
+	}
+
+	if Options.MaxStatusBytes != 0 && Options.MaxStatusBytes < 1024 {
+		return errors.New("the --max-policy-status-bytes flag must be at least 1024")
+	}
+
 	var found bool
 
 	// Get hubconfig to talk to hub apiserver
@@ -225,3 +665,108 @@ func ProcessAndParse(flagset *flag.FlagSet) error {
 
 	return nil
 }
+
+// parseHubSource parses a --hub-source value. The cluster-namespace-on-hub defaults to the cluster-namespace.
+func parseHubSource(value string) (HubSource, error) {
+	source := HubSource{}
+
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(pair, "=")
+		if !found || val == "" {
+			return source, fmt.Errorf(
+				"the --hub-source value %s must be a comma-separated list of key=value pairs", value,
+			)
+		}
+
+		switch key {
+		case "name":
+			source.Name = val
+		case "kubeconfig":
+			source.HubConfigFilePathName = val
+		case "cluster-namespace-on-hub":
+			source.ClusterNamespaceOnHub = val
+		case "cluster-namespace":
+			source.ClusterNamespace = val
+		case "compliance-api-url":
+			source.ComplianceAPIURL = val
+		default:
+			return source, fmt.Errorf("the --hub-source value %s has the unknown key %s", value, key)
+		}
+	}
+
+	if source.Name == "" || source.HubConfigFilePathName == "" || source.ClusterNamespace == "" {
+		return source, fmt.Errorf(
+			"the --hub-source value %s must set the name, kubeconfig and cluster-namespace", value,
+		)
+	}
+
+	// The name is part of the controller names and the leader election ID.
+	if errs := validation.IsDNS1123Label(source.Name); len(errs) != 0 {
+		return source, fmt.Errorf("the --hub-source name %s is invalid: %s", source.Name, strings.Join(errs, "; "))
+	}
+
+	if source.ClusterNamespaceOnHub == "" {
+		source.ClusterNamespaceOnHub = source.ClusterNamespace
+	}
+
+	return source, nil
+}
+
+// validateHubSources ensures that each hub source has a unique name and namespace on the managed cluster.
+func validateHubSources() error {
+	if len(Options.HubSources) != 0 && Options.OnMulticlusterhub {
+		return errors.New("the --hub-source flag can't be used with --on-multicluster-hub")
+	}
+
+	names := map[string]bool{}
+	namespaces := map[string]bool{Options.ClusterNamespace: true}
+
+	for _, source := range Options.HubSources {
+		if names[source.Name] {
+			return fmt.Errorf("the --hub-source name %s is used more than once", source.Name)
+		}
+
+		if namespaces[source.ClusterNamespace] {
+			return fmt.Errorf(
+				"the --hub-source cluster-namespace %s is already used by another hub", source.ClusterNamespace,
+			)
+		}
+
+		names[source.Name] = true
+		namespaces[source.ClusterNamespace] = true
+	}
+
+	return nil
+}
+
+// HubConfigFiles returns the kubeconfigs of the hub and its standby hubs, in the order of preference.
+func HubConfigFiles() []string {
+	return append([]string{Options.HubConfigFilePathName}, Options.HubFailoverConfigFiles...)
+}
+
+// HubComplianceAPIURLs returns the compliance events endpoints of the hub and its standby hubs, in the order of
+// HubConfigFiles.
+func HubComplianceAPIURLs() []string {
+	urls := []string{Options.ComplianceAPIURL}
+
+	for i := range Options.HubFailoverConfigFiles {
+		if len(Options.HubFailoverComplianceAPIURLs) == 0 {
+			urls = append(urls, Options.ComplianceAPIURL)
+		} else {
+			urls = append(urls, Options.HubFailoverComplianceAPIURLs[i])
+		}
+	}
+
+	return urls
+}
+
+// ManagedNamespaces returns the namespaces on the managed cluster that policies are synced to from the hubs.
+func ManagedNamespaces() []string {
+	namespaces := []string{Options.ClusterNamespace}
+
+	for _, source := range Options.HubSources {
+		namespaces = append(namespaces, source.ClusterNamespace)
+	}
+
+	return namespaces
+}